@@ -3,19 +3,133 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 )
 
 // Config representa la configuración de la aplicación
 type Config struct {
-	// VPNConfigPath es la ruta al archivo .ovpn seleccionado por el usuario
-	VPNConfigPath string `json:"vpn_config_path"`
+	// VPNProfiles son los perfiles VPN del usuario, en el orden en que se muestran
+	VPNProfiles []*VPNProfile `json:"vpn_profiles,omitempty"`
 
-	// Versión de la configuración (para futuras migraciones)
+	// CurrentProfile es el ID del perfil seleccionado: el último elegido o
+	// con el que se conectó
+	CurrentProfile string `json:"current_profile,omitempty"`
+
+	// VPNConfigPath es el archivo .ovpn de la versión 1 (un solo perfil).
+	// La migración a la versión 2 lo convierte en un perfil de VPNProfiles.
+	VPNConfigPath string `json:"vpn_config_path,omitempty"`
+
+	// Reconnect controla la reconexión automática cuando el túnel se cae
+	Reconnect ReconnectConfig `json:"reconnect"`
+
+	// Profiles guarda las preferencias de cada perfil, por ruta del .ovpn
+	Profiles map[string]*ProfileSettings `json:"profiles,omitempty"`
+
+	// DangerousProfiles es la política para perfiles que ejecutan comandos
+	// como root (DangerousConfirm o DangerousBlock)
+	DangerousProfiles string `json:"dangerous_profiles,omitempty"`
+
+	// Version es la versión de config.json (ver CurrentVersion y migrations)
 	Version int `json:"version"`
 }
 
+// ReconnectConfig contiene las preferencias de reconexión automática
+type ReconnectConfig struct {
+	// Disabled desactiva la reconexión automática
+	Disabled bool `json:"disabled,omitempty"`
+
+	// MaxAttempts es el número máximo de reintentos (0 = valor por defecto)
+	MaxAttempts int `json:"max_attempts,omitempty"`
+}
+
+// Qué hacer con los perfiles que ejecutan comandos como root (up, down, plugin...)
+const (
+	DangerousConfirm = ""      // Por defecto: pedir confirmación explícita al elegirlos
+	DangerousBlock   = "block" // No permitir usarlos
+)
+
+// Modos de DNS de un perfil
+const (
+	DNSSplit  = ""       // Por defecto: solo los dominios de la VPN usan sus DNS
+	DNSGlobal = "global" // Todas las consultas usan los DNS de la VPN
+	DNSOff    = "off"    // No se modifica el DNS del sistema
+)
+
+// Qué hacer con la VPN al suspender el equipo
+const (
+	SleepRestart   = ""          // Por defecto: al volver se reinicia el túnel
+	SleepReconnect = "reconnect" // Desconectar antes de suspender y reconectar al volver
+	SleepKeep      = "keep"      // No hacer nada
+)
+
+// Qué hacer con la VPN al bloquear la pantalla
+const (
+	LockKeep       = ""           // Por defecto: no hacer nada
+	LockDisconnect = "disconnect" // Desconectar
+	LockReconnect  = "reconnect"  // Desconectar y reconectar al desbloquear
+)
+
+// ProfileSettings contiene las preferencias de un perfil .ovpn
+type ProfileSettings struct {
+	// DNS es el modo de DNS (DNSSplit, DNSGlobal o DNSOff)
+	DNS string `json:"dns,omitempty"`
+
+	// Routes modifica las rutas que empuja el servidor (split tunnelling)
+	Routes RouteRules `json:"routes"`
+
+	// KillSwitch bloquea el tráfico fuera de la VPN mientras la sesión está activa
+	KillSwitch bool `json:"kill_switch,omitempty"`
+
+	// KillSwitchAllowLAN permite la red local con el kill switch activo
+	KillSwitchAllowLAN bool `json:"kill_switch_allow_lan,omitempty"`
+
+	// Health configura el chequeo de salud del túnel
+	Health HealthSettings `json:"health"`
+
+	// OnSleep es la política al suspender (SleepRestart, SleepReconnect o SleepKeep)
+	OnSleep string `json:"on_sleep,omitempty"`
+
+	// OnLock es la política al bloquear la pantalla (LockKeep, LockDisconnect o LockReconnect)
+	OnLock string `json:"on_lock,omitempty"`
+
+	// ApprovedHash es el SHA-256 del archivo .ovpn cuando el usuario aceptó que
+	// ejecuta comandos como administrador. Si el archivo cambia se vuelve a pedir.
+	ApprovedHash string `json:"approved_hash,omitempty"`
+}
+
+// HealthSettings son las preferencias del chequeo de salud de un perfil
+type HealthSettings struct {
+	// Probe es el tipo de sondeo: "icmp", "tcp", "http" o vacío (desactivado)
+	Probe string `json:"probe,omitempty"`
+
+	// Target es el host, host:puerto o URL que se sondea
+	Target string `json:"target,omitempty"`
+
+	// IntervalSeconds es el tiempo entre sondeos (0 = valor por defecto)
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+
+	// Failures es el número de fallos seguidos para considerar la conexión degradada (0 = valor por defecto)
+	Failures int `json:"failures,omitempty"`
+
+	// Restart reinicia OpenVPN cuando la conexión se degrada
+	Restart bool `json:"restart,omitempty"`
+}
+
+// RouteRules son las reglas de rutas de un perfil. Los destinos son
+// prefijos CIDR, IPs o nombres de host.
+type RouteRules struct {
+	// IgnorePushed ignora las rutas del servidor; solo se usan las de Include
+	IgnorePushed bool `json:"ignore_pushed,omitempty"`
+
+	// Include son destinos que siempre van por el túnel
+	Include []string `json:"include,omitempty"`
+
+	// Exclude son destinos que nunca van por el túnel
+	Exclude []string `json:"exclude,omitempty"`
+}
+
 var (
 	// ErrConfigNotFound se usa cuando no existe configuración guardada
 	ErrConfigNotFound = errors.New("configuration not found")
@@ -44,24 +158,42 @@ func getConfigPath() (string, error) {
 	return filepath.Join(configDir, "config.json"), nil
 }
 
-// Load carga la configuración desde disco
+// Load carga la configuración desde disco. Una configuración de una versión
+// anterior se respalda (config.json.v<N>.bak) y se migra; una de una versión
+// más nueva retorna ErrNewerVersion y un archivo dañado, *ParseError.
 func Load() (*Config, error) {
 	configPath, err := getConfigPath()
 	if err != nil {
 		return nil, err
 	}
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
-	data, err := os.ReadFile(configPath)
+	data, version, err := readConfigFile(configPath)
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			return nil, ErrConfigNotFound
-		}
 		return nil, err
 	}
+	if data == nil {
+		return nil, ErrConfigNotFound
+	}
+	if version > CurrentVersion {
+		return nil, newerVersionError(version)
+	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+		return nil, &ParseError{Path: configPath, Err: err}
+	}
+	config.Version = version
+
+	if version < CurrentVersion {
+		config.migrate()
+		if err := config.write(configPath); err != nil {
+			return nil, fmt.Errorf("no se pudo guardar la configuración migrada: %w", err)
+		}
 	}
 
 	return &config, nil
@@ -73,33 +205,51 @@ func (c *Config) Save() error {
 	if err != nil {
 		return err
 	}
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	// Asegurar que la versión está configurada
-	if c.Version == 0 {
-		c.Version = 1
+	return c.write(configPath)
+}
+
+// write reemplaza config.json de forma atómica. No pisa una configuración
+// más nueva ni una que no se pudo leer; una más antigua se respalda antes.
+func (c *Config) write(configPath string) error {
+	old, version, err := readConfigFile(configPath)
+	if err != nil {
+		return err
+	}
+	if version > CurrentVersion {
+		return newerVersionError(version)
+	}
+	if old != nil && version < CurrentVersion {
+		if err := backup(configPath, old, version); err != nil {
+			return err
+		}
 	}
 
+	c.Version = CurrentVersion
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(configPath, data, 0644)
-}
-
-// HasVPNConfig verifica si hay un archivo .ovpn configurado
-func (c *Config) HasVPNConfig() bool {
-	return c.VPNConfigPath != ""
+	return writeFileAtomic(configPath, data, 0644)
 }
 
-// IsVPNConfigValid verifica si el archivo .ovpn configurado existe
-func (c *Config) IsVPNConfigValid() bool {
-	if !c.HasVPNConfig() {
-		return false
+// SettingsFor retorna las preferencias del perfil, creándolas si no existen
+func (c *Config) SettingsFor(profilePath string) *ProfileSettings {
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*ProfileSettings)
 	}
-
-	_, err := os.Stat(c.VPNConfigPath)
-	return err == nil
+	settings, ok := c.Profiles[profilePath]
+	if !ok || settings == nil {
+		settings = &ProfileSettings{}
+		c.Profiles[profilePath] = settings
+	}
+	return settings
 }
 
 // GetConfigDir retorna el directorio de configuración
@@ -117,9 +267,18 @@ func GetConfigDir() (string, error) {
 	return filepath.Join(configHome, "NavTunnel"), nil
 }
 
+// ProfilesDir retorna el directorio donde se guardan los perfiles importados
+func ProfilesDir() (string, error) {
+	dir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "profiles"), nil
+}
+
 // Default retorna una configuración por defecto
 func Default() *Config {
 	return &Config{
-		Version: 1,
+		Version: CurrentVersion,
 	}
 }
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockConfig toma un bloqueo exclusivo (flock) sobre config.json.lock para
+// que dos instancias no lean y escriban la configuración a la vez. Se usa un
+// archivo aparte porque Save reemplaza config.json con un rename.
+func lockConfig(configPath string) (func(), error) {
+	f, err := os.OpenFile(configPath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package config
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockConfig toma un bloqueo exclusivo (LockFileEx) sobre config.json.lock
+// para que dos instancias no lean y escriban la configuración a la vez. Se usa
+// un archivo aparte porque Save reemplaza config.json con un rename.
+func lockConfig(configPath string) (func(), error) {
+	f, err := os.OpenFile(configPath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	handle := windows.Handle(f.Fd())
+	overlapped := new(windows.Overlapped)
+	if err := windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		windows.UnlockFileEx(handle, 0, 1, 0, overlapped)
+		f.Close()
+	}, nil
+}
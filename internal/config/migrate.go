@@ -0,0 +1,158 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CurrentVersion es la versión de config.json que escribe esta versión de
+// NavTunnel. Debe ser len(migrations)+1.
+const CurrentVersion = 2
+
+// migrations[i] actualiza una configuración de la versión i+1 a la i+2.
+// Los campos que se dejan de usar se conservan en Config (solo lectura) para
+// que la migración pueda leerlos.
+var migrations = []func(c *Config){
+	(*Config).migrateVPNConfigPath, // 1 → 2: vpn_config_path pasa a vpn_profiles
+}
+
+var (
+	// ErrNewerVersion se usa cuando config.json lo escribió una versión más
+	// nueva de NavTunnel: no se lee ni se sobrescribe
+	ErrNewerVersion = errors.New("la configuración es de una versión más nueva de NavTunnel")
+)
+
+// ParseError indica que config.json existe pero no se pudo interpretar
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s no es válido: %v", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newerVersionError describe una configuración de una versión más nueva
+func newerVersionError(version int) error {
+	return fmt.Errorf("%w (versión %d; esta versión entiende hasta la %d)", ErrNewerVersion, version, CurrentVersion)
+}
+
+// migrate actualiza la configuración paso a paso hasta CurrentVersion
+func (c *Config) migrate() {
+	for c.Version < CurrentVersion {
+		migrations[c.Version-1](c)
+		c.Version++
+	}
+}
+
+// migrateVPNConfigPath convierte la configuración de un solo archivo
+// (vpn_config_path) en un perfil de la lista
+func (c *Config) migrateVPNConfigPath() {
+	if c.VPNConfigPath == "" {
+		return
+	}
+	p := c.AddProfile(c.VPNConfigPath)
+	if c.CurrentProfile == "" {
+		c.CurrentProfile = p.ID
+	}
+	c.VPNConfigPath = ""
+}
+
+// readConfigFile lee config.json y su versión (0 si no existe). Una
+// configuración sin versión es de la primera.
+func readConfigFile(path string) ([]byte, int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, 0, &ParseError{Path: path, Err: err}
+	}
+	if header.Version < 0 {
+		return nil, 0, &ParseError{Path: path, Err: fmt.Errorf("versión %d no válida", header.Version)}
+	}
+	if header.Version == 0 {
+		header.Version = 1
+	}
+	return data, header.Version, nil
+}
+
+// backupPath es donde se guarda la copia de config.json antes de migrarlo
+func backupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// backup copia config.json antes de reemplazarlo por una versión más nueva.
+// Si ya hay una copia de esa versión se conserva: es la original.
+func backup(path string, data []byte, version int) error {
+	target := backupPath(path, version)
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	if err := writeFileAtomic(target, data, 0644); err != nil {
+		return fmt.Errorf("no se pudo respaldar la configuración: %w", err)
+	}
+	return nil
+}
+
+// MoveAside renombra config.json (por ejemplo, si está dañado) para empezar
+// con la configuración por defecto sin perderlo. Retorna la ruta nueva.
+func MoveAside() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	unlock, err := lockConfig(configPath)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	target := fmt.Sprintf("%s.%s.bad", configPath, time.Now().Format("20060102-150405"))
+	if err := os.Rename(configPath, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// writeFileAtomic escribe el archivo completo o no lo toca: escribe en un
+// temporal del mismo directorio y lo renombra
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
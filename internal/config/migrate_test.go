@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// configFile prepara XDG_CONFIG_HOME con el config.json indicado y retorna su ruta
+func configFile(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	path := filepath.Join(home, "NavTunnel", "config.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestMigrationsMatchCurrentVersion(t *testing.T) {
+	if CurrentVersion != len(migrations)+1 {
+		t.Errorf("CurrentVersion = %d, pero hay %d migraciones", CurrentVersion, len(migrations))
+	}
+}
+
+func TestLoadBacksUpBeforeMigrating(t *testing.T) {
+	legacy := `{"vpn_config_path": "/vpn/oficina.ovpn", "version": 1}`
+	path := configFile(t, legacy)
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != CurrentVersion {
+		t.Errorf("Version = %d, se esperaba %d", c.Version, CurrentVersion)
+	}
+
+	saved, err := os.ReadFile(backupPath(path, 1))
+	if err != nil {
+		t.Fatalf("no se respaldó la configuración: %v", err)
+	}
+	if string(saved) != legacy {
+		t.Errorf("respaldo = %s", saved)
+	}
+
+	// La configuración migrada queda guardada
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"vpn_profiles"`) || strings.Contains(string(data), "vpn_config_path") {
+		t.Errorf("config.json no se migró:\n%s", data)
+	}
+}
+
+func TestLoadWithoutVersion(t *testing.T) {
+	configFile(t, `{"vpn_config_path": "/vpn/oficina.ovpn"}`)
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.VPNProfiles) != 1 {
+		t.Errorf("perfiles = %d, se esperaba 1", len(c.VPNProfiles))
+	}
+}
+
+func TestNewerVersionIsNotOverwritten(t *testing.T) {
+	newer := `{"vpn_profiles": [], "version": 99}`
+	path := configFile(t, newer)
+
+	if _, err := Load(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Load() error = %v, se esperaba ErrNewerVersion", err)
+	}
+	if err := Default().Save(); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Save() error = %v, se esperaba ErrNewerVersion", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("se sobrescribió la configuración:\n%s", data)
+	}
+}
+
+func TestCorruptConfig(t *testing.T) {
+	path := configFile(t, `{"vpn_profiles": [`)
+
+	var parseErr *ParseError
+	if _, err := Load(); !errors.As(err, &parseErr) {
+		t.Fatalf("Load() error = %v, se esperaba *ParseError", err)
+	}
+	if err := Default().Save(); !errors.As(err, &parseErr) {
+		t.Errorf("Save() error = %v, no debe pisar un archivo dañado", err)
+	}
+
+	moved, err := MoveAside()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(moved); string(data) != `{"vpn_profiles": [` {
+		t.Errorf("el archivo apartado no conserva el contenido: %s", data)
+	}
+	if err := Default().Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err != nil {
+		t.Errorf("Load() después de apartar: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInvalidVersion(t *testing.T) {
+	configFile(t, `{"vpn_profiles": [], "version": -1}`)
+
+	var parseErr *ParseError
+	if _, err := Load(); !errors.As(err, &parseErr) {
+		t.Errorf("Load() error = %v, se esperaba *ParseError", err)
+	}
+	if err := Default().Save(); !errors.As(err, &parseErr) {
+		t.Errorf("Save() error = %v, no debe pisar un archivo con versión no válida", err)
+	}
+}
+
+func TestSaveIsAtomic(t *testing.T) {
+	path := configFile(t, "")
+	c := Default()
+	c.AddProfile("/vpn/prod.ovpn")
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Solo quedan config.json y el archivo de bloqueo: ningún temporal
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, " ") != "config.json config.json.lock" {
+		t.Errorf("archivos = %v", names)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.VPNProfiles) != 1 || loaded.Version != CurrentVersion {
+		t.Errorf("configuración cargada = %+v", loaded)
+	}
+}
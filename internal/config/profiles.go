@@ -0,0 +1,178 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// VPNProfile es un perfil VPN guardado: un archivo .ovpn con un nombre visible
+type VPNProfile struct {
+	// ID identifica el perfil aunque cambie de nombre
+	ID string `json:"id"`
+
+	// Name es el nombre que se muestra en la ventana y en el tray
+	Name string `json:"name"`
+
+	// Path es la ruta al archivo .ovpn
+	Path string `json:"path"`
+
+	// CreatedAt es cuándo se agregó el perfil
+	CreatedAt time.Time `json:"created_at"`
+
+	// LastUsed es la última vez que se conectó con el perfil (cero si nunca)
+	LastUsed time.Time `json:"last_used"`
+}
+
+// Exists indica si el archivo .ovpn del perfil existe
+func (p *VPNProfile) Exists() bool {
+	_, err := os.Stat(p.Path)
+	return err == nil
+}
+
+// ProfileName arma el nombre visible de un perfil a partir de su archivo
+func ProfileName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// Profile retorna el perfil con ese ID (nil si no existe)
+func (c *Config) Profile(id string) *VPNProfile {
+	for _, p := range c.VPNProfiles {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// ProfileByPath retorna el perfil que usa ese archivo (nil si no hay)
+func (c *Config) ProfileByPath(path string) *VPNProfile {
+	for _, p := range c.VPNProfiles {
+		if p.Path == path {
+			return p
+		}
+	}
+	return nil
+}
+
+// Current retorna el perfil seleccionado. Si CurrentProfile ya no existe se
+// usa el último con el que se conectó, o el primero de la lista (nil si no
+// hay perfiles).
+func (c *Config) Current() *VPNProfile {
+	if p := c.Profile(c.CurrentProfile); p != nil {
+		return p
+	}
+	var current *VPNProfile
+	for _, p := range c.VPNProfiles {
+		if current == nil || p.LastUsed.After(current.LastUsed) {
+			current = p
+		}
+	}
+	return current
+}
+
+// AddProfile agrega el archivo a la lista de perfiles. Si ya estaba, retorna
+// el perfil existente.
+func (c *Config) AddProfile(path string) *VPNProfile {
+	if p := c.ProfileByPath(path); p != nil {
+		return p
+	}
+	p := &VPNProfile{
+		ID:        c.newProfileID(),
+		Name:      c.uniqueProfileName(ProfileName(path)),
+		Path:      path,
+		CreatedAt: time.Now(),
+	}
+	c.VPNProfiles = append(c.VPNProfiles, p)
+	return p
+}
+
+// DuplicateProfile agrega una copia del perfil id que usa el archivo path
+// (una copia del .ovpn original) con las mismas preferencias
+func (c *Config) DuplicateProfile(id, path string) (*VPNProfile, error) {
+	src := c.Profile(id)
+	if src == nil {
+		return nil, fmt.Errorf("no existe el perfil %s", id)
+	}
+	if c.ProfileByPath(path) != nil {
+		return nil, fmt.Errorf("%s ya es otro perfil", filepath.Base(path))
+	}
+
+	p := &VPNProfile{
+		ID:        c.newProfileID(),
+		Name:      c.uniqueProfileName(src.Name + " (copia)"),
+		Path:      path,
+		CreatedAt: time.Now(),
+	}
+	if settings, ok := c.Profiles[src.Path]; ok && settings != nil {
+		*c.SettingsFor(path) = settings.clone()
+	}
+	c.VPNProfiles = append(c.VPNProfiles, p)
+	return p, nil
+}
+
+// RemoveProfile quita el perfil de la lista junto con sus preferencias y lo
+// retorna (nil si no existía). El archivo .ovpn no se toca.
+func (c *Config) RemoveProfile(id string) *VPNProfile {
+	for i, p := range c.VPNProfiles {
+		if p.ID != id {
+			continue
+		}
+		c.VPNProfiles = append(c.VPNProfiles[:i], c.VPNProfiles[i+1:]...)
+		if c.ProfileByPath(p.Path) == nil {
+			delete(c.Profiles, p.Path)
+		}
+		if c.CurrentProfile == id {
+			c.CurrentProfile = ""
+		}
+		return p
+	}
+	return nil
+}
+
+// UseProfile marca el perfil como seleccionado y usado ahora
+func (c *Config) UseProfile(id string) {
+	if p := c.Profile(id); p != nil {
+		p.LastUsed = time.Now()
+		c.CurrentProfile = id
+	}
+}
+
+// uniqueProfileName agrega un número al nombre si otro perfil ya lo usa
+func (c *Config) uniqueProfileName(name string) string {
+	taken := make(map[string]bool)
+	for _, p := range c.VPNProfiles {
+		taken[p.Name] = true
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	return unique
+}
+
+// newProfileID genera un ID corto que no usa ningún otro perfil
+func (c *Config) newProfileID() string {
+	for {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			return fmt.Sprintf("%x", time.Now().UnixNano())
+		}
+		id := hex.EncodeToString(b)
+		if c.Profile(id) == nil {
+			return id
+		}
+	}
+}
+
+// clone retorna una copia de las preferencias que no comparte las listas de rutas
+func (s *ProfileSettings) clone() ProfileSettings {
+	copied := *s
+	copied.Routes.Include = append([]string(nil), s.Routes.Include...)
+	copied.Routes.Exclude = append([]string(nil), s.Routes.Exclude...)
+	return copied
+}
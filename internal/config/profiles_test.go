@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadMigratesVPNConfigPath(t *testing.T) {
+	configFile(t, `{
+  "vpn_config_path": "/home/ana/vpn/oficina.ovpn",
+  "profiles": {"/home/ana/vpn/oficina.ovpn": {"dns": "global", "routes": {}, "health": {}}},
+  "version": 1
+}`)
+
+	c, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.VPNConfigPath != "" {
+		t.Errorf("VPNConfigPath = %q, se esperaba que se migrara", c.VPNConfigPath)
+	}
+	if len(c.VPNProfiles) != 1 {
+		t.Fatalf("perfiles = %d, se esperaba 1", len(c.VPNProfiles))
+	}
+	p := c.Current()
+	if p == nil || p.Name != "oficina" || p.Path != "/home/ana/vpn/oficina.ovpn" || p.ID == "" {
+		t.Fatalf("perfil actual = %+v", p)
+	}
+	if c.SettingsFor(p.Path).DNS != DNSGlobal {
+		t.Error("se perdieron las preferencias del perfil")
+	}
+}
+
+func TestProfileList(t *testing.T) {
+	c := Default()
+	prod := c.AddProfile("/vpn/prod.ovpn")
+	staging := c.AddProfile("/vpn/staging.ovpn")
+	if again := c.AddProfile("/vpn/prod.ovpn"); again != prod {
+		t.Error("el mismo archivo se agregó dos veces")
+	}
+	other := c.AddProfile("/otra/prod.ovpn")
+	if other.Name != "prod 2" {
+		t.Errorf("nombre = %q, se esperaba \"prod 2\"", other.Name)
+	}
+
+	// Sin perfil seleccionado se usa el último con el que se conectó
+	staging.LastUsed = time.Now()
+	if c.Current() != staging {
+		t.Errorf("perfil actual = %+v, se esperaba staging", c.Current())
+	}
+	c.UseProfile(prod.ID)
+	if c.CurrentProfile != prod.ID || c.Current() != prod {
+		t.Errorf("perfil actual = %+v, se esperaba prod", c.Current())
+	}
+
+	c.SettingsFor(prod.Path).Routes.Include = []string{"10.0.0.0/8"}
+	dup, err := c.DuplicateProfile(prod.ID, "/vpn/prod-copia.ovpn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dup.Name != "prod (copia)" || dup.ID == prod.ID {
+		t.Errorf("copia = %+v", dup)
+	}
+	c.SettingsFor(dup.Path).Routes.Include[0] = "192.168.0.0/16"
+	if got := c.SettingsFor(prod.Path).Routes.Include[0]; got != "10.0.0.0/8" {
+		t.Errorf("la copia comparte las rutas del original: %s", got)
+	}
+
+	if removed := c.RemoveProfile(prod.ID); removed != prod {
+		t.Fatalf("RemoveProfile = %+v", removed)
+	}
+	if c.CurrentProfile != "" || c.Profile(prod.ID) != nil {
+		t.Error("el perfil eliminado sigue seleccionado")
+	}
+	if _, ok := c.Profiles[prod.Path]; ok {
+		t.Error("quedaron las preferencias del perfil eliminado")
+	}
+	if len(c.VPNProfiles) != 3 {
+		t.Errorf("perfiles = %d, se esperaban 3", len(c.VPNProfiles))
+	}
+}
@@ -0,0 +1,16 @@
+package core
+
+import "github.com/lavp2393/navtunnel/internal/profile"
+
+// StaticChallenge es la directiva static-challenge declarada en un perfil .ovpn
+type StaticChallenge = profile.StaticChallenge
+
+// ReadStaticChallenge busca la directiva static-challenge en el perfil.
+// Retorna nil si el perfil no la declara.
+func ReadStaticChallenge(ovpnPath string) (*StaticChallenge, error) {
+	p, err := profile.ParseFile(ovpnPath)
+	if err != nil {
+		return nil, err
+	}
+	return p.Auth().Challenge, nil
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lavp2393/navtunnel/internal/mgmt"
+)
+
+// ConnectionInfo describe la configuración de red de la sesión conectada.
+// Se arma con los campos de >STATE: y con las líneas de log de OpenVPN
+// (PUSH_REPLY, "link remote" y la apertura de la interfaz del túnel).
+type ConnectionInfo struct {
+	LocalIP       string // IPv4 asignada al túnel
+	LocalIPv6     string // IPv6 asignada al túnel
+	RemoteIP      string // Servidor VPN
+	RemotePort    string
+	Protocol      string   // "udp" o "tcp"
+	Device        string   // Interfaz del túnel (tun0, utun3, ...)
+	Routes        []string // Rutas empujadas por el servidor ("10.0.0.0/8", "fd00::/64")
+	DNSServers    []string
+	SearchDomains []string
+
+	pushContinues bool // El último PUSH_REPLY anunció que sigue otro (push-continuation 2)
+}
+
+// TunnelIP retorna la IP del túnel, preferentemente la IPv4
+func (c ConnectionInfo) TunnelIP() string {
+	if c.LocalIP != "" {
+		return c.LocalIP
+	}
+	return c.LocalIPv6
+}
+
+// clone retorna una copia que no comparte los slices
+func (c ConnectionInfo) clone() ConnectionInfo {
+	c.Routes = append([]string(nil), c.Routes...)
+	c.DNSServers = append([]string(nil), c.DNSServers...)
+	c.SearchDomains = append([]string(nil), c.SearchDomains...)
+	return c
+}
+
+var (
+	// pushReplyRe reconoce la línea de log con las opciones empujadas por el servidor
+	pushReplyRe = regexp.MustCompile(`PUSH_REPLY,([^']*)`)
+
+	// linkRemoteRe reconoce la línea con el protocolo y la dirección del servidor:
+	// "UDPv4 link remote: [AF_INET]203.0.113.1:1194", "TCPv4_CLIENT link remote: ..."
+	linkRemoteRe = regexp.MustCompile(`\b(UDP|TCP)(?:v[46])?(?:_CLIENT)? link remote: (?:\[AF_INET6?\])?(\S+)`)
+)
+
+// applyLog actualiza la información con una línea de log de OpenVPN
+func (c *ConnectionInfo) applyLog(line string) {
+	if match := tunOpenedRe.FindStringSubmatch(line); match != nil {
+		c.Device = match[1]
+	}
+	if match := linkRemoteRe.FindStringSubmatch(line); match != nil {
+		c.Protocol = strings.ToLower(match[1])
+		if host, port, ok := splitHostPort(match[2]); ok {
+			c.RemoteIP, c.RemotePort = host, port
+		}
+	}
+	if match := pushReplyRe.FindStringSubmatch(line); match != nil {
+		c.applyPushReply(match[1])
+	}
+}
+
+// applyPushReply interpreta las opciones de un PUSH_REPLY. Cada PUSH_REPLY
+// reemplaza a los anteriores (OpenVPN los vuelve a recibir tras reiniciar),
+// salvo que sea la continuación de uno partido en varios mensajes.
+func (c *ConnectionInfo) applyPushReply(options string) {
+	if !c.pushContinues {
+		c.Routes, c.DNSServers, c.SearchDomains = nil, nil, nil
+	}
+	c.pushContinues = false
+
+	for _, option := range strings.Split(options, ",") {
+		fields := strings.Fields(option)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "route":
+			mask := ""
+			if len(fields) > 2 {
+				mask = fields[2]
+			}
+			c.Routes = append(c.Routes, routePrefix(fields[1], mask))
+		case "route-ipv6":
+			c.Routes = append(c.Routes, fields[1])
+		case "push-continuation":
+			c.pushContinues = fields[1] == "2"
+		case "ifconfig":
+			c.LocalIP = fields[1]
+		case "ifconfig-ipv6":
+			c.LocalIPv6, _, _ = strings.Cut(fields[1], "/")
+		case "dhcp-option":
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "DNS", "DNS6":
+				c.DNSServers = append(c.DNSServers, fields[2])
+			case "DOMAIN", "DOMAIN-SEARCH":
+				c.SearchDomains = append(c.SearchDomains, fields[2])
+			}
+		case "dns":
+			// OpenVPN 2.6: "dns server 0 address 10.0.0.2" y "dns search-domains a b"
+			switch {
+			case fields[1] == "server" && len(fields) >= 5 && fields[3] == "address":
+				c.DNSServers = append(c.DNSServers, fields[4:]...)
+			case fields[1] == "search-domains":
+				c.SearchDomains = append(c.SearchDomains, fields[2:]...)
+			}
+		}
+	}
+}
+
+// applyState actualiza la información con los campos de un >STATE:
+func (c *ConnectionInfo) applyState(state mgmt.State) {
+	if state.LocalIP != "" {
+		c.LocalIP = state.LocalIP
+	}
+	if state.LocalIPv6 != "" {
+		c.LocalIPv6 = state.LocalIPv6
+	}
+	if state.RemoteIP != "" {
+		c.RemoteIP = state.RemoteIP
+	}
+	if state.RemotePort != "" {
+		c.RemotePort = state.RemotePort
+	}
+}
+
+// routePrefix convierte "10.0.0.0 255.0.0.0" en "10.0.0.0/8"
+func routePrefix(network, mask string) string {
+	if mask == "" {
+		return network
+	}
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return network + "/" + mask
+	}
+	ones, bits := net.IPMask(ip).Size()
+	if bits == 0 {
+		return network + "/" + mask // Máscara no contigua
+	}
+	return network + "/" + strconv.Itoa(ones)
+}
+
+// splitHostPort separa "ip:puerto", también para IPv6 sin corchetes ("fd00::1:1194")
+func splitHostPort(addr string) (string, string, bool) {
+	i := strings.LastIndex(addr, ":")
+	if i <= 0 || i == len(addr)-1 {
+		return "", "", false
+	}
+	return strings.Trim(addr[:i], "[]"), addr[i+1:], true
+}
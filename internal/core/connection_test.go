@@ -0,0 +1,88 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lavp2393/navtunnel/internal/mgmt"
+)
+
+func TestConnectionInfo(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		state string
+		want  ConnectionInfo
+	}{
+		{
+			name: "dhcp-option y rutas IPv4",
+			lines: []string{
+				"2024-05-01 10:00:00 UDPv4 link remote: [AF_INET]203.0.113.1:1194",
+				"2024-05-01 10:00:01 PUSH: Received control message: 'PUSH_REPLY,route 10.0.0.0 255.0.0.0," +
+					"route 192.168.50.0 255.255.255.0,dhcp-option DNS 10.0.0.2,dhcp-option DNS 10.0.0.3," +
+					"dhcp-option DOMAIN corp.example.com,ifconfig 10.8.0.6 255.255.255.0,peer-id 0'",
+				"2024-05-01 10:00:01 TUN/TAP device tun0 opened",
+			},
+			state: "1714557601,CONNECTED,SUCCESS,10.8.0.6,203.0.113.1,1194,,,",
+			want: ConnectionInfo{
+				LocalIP:       "10.8.0.6",
+				RemoteIP:      "203.0.113.1",
+				RemotePort:    "1194",
+				Protocol:      "udp",
+				Device:        "tun0",
+				Routes:        []string{"10.0.0.0/8", "192.168.50.0/24"},
+				DNSServers:    []string{"10.0.0.2", "10.0.0.3"},
+				SearchDomains: []string{"corp.example.com"},
+			},
+		},
+		{
+			name: "opciones dns de OpenVPN 2.6 e IPv6 por TCP",
+			lines: []string{
+				"TCPv6_CLIENT link remote: [AF_INET6]2001:db8::1:443",
+				"PUSH: Received control message: 'PUSH_REPLY,route-ipv6 fd00:10::/64," +
+					"dns server 0 address fd00::53 10.0.0.53,dns search-domains a.example b.example," +
+					"ifconfig-ipv6 fd00::1000/64 fd00::1'",
+			},
+			want: ConnectionInfo{
+				LocalIPv6:     "fd00::1000",
+				RemoteIP:      "2001:db8::1",
+				RemotePort:    "443",
+				Protocol:      "tcp",
+				Routes:        []string{"fd00:10::/64"},
+				DNSServers:    []string{"fd00::53", "10.0.0.53"},
+				SearchDomains: []string{"a.example", "b.example"},
+			},
+		},
+		{
+			name: "PUSH_REPLY partido con push-continuation",
+			lines: []string{
+				"PUSH: Received control message: 'PUSH_REPLY,route 10.1.0.0 255.255.0.0,push-continuation 2'",
+				"PUSH: Received control message: 'PUSH_REPLY,route 10.2.0.0 255.255.0.0,push-continuation 1'",
+			},
+			want: ConnectionInfo{Routes: []string{"10.1.0.0/16", "10.2.0.0/16"}},
+		},
+		{
+			name: "un PUSH_REPLY nuevo reemplaza al anterior",
+			lines: []string{
+				"PUSH: Received control message: 'PUSH_REPLY,route 10.1.0.0 255.255.0.0,dhcp-option DNS 10.1.0.2'",
+				"PUSH: Received control message: 'PUSH_REPLY,route 10.2.0.0 255.255.0.0'",
+			},
+			want: ConnectionInfo{Routes: []string{"10.2.0.0/16"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info ConnectionInfo
+			for _, line := range tt.lines {
+				info.applyLog(line)
+			}
+			if tt.state != "" {
+				info.applyState(mgmt.ParseState(tt.state))
+			}
+			if got := info.clone(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// DNSMode indica cómo se aplican al sistema los DNS empujados por el servidor
+type DNSMode string
+
+const (
+	DNSOff    DNSMode = ""       // No se toca el DNS del sistema
+	DNSSplit  DNSMode = "split"  // Los dominios empujados solo enrutan sus consultas al túnel
+	DNSGlobal DNSMode = "global" // Todas las consultas van a los DNS del túnel
+)
+
+// SessionOptions agrupa las preferencias del perfil que afectan a la sesión
+type SessionOptions struct {
+	DNS        DNSMode
+	Routes     RouteRules
+	KillSwitch KillSwitchOptions
+	Health     HealthCheck
+}
+
+// applyDNS aplica los DNS de la sesión conectada a la interfaz del túnel.
+// Se repite en cada CONNECTED porque OpenVPN puede recrear la interfaz al reiniciar.
+func (m *Manager) applyDNS() {
+	if m.options.DNS == DNSOff || m.plat == nil {
+		return
+	}
+
+	info := m.ConnectionInfo()
+	if len(info.DNSServers) == 0 {
+		return
+	}
+	if info.Device == "" {
+		m.emit(Event{Type: EventLogLine, Message: "No se configuró el DNS de la VPN: se desconoce la interfaz del túnel"})
+		return
+	}
+
+	err := m.plat.ConfigureDNS(platform.DNSConfig{
+		Device:      info.Device,
+		Servers:     info.DNSServers,
+		Domains:     info.SearchDomains,
+		RoutingOnly: m.options.DNS != DNSGlobal,
+	})
+	if err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo configurar el DNS de la VPN: " + err.Error()})
+		return
+	}
+
+	m.mu.Lock()
+	m.dnsDevice = info.Device
+	m.mu.Unlock()
+
+	msg := "DNS de la VPN configurado en " + info.Device + ": " + strings.Join(info.DNSServers, ", ")
+	if len(info.SearchDomains) > 0 {
+		msg += " (" + strings.Join(info.SearchDomains, ", ") + ")"
+	}
+	m.emit(Event{Type: EventLogLine, Message: msg})
+}
+
+// revertDNS deshace la configuración DNS aplicada por applyDNS. Si OpenVPN
+// ya terminó, la interfaz desapareció y systemd-resolved la olvidó solo.
+func (m *Manager) revertDNS() {
+	m.mu.Lock()
+	device := m.dnsDevice
+	m.dnsDevice = ""
+	m.mu.Unlock()
+
+	if device == "" || m.plat == nil {
+		return
+	}
+	if err := m.plat.RevertDNS(device); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo restaurar el DNS: " + err.Error()})
+	}
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// ManagementEndpoint describe dónde escucha el management interface de una sesión
+// y cómo autenticarse contra él
+type ManagementEndpoint struct {
+	ID           string // Identifica la sesión en los nombres de archivo
+	Network      string // "unix" o "tcp"
+	Address      string // Ruta del socket o 127.0.0.1:puerto
+	Port         int    // Solo para "tcp"
+	PasswordFile string // Archivo que lee OpenVPN (0600, en el directorio privado)
+	Password     string // Contraseña que envía el cliente
+}
+
+// NewManagementEndpoint prepara un endpoint nuevo para una sesión.
+// En Linux y macOS usa un socket Unix dentro del directorio privado del usuario;
+// en Windows un puerto TCP de loopback. Siempre se protege con contraseña.
+func NewManagementEndpoint(plat platform.Platform) (*ManagementEndpoint, error) {
+	dir, err := plat.EnsureRuntimeDir()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo preparar el directorio de ejecución: %w", err)
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &ManagementEndpoint{
+		ID:           id,
+		PasswordFile: filepath.Join(dir, "mgmt-"+id+".pw"),
+		Password:     password,
+	}
+
+	if runtime.GOOS == "windows" {
+		port, err := findFreePort()
+		if err != nil {
+			return nil, err
+		}
+		e.Network = "tcp"
+		e.Port = port
+		e.Address = fmt.Sprintf("127.0.0.1:%d", port)
+	} else {
+		e.Network = "unix"
+		e.Address = filepath.Join(dir, "mgmt-"+id+".sock")
+	}
+
+	if err := os.WriteFile(e.PasswordFile, []byte(password+"\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("no se pudo escribir la contraseña del management interface: %w", err)
+	}
+
+	return e, nil
+}
+
+// StartConfig completa la configuración de arranque de la plataforma con este endpoint
+func (e *ManagementEndpoint) StartConfig(config platform.StartConfig) platform.StartConfig {
+	config.MgmtPasswordFile = e.PasswordFile
+	if e.Network == "unix" {
+		config.MgmtSocket = e.Address
+	} else {
+		config.MgmtPort = e.Port
+	}
+	return config
+}
+
+// RecordPath retorna la ruta del registro de la sesión (ver SessionRecord)
+func (e *ManagementEndpoint) RecordPath() string {
+	return filepath.Join(filepath.Dir(e.PasswordFile), "session-"+e.ID+".json")
+}
+
+// Cleanup elimina el socket, el archivo de contraseña y el registro de la sesión
+func (e *ManagementEndpoint) Cleanup() {
+	if e.Network == "unix" {
+		os.Remove(e.Address)
+	}
+	os.Remove(e.PasswordFile)
+	os.Remove(e.RecordPath())
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
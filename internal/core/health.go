@@ -0,0 +1,269 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// HealthProbe es el tipo de sondeo del chequeo de salud del túnel
+type HealthProbe string
+
+const (
+	ProbeNone HealthProbe = ""     // Sin chequeo de salud
+	ProbeICMP HealthProbe = "icmp" // Eco ICMP a un host
+	ProbeTCP  HealthProbe = "tcp"  // Conexión TCP a host:puerto
+	ProbeHTTP HealthProbe = "http" // GET a una URL (cualquier respuesta HTTP cuenta como éxito)
+)
+
+// Valores por defecto del chequeo de salud
+const (
+	defaultHealthInterval = 30 * time.Second
+	defaultHealthTimeout  = 5 * time.Second
+	defaultHealthFailures = 3
+	healthWindow          = 20 // Sondeos considerados para la pérdida
+)
+
+// HealthCheck configura el sondeo periódico de un host interno a través del
+// túnel. Detecta túneles "conectados" que no pasan tráfico mucho antes que
+// el ping-restart de OpenVPN.
+type HealthCheck struct {
+	Probe    HealthProbe
+	Target   string        // Host (icmp), host:puerto (tcp) o URL (http)
+	Interval time.Duration // 0 usa 30s
+	Timeout  time.Duration // 0 usa 5s
+	Failures int           // Fallos seguidos para marcar la conexión degradada (0 usa 3)
+	Restart  bool          // Reinicio suave de OpenVPN (SIGUSR1) al degradarse
+}
+
+// withDefaults completa los valores no configurados
+func (h HealthCheck) withDefaults() HealthCheck {
+	if h.Interval <= 0 {
+		h.Interval = defaultHealthInterval
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = defaultHealthTimeout
+	}
+	if h.Timeout > h.Interval {
+		h.Timeout = h.Interval
+	}
+	if h.Failures <= 0 {
+		h.Failures = defaultHealthFailures
+	}
+	return h
+}
+
+// Validate revisa que el destino corresponda al tipo de sondeo
+func (h HealthCheck) Validate() error {
+	switch h.Probe {
+	case ProbeNone:
+		return nil
+	case ProbeICMP:
+		if h.Target == "" || h.Target[0] == '-' {
+			return fmt.Errorf("chequeo de salud: host inválido: %q", h.Target)
+		}
+	case ProbeTCP:
+		host, port, err := net.SplitHostPort(h.Target)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("chequeo de salud: se espera host:puerto, no %q", h.Target)
+		}
+	case ProbeHTTP:
+		u, err := url.Parse(h.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("chequeo de salud: URL inválida: %q", h.Target)
+		}
+	default:
+		return fmt.Errorf("chequeo de salud: tipo de sondeo desconocido: %q", h.Probe)
+	}
+	return nil
+}
+
+// Health resume los últimos sondeos del chequeo de salud
+type Health struct {
+	Latency    time.Duration // Último sondeo exitoso
+	AvgLatency time.Duration // Promedio de los sondeos exitosos recientes
+	Loss       float64       // Fracción de sondeos recientes fallidos (0 a 1)
+	Failures   int           // Fallos seguidos
+	Degraded   bool
+	LastError  string
+}
+
+// healthTracker acumula los resultados de los sondeos recientes
+type healthTracker struct {
+	mu        sync.Mutex
+	results   []time.Duration // Latencias; -1 es un fallo
+	failures  int
+	degraded  bool
+	lastError string
+}
+
+// record agrega el resultado de un sondeo
+func (t *healthTracker) record(latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		latency = -1
+		t.failures++
+		t.lastError = err.Error()
+	} else {
+		t.failures = 0
+		t.lastError = ""
+	}
+	t.results = append(t.results, latency)
+	if len(t.results) > healthWindow {
+		t.results = t.results[len(t.results)-healthWindow:]
+	}
+}
+
+// setDegraded marca o desmarca la conexión como degradada
+func (t *healthTracker) setDegraded(degraded bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.degraded = degraded
+}
+
+// reset descarta los sondeos (tras una reconexión el túnel es otro)
+func (t *healthTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.results, t.failures, t.degraded, t.lastError = nil, 0, false, ""
+}
+
+// snapshot retorna el resumen de los sondeos recientes
+func (t *healthTracker) snapshot() Health {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := Health{Failures: t.failures, Degraded: t.degraded, LastError: t.lastError}
+	var (
+		total time.Duration
+		ok    int
+	)
+	for _, latency := range t.results {
+		if latency < 0 {
+			continue
+		}
+		total += latency
+		ok++
+		h.Latency = latency
+	}
+	if ok > 0 {
+		h.AvgLatency = total / time.Duration(ok)
+	}
+	if len(t.results) > 0 {
+		h.Loss = float64(len(t.results)-ok) / float64(len(t.results))
+	}
+	return h
+}
+
+// probeHealth ejecuta un sondeo y retorna su latencia
+func (m *Manager) probeHealth(check HealthCheck) (time.Duration, error) {
+	start := time.Now()
+	switch check.Probe {
+	case ProbeICMP:
+		if m.plat == nil {
+			return 0, errors.New("sin plataforma para ICMP")
+		}
+		return m.plat.Ping(check.Target, check.Timeout)
+
+	case ProbeTCP:
+		conn, err := net.DialTimeout("tcp", check.Target, check.Timeout)
+		if err != nil {
+			return 0, err
+		}
+		conn.Close()
+		return time.Since(start), nil
+
+	case ProbeHTTP:
+		ctx, cancel := context.WithTimeout(context.Background(), check.Timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.Target, nil)
+		if err != nil {
+			return 0, err
+		}
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return time.Since(start), nil
+	}
+	return 0, fmt.Errorf("tipo de sondeo desconocido: %q", check.Probe)
+}
+
+// monitorHealth sondea el túnel mientras está arriba. Tras Failures fallos
+// seguidos la sesión pasa a Degraded (y, si se pidió, OpenVPN se reinicia);
+// el primer sondeo exitoso la devuelve a Connected.
+func (m *Manager) monitorHealth() {
+	defer m.wg.Done()
+
+	check := m.options.Health.withDefaults()
+	if check.Probe == ProbeNone {
+		return
+	}
+
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		state := m.State()
+		if !state.TunnelUp() {
+			continue
+		}
+
+		latency, err := m.probeHealth(check)
+		m.health.record(latency, err)
+		health := m.health.snapshot()
+
+		switch {
+		case err == nil && state == StateDegraded:
+			m.health.setDegraded(false)
+			m.transition(StateConnected)
+			m.emit(Event{Type: EventLogLine, Message: "Chequeo de salud: el túnel vuelve a responder"})
+		case err != nil && state == StateConnected && health.Failures >= check.Failures:
+			m.health.setDegraded(true)
+			m.transition(StateDegraded)
+			m.emit(Event{Type: EventLogLine, Message: fmt.Sprintf("Chequeo de salud: %d sondeos fallidos seguidos, conexión degradada (%s)", health.Failures, health.LastError)})
+			if check.Restart {
+				m.restartDegraded()
+			}
+		}
+
+		m.emit(Event{Type: EventHealth, Health: m.health.snapshot()})
+	}
+}
+
+// restartDegraded reinicia el túnel degradado con un reinicio suave
+func (m *Manager) restartDegraded() {
+	if err := m.softRestart(); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "Chequeo de salud: no se pudo reiniciar OpenVPN: " + err.Error()})
+		return
+	}
+	m.emit(Event{Type: EventLogLine, Message: "Chequeo de salud: reiniciando el túnel (SIGUSR1)"})
+	m.emit(Event{Type: EventHealthRestart})
+}
+
+// softRestart pide a OpenVPN un reinicio suave (SIGUSR1): renegocia el túnel
+// sin terminar el proceso ni volver a pedir credenciales guardadas en memoria
+func (m *Manager) softRestart() error {
+	m.mu.Lock()
+	client := m.client
+	m.mu.Unlock()
+	if client == nil {
+		return errors.New("sin conexión al management interface")
+	}
+	return client.Signal("SIGUSR1")
+}
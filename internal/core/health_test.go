@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthTracker(t *testing.T) {
+	var tracker healthTracker
+	tracker.record(10*time.Millisecond, nil)
+	tracker.record(0, errors.New("timeout"))
+	tracker.record(0, errors.New("timeout"))
+	tracker.record(30*time.Millisecond, nil)
+	tracker.record(0, errors.New("refused"))
+
+	h := tracker.snapshot()
+	want := Health{
+		Latency:    30 * time.Millisecond,
+		AvgLatency: 20 * time.Millisecond,
+		Loss:       0.6,
+		Failures:   1,
+		LastError:  "refused",
+	}
+	if h != want {
+		t.Errorf("snapshot:\n got: %+v\nwant: %+v", h, want)
+	}
+
+	// Solo cuentan los sondeos recientes
+	for i := 0; i < healthWindow; i++ {
+		tracker.record(time.Millisecond, nil)
+	}
+	if h := tracker.snapshot(); h.Loss != 0 || h.Failures != 0 || h.AvgLatency != time.Millisecond {
+		t.Errorf("tras %d sondeos exitosos: %+v", healthWindow, h)
+	}
+}
+
+func TestHealthCheckValidate(t *testing.T) {
+	valid := []HealthCheck{
+		{},
+		{Probe: ProbeICMP, Target: "10.10.0.1"},
+		{Probe: ProbeTCP, Target: "intranet.corp:443"},
+		{Probe: ProbeHTTP, Target: "https://intranet.corp/health"},
+	}
+	for _, check := range valid {
+		if err := check.Validate(); err != nil {
+			t.Errorf("%+v: %v", check, err)
+		}
+	}
+
+	invalid := []HealthCheck{
+		{Probe: ProbeICMP},
+		{Probe: ProbeICMP, Target: "-f"},
+		{Probe: ProbeTCP, Target: "intranet.corp"},
+		{Probe: ProbeHTTP, Target: "ftp://intranet.corp"},
+		{Probe: "udp", Target: "10.10.0.1:53"},
+	}
+	for _, check := range invalid {
+		if err := check.Validate(); err == nil {
+			t.Errorf("%+v: se esperaba un error", check)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/lavp2393/navtunnel/internal/mgmt"
+	"github.com/lavp2393/navtunnel/internal/platform"
+	"github.com/lavp2393/navtunnel/internal/profile"
+)
+
+// KillSwitchOptions configura el bloqueo del tráfico fuera de la VPN.
+// El bloqueo se activa antes de lanzar OpenVPN, se mantiene durante las
+// reconexiones y solo se quita con DisableKillSwitch.
+type KillSwitchOptions struct {
+	Enabled  bool
+	AllowLAN bool // Permitir la red local
+
+	// pinned son las IPs de los servidores del perfil (por nombre),
+	// resueltas al activar el bloqueo. Con el bloqueo activo OpenVPN ya no
+	// puede resolver los nombres al reconectar: se le indican estas IPs por
+	// --management-query-remote (ver handleRemote).
+	pinned map[string][]string
+}
+
+// profileRemote es un servidor VPN declarado en el perfil
+type profileRemote struct {
+	Host     string
+	Port     int
+	Protocol string // "udp" o "tcp"
+}
+
+// readProfileNetwork lee del perfil los servidores (remote, con port y
+// proto como valores por defecto) y la interfaz del túnel (dev)
+func readProfileNetwork(ovpnPath string) ([]profileRemote, string, error) {
+	p, err := profile.ParseFile(ovpnPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var remotes []profileRemote
+	for _, r := range p.Remotes() {
+		remotes = append(remotes, profileRemote{Host: r.Host, Port: r.Port, Protocol: r.Transport()})
+	}
+	device := p.Dev()
+	if device == "" {
+		device = "tun"
+	}
+	return remotes, devicePattern(device), nil
+}
+
+// devicePattern traduce la directiva dev a un nombre de interfaz: con
+// "dev tun" el kernel elige el número, así que se acepta cualquier tunN
+func devicePattern(dev string) string {
+	if dev == "tun" || dev == "tap" {
+		return dev + "*"
+	}
+	return dev
+}
+
+// pinRemotes resuelve los nombres de los servidores del perfil. Los que no
+// se pueden resolver quedan fuera; killSwitchConfig informa el error.
+func pinRemotes(profile string) (map[string][]string, error) {
+	remotes, _, err := readProfileNetwork(profile)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[string][]string)
+	for _, r := range remotes {
+		if net.ParseIP(r.Host) != nil || pinned[r.Host] != nil {
+			continue
+		}
+		ips, err := net.LookupIP(r.Host)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			pinned[r.Host] = append(pinned[r.Host], ip.String())
+		}
+	}
+	return pinned, nil
+}
+
+// killSwitchConfig arma las reglas del kill switch para el perfil. Los
+// nombres de los servidores usan las IPs fijadas al activar el bloqueo (o se
+// resuelven ahora si no las hay). Con la sesión conectada se agregan el
+// servidor y la interfaz reales.
+func killSwitchConfig(profile string, info ConnectionInfo, options KillSwitchOptions) (platform.KillSwitchConfig, error) {
+	remotes, device, err := readProfileNetwork(profile)
+	if err != nil {
+		return platform.KillSwitchConfig{}, fmt.Errorf("no se pudo leer el perfil: %w", err)
+	}
+
+	config := platform.KillSwitchConfig{Device: device, AllowLAN: options.AllowLAN}
+	if info.Device != "" {
+		config.Device = info.Device
+	}
+
+	seen := make(map[platform.KillSwitchRemote]bool)
+	add := func(ip net.IP, port int, protocol string) {
+		r := platform.KillSwitchRemote{IP: ip.String(), Port: port, Protocol: protocol}
+		if !seen[r] {
+			seen[r] = true
+			config.Remotes = append(config.Remotes, r)
+		}
+	}
+
+	var lookupErr error
+	for _, r := range remotes {
+		if ip := net.ParseIP(r.Host); ip != nil {
+			add(ip, r.Port, r.Protocol)
+			continue
+		}
+		if pinned, ok := options.pinned[r.Host]; ok {
+			for _, ip := range pinned {
+				add(net.ParseIP(ip), r.Port, r.Protocol)
+			}
+			continue
+		}
+		ips, err := net.LookupIP(r.Host)
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		for _, ip := range ips {
+			add(ip, r.Port, r.Protocol)
+		}
+	}
+	if ip := net.ParseIP(info.RemoteIP); ip != nil {
+		if port, err := strconv.Atoi(info.RemotePort); err == nil && info.Protocol != "" {
+			add(ip, port, info.Protocol)
+		}
+	}
+
+	if len(config.Remotes) == 0 {
+		if lookupErr != nil {
+			return config, fmt.Errorf("no se pudo resolver el servidor VPN: %w", lookupErr)
+		}
+		return config, fmt.Errorf("el perfil no declara ningún servidor (remote)")
+	}
+	return config, nil
+}
+
+// refreshKillSwitch ajusta el kill switch a la sesión conectada
+func (m *Manager) refreshKillSwitch() {
+	if !m.options.KillSwitch.Enabled || m.plat == nil {
+		return
+	}
+
+	config, err := killSwitchConfig(m.profile, m.ConnectionInfo(), m.options.KillSwitch)
+	if err == nil {
+		err = m.plat.EnableKillSwitch(config)
+	}
+	if err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo actualizar el kill switch: " + err.Error()})
+	}
+}
+
+// handleRemote responde a un >REMOTE: antes de cada intento de conexión. Si
+// el nombre del servidor tiene IPs fijadas se conecta a una de ellas (una
+// distinta en cada intento); si no, al servidor que eligió OpenVPN.
+func (m *Manager) handleRemote(r mgmt.Remote) {
+	var ip string
+	m.mu.Lock()
+	if ips := m.options.KillSwitch.pinned[r.Host]; len(ips) > 0 {
+		ip = ips[m.remoteTurn%len(ips)]
+		m.remoteTurn++
+	}
+	m.mu.Unlock()
+
+	var err error
+	if ip == "" {
+		err = m.client.RemoteAccept()
+	} else {
+		m.emit(Event{Type: EventLogLine, Message: fmt.Sprintf("Servidor %s: se conecta a %s, la IP permitida por el kill switch", r.Host, ip)})
+		err = m.client.RemoteModify(ip, r.Port)
+	}
+	if err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "Error al responder por el servidor remoto: " + err.Error()})
+	}
+}
+
+// prepareKillSwitch activa el kill switch antes de la primera conexión y
+// retorna las opciones con las IPs de los servidores fijadas. Si el perfil
+// no lo usa, se quita el que haya quedado de una sesión anterior.
+func prepareKillSwitch(plat platform.Platform, profile string, options KillSwitchOptions) (KillSwitchOptions, error) {
+	if !options.Enabled {
+		if active, _ := plat.KillSwitchActive(); active {
+			return options, plat.DisableKillSwitch()
+		}
+		return options, nil
+	}
+
+	pinned, err := pinRemotes(profile)
+	if err != nil {
+		return options, fmt.Errorf("no se pudo activar el kill switch: %w", err)
+	}
+	options.pinned = pinned
+
+	config, err := killSwitchConfig(profile, ConnectionInfo{}, options)
+	if err == nil {
+		err = plat.EnableKillSwitch(config)
+	}
+	if err != nil {
+		return options, fmt.Errorf("no se pudo activar el kill switch: %w", err)
+	}
+	return options, nil
+}
+
+// KillSwitchActive indica si el kill switch está bloqueando el tráfico
+func KillSwitchActive() bool {
+	active, err := platform.New().KillSwitchActive()
+	return err == nil && active
+}
+
+// DisableKillSwitch quita el kill switch. Solo debe llamarse cuando el
+// usuario desconecta a propósito o decide quitar un bloqueo que quedó activo.
+func DisableKillSwitch() error {
+	return platform.New().DisableKillSwitch()
+}
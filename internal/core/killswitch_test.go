@@ -0,0 +1,113 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+func writeProfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.ovpn")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadProfileNetwork(t *testing.T) {
+	profile := writeProfile(t, `client
+dev tun
+remote 203.0.113.1
+remote 203.0.113.2 443 tcp-client
+<ca>
+remote 198.51.100.1 1194
+</ca>
+<connection>
+remote 2001:db8::1 1195 udp6
+</connection>
+port 1200
+proto udp4
+`)
+
+	remotes, device, err := readProfileNetwork(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []profileRemote{
+		{Host: "203.0.113.1", Port: 1200, Protocol: "udp"},
+		{Host: "203.0.113.2", Port: 443, Protocol: "tcp"},
+		{Host: "2001:db8::1", Port: 1195, Protocol: "udp"},
+	}
+	if !reflect.DeepEqual(remotes, want) {
+		t.Errorf("remotes:\n got: %+v\nwant: %+v", remotes, want)
+	}
+	if device != "tun*" {
+		t.Errorf("device = %q, se esperaba tun*", device)
+	}
+}
+
+func TestKillSwitchConfig(t *testing.T) {
+	profile := writeProfile(t, "client\ndev tun\nremote 203.0.113.1 1194\n")
+
+	// Antes de conectar: cualquier tunN y los servidores del perfil
+	config, err := killSwitchConfig(profile, ConnectionInfo{}, KillSwitchOptions{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := platform.KillSwitchConfig{
+		Device:  "tun*",
+		Remotes: []platform.KillSwitchRemote{{IP: "203.0.113.1", Port: 1194, Protocol: "udp"}},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("antes de conectar:\n got: %+v\nwant: %+v", config, want)
+	}
+
+	// Conectada: la interfaz real y el servidor al que se conectó
+	info := ConnectionInfo{Device: "tun3", RemoteIP: "203.0.113.9", RemotePort: "1194", Protocol: "udp"}
+	config, err = killSwitchConfig(profile, info, KillSwitchOptions{Enabled: true, AllowLAN: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = platform.KillSwitchConfig{
+		Device: "tun3",
+		Remotes: []platform.KillSwitchRemote{
+			{IP: "203.0.113.1", Port: 1194, Protocol: "udp"},
+			{IP: "203.0.113.9", Port: 1194, Protocol: "udp"},
+		},
+		AllowLAN: true,
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("conectada:\n got: %+v\nwant: %+v", config, want)
+	}
+
+	// Un perfil sin servidores no puede protegerse
+	if _, err := killSwitchConfig(writeProfile(t, "client\n"), ConnectionInfo{}, KillSwitchOptions{Enabled: true}); err == nil {
+		t.Error("se esperaba un error con un perfil sin remote")
+	}
+}
+
+func TestKillSwitchConfigHostname(t *testing.T) {
+	profile := writeProfile(t, "client\nremote vpn.example.com 443 tcp\nremote 203.0.113.1 1194\n")
+
+	// Los nombres usan las IPs fijadas al activar el bloqueo, sin consultar el DNS
+	options := KillSwitchOptions{
+		Enabled: true,
+		pinned:  map[string][]string{"vpn.example.com": {"198.51.100.7", "2001:db8::7"}},
+	}
+	config, err := killSwitchConfig(profile, ConnectionInfo{}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []platform.KillSwitchRemote{
+		{IP: "198.51.100.7", Port: 443, Protocol: "tcp"},
+		{IP: "2001:db8::7", Port: 443, Protocol: "tcp"},
+		{IP: "203.0.113.1", Port: 1194, Protocol: "udp"},
+	}
+	if !reflect.DeepEqual(config.Remotes, want) {
+		t.Errorf("remotes:\n got: %+v\nwant: %+v", config.Remotes, want)
+	}
+}
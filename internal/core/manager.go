@@ -1,14 +1,14 @@
 package core
 
 import (
-	"bufio"
 	"fmt"
-	"os"
-	"os/exec"
-	"strings"
+	"net"
+	"regexp"
 	"sync"
+	"time"
 
-	"github.com/creack/pty"
+	"github.com/lavp2393/navtunnel/internal/mgmt"
+	"github.com/lavp2393/navtunnel/internal/platform"
 )
 
 // EventType representa el tipo de evento
@@ -23,13 +23,50 @@ const (
 	EventFatal
 	EventLogLine
 	EventDisconnected
+	EventWebAuth
+	EventReconnecting
+	EventStateChanged
+	EventHealth        // Resultado de un sondeo del chequeo de salud
+	EventHealthRestart // El chequeo de salud reinició el túnel (SIGUSR1)
 )
 
 // Event representa un evento del proceso OpenVPN
 type Event struct {
 	Type    EventType
 	Message string
-	Stage   string // Para AuthFailed: "password" o "otp"
+	Stage   string // Para AuthFailed: "password", "credentials" o "challenge"
+
+	// Para AskOTP: si la respuesta puede mostrarse en claro y si es obligatoria
+	Echo             bool
+	ResponseRequired bool
+
+	// Para WebAuth: URL a abrir en el navegador y tiempo máximo de espera
+	URL     string
+	Timeout time.Duration
+
+	// Para Reconnecting: número de intento y tiempo hasta el próximo intento
+	Attempt     int
+	MaxAttempts int
+	Delay       time.Duration
+
+	// Para Connected: IP asignada al túnel
+	LocalIP string
+
+	// Para Health: resumen de los sondeos recientes
+	Health Health
+
+	// Para Reconnecting del supervisor: la reconexión espera a que vuelva la red
+	Offline bool
+
+	// Para StateChanged: estado nuevo y estado anterior
+	State     State
+	PrevState State
+
+	// Para AskUser y AuthFailed "credentials": texto del static-challenge del
+	// perfil. Si no está vacío, la UI debe pedir usuario, contraseña y OTP
+	// juntos y enviarlos con SendFns.Credentials (Echo indica si el OTP puede
+	// mostrarse en claro).
+	Challenge string
 }
 
 // SendFns agrupa las funciones para enviar credenciales
@@ -37,340 +74,964 @@ type SendFns struct {
 	Username func(string) error
 	Password func(string) error
 	OTP      func(string) error
+
+	// Credentials envía usuario, contraseña y OTP en un solo paso (static-challenge)
+	Credentials func(username, password, otp string) error
 }
 
-// Manager gestiona la comunicación con el proceso OpenVPN
+// authType es el tipo de credencial que OpenVPN usa para usuario/contraseña
+const authType = "Auth"
+
+// webAuthTimeout es el tiempo por defecto para completar el inicio de sesión
+// web si OpenVPN no anuncia uno con AUTH_PENDING
+const webAuthTimeout = 5 * time.Minute
+
+// mgmtConnectTimeout es el tiempo máximo para que OpenVPN abra el management interface
+const mgmtConnectTimeout = 15 * time.Second
+
+// Tiempos del cierre de una sesión: primero se pide a OpenVPN que salga por el
+// management interface, luego SIGTERM al grupo de procesos y por último SIGKILL
+var (
+	mgmtSignalTimeout   = 2 * time.Second
+	gracefulStopTimeout = 8 * time.Second
+	terminateTimeout    = 3 * time.Second
+)
+
+// statsInterval es cada cuánto OpenVPN envía >BYTECOUNT: y se revisan los contadores
+const statsInterval = 2 * time.Second
+
+// tunOpenedRe reconoce la línea de log en la que OpenVPN abre la interfaz del túnel
+var tunOpenedRe = regexp.MustCompile(`TUN/TAP device (\S+) opened`)
+
+// Manager gestiona la comunicación con el proceso OpenVPN a través de su management interface
 type Manager struct {
-	cmd          *exec.Cmd
-	ptmx         *os.File // Pseudo-terminal master
-	events       chan Event
-	stopCh       chan struct{}
-	wg           sync.WaitGroup
-	currentStage string // "username", "password", "otp"
-	mu           sync.Mutex
+	proc     *OpenVPNProcess
+	endpoint *ManagementEndpoint
+	plat     platform.Platform
+	client   *mgmt.Client
+	events   chan Event
+	stopCh   chan struct{}
+	exited   chan struct{}
+	drained  chan struct{} // Se cierra cuando se procesaron todas las notificaciones
+	wg       sync.WaitGroup
+
+	fsm *StateMachine
+
+	mu         sync.Mutex
+	retryStage string // Credencial que la UI vuelve a pedir tras un AUTH_FAILED
+
+	// Credenciales de la sesión (solo en memoria, OpenVPN corre con --auth-nocache)
+	username        string
+	password        string
+	otp             string
+	needAuth        bool // OpenVPN espera credenciales (>PASSWORD:Need ...)
+	needUsername    bool // La petición incluye el usuario además de la contraseña
+	staticChallenge bool // El servidor pide OTP con static-challenge (SC:)
+	challengeText   string
+	challengeEcho   bool
+
+	// Static-challenge declarado en el perfil, leído antes de conectar
+	profileChallenge *StaticChallenge
+
+	// Challenge dinámico CRV1 pendiente de respuesta
+	dynamic         *mgmt.DynamicChallenge
+	dynamicAnswered bool
+	dynamicSent     bool // La última credencial enviada respondía a un CRV1
+
+	// Autenticación web (SSO) pendiente
+	pendingTimeout time.Duration
+	webAuthTimer   *time.Timer
+
+	// Estadísticas de tráfico
+	stats statsTracker
+
+	// Sondeos del chequeo de salud
+	health healthTracker
+
+	// Configuración de red de la sesión, leída de >STATE: y del log (requiere mu)
+	info ConnectionInfo
+
+	// Perfil, sus preferencias y estado de lo aplicado al sistema
+	profile    string
+	options    SessionOptions
+	dnsDevice  string // Interfaz con DNS configurado (requiere mu)
+	remoteTurn int    // Intentos respondidos con una IP fijada (requiere mu)
+
+	eventsMu     sync.Mutex
+	eventsClosed bool
 }
 
 // Start inicia el manager y el proceso OpenVPN
 // IMPORTANTE: ovpnPath es la ruta a tu archivo .ovpn
 func Start(ovpnPath string, openvpnBinary string) (*Manager, error) {
-	if openvpnBinary == "" {
-		openvpnBinary = "openvpn"
+	return StartWithLauncher(ovpnPath, openvpnBinary, DefaultLauncher())
+}
+
+// StartWithLauncher es como Start pero lanza OpenVPN con el launcher indicado
+func StartWithLauncher(ovpnPath, openvpnBinary string, launch Launcher) (*Manager, error) {
+	return startWithCredentials(launch, ovpnPath, openvpnBinary, "", "", SessionOptions{})
+}
+
+// startWithCredentials inicia una sesión reutilizando usuario y contraseña ya
+// conocidos (p.ej. al reconectar); solo se pedirá a la UI lo que falte, como el OTP
+func startWithCredentials(launch Launcher, ovpnPath, openvpnBinary, username, password string, options SessionOptions) (*Manager, error) {
+	// Las preferencias del perfil se validan antes de lanzar nada
+	extraArgs, err := options.Routes.Args()
+	if err != nil {
+		return nil, fmt.Errorf("reglas de rutas inválidas: %w", err)
+	}
+	if err := options.Health.Validate(); err != nil {
+		return nil, err
+	}
+	if len(options.KillSwitch.pinned) > 0 {
+		// OpenVPN consulta antes de cada intento a qué servidor conectar
+		extraArgs = append(extraArgs, "--management-query-remote")
 	}
 
-	// 1. Preparar el comando OpenVPN con elevación de privilegios
-	// En Linux, OpenVPN necesita ejecutarse como root para crear el túnel
-	// Usamos sudo porque pkexec bloquea stdin/stdout para interacción
-	args := []string{
-		"--config", ovpnPath,
-		"--auth-nocache",
-		"--auth-retry", "interact",
-		"--verb", "3", // Verbosidad moderada
+	// 1. Preparar el management interface (socket privado + contraseña)
+	plat := platform.New()
+	endpoint, err := NewManagementEndpoint(plat)
+	if err != nil {
+		return nil, err
 	}
 
-	// Elevar con sudo
-	// Asume que el usuario tiene NOPASSWD configurado para openvpn
-	sudoArgs := append([]string{openvpnBinary}, args...)
-	cmd := exec.Command("sudo", sudoArgs...)
+	m := newManager(endpoint)
+	m.plat = plat
+	m.profile = ovpnPath
+	m.options = options
+	m.transition(StateStarting)
+	m.username = username
+	m.password = password
 
-	// 2. Crear un pseudo-terminal (PTY)
-	// Esto simula un terminal interactivo real, evitando que OpenVPN
-	// use systemd-ask-password
-	ptmx, err := pty.Start(cmd)
+	// Si el perfil declara static-challenge, la UI pedirá todo de una vez
+	challenge, err := ReadStaticChallenge(ovpnPath)
 	if err != nil {
-		return nil, fmt.Errorf("error al iniciar OpenVPN con PTY: %w", err)
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo leer static-challenge del perfil: " + err.Error()})
 	}
+	m.profileChallenge = challenge
 
-	// 3. Crear el Manager
-	m := &Manager{
-		cmd:    cmd,
-		ptmx:   ptmx,
-		events: make(chan Event, 100),
-		stopCh: make(chan struct{}),
+	// 2. Lanzar OpenVPN con --management-query-passwords y --management-hold
+	// La salida estándar del proceso se reenvía como líneas de log
+	proc, err := StartOpenVPN(launch, ovpnPath, openvpnBinary, endpoint, extraArgs, m.logLine)
+	if err != nil {
+		endpoint.Cleanup()
+		return nil, err
 	}
+	m.proc = proc
 
-	// 4. Iniciar el lector del PTY en una goroutine
-	m.wg.Add(1)
-	go m.readPTY()
+	// Registro para recuperar la sesión si NavTunnel termina sin cerrarla
+	if err := writeSessionRecord(endpoint, proc.PID(), ovpnPath); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: err.Error()})
+	}
 
 	// Goroutine para manejar el fin del proceso
-	go func() {
-		m.cmd.Wait()
-		// Si el proceso termina, avisamos
-		select {
-		case m.events <- Event{Type: EventDisconnected, Message: "Proceso OpenVPN terminado"}:
-		case <-m.stopCh:
-		}
-		m.Stop() // Asegurarse de cerrar todo
-	}()
+	go m.waitProcess()
+
+	// 3. Conectarse y autenticarse en el management interface
+	client, err := m.dialManagement()
+	if err != nil {
+		m.Stop()
+		return nil, fmt.Errorf("no se pudo conectar al management interface: %w", err)
+	}
+
+	// 4. Procesar notificaciones en tiempo real
+	m.attach(client)
 
 	return m, nil
 }
 
+// newManager crea un manager sin proceso asociado
+func newManager(endpoint *ManagementEndpoint) *Manager {
+	m := &Manager{
+		endpoint: endpoint,
+		events:   make(chan Event, 100),
+		stopCh:   make(chan struct{}),
+		exited:   make(chan struct{}),
+		drained:  make(chan struct{}),
+	}
+	m.fsm = NewStateMachine(func(from, to State) {
+		m.emit(Event{Type: EventStateChanged, State: to, PrevState: from})
+	})
+	return m
+}
+
+// State retorna el estado actual de la sesión
+func (m *Manager) State() State {
+	return m.fsm.Current()
+}
+
+// transition cambia el estado de la sesión; las transiciones inválidas se
+// registran en el log y se ignoran
+func (m *Manager) transition(to State) {
+	if err := m.fsm.Transition(to); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "Cambio de estado ignorado: " + err.Error()})
+	}
+}
+
+// attach conecta el manager a un management interface ya autenticado y
+// comienza a procesar sus notificaciones
+func (m *Manager) attach(client *mgmt.Client) {
+	m.mu.Lock()
+	m.client = client
+	m.mu.Unlock()
+
+	// Anunciar soporte de >INFO_PRE: (autenticación web)
+	if err := client.Version(3); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "El management interface no acepta version 3: " + err.Error()})
+	}
+
+	m.wg.Add(3)
+	go m.readNotifications()
+	go m.pollCounters()
+	go m.monitorHealth()
+}
+
 // Events retorna el canal de eventos
 func (m *Manager) Events() <-chan Event {
 	return m.events
 }
 
-// SendFunctions retorna las funciones para enviar credenciales
+// Stats retorna las estadísticas de tráfico de la sesión
+func (m *Manager) Stats() Stats {
+	return m.stats.snapshot()
+}
+
+// Health retorna el resumen del chequeo de salud de la sesión
+func (m *Manager) Health() Health {
+	return m.health.snapshot()
+}
+
+// ConnectionInfo retorna la configuración de red conocida de la sesión
+func (m *Manager) ConnectionInfo() ConnectionInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.info.clone()
+}
+
+// credentials retorna usuario y contraseña conocidos de la sesión
+func (m *Manager) credentials() (string, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.username, m.password
+}
+
+// SendFunctions retorna las funciones para enviar credenciales.
+// Cada credencial se guarda en memoria; cuando OpenVPN tiene todo lo que
+// pidió se envían juntas por el management interface.
 func (m *Manager) SendFunctions() SendFns {
 	return SendFns{
 		Username: func(username string) error {
 			m.mu.Lock()
-			m.currentStage = "password" // La siguiente etapa es password
+			m.username = username
 			m.mu.Unlock()
-			return m.sendCommand(username)
+			return m.askNext()
 		},
 		Password: func(password string) error {
 			m.mu.Lock()
-			m.currentStage = "otp" // La siguiente etapa es OTP
+			m.password = password
 			m.mu.Unlock()
-			return m.sendCommand(password)
+			return m.askNext()
 		},
 		OTP: func(otp string) error {
 			m.mu.Lock()
-			m.currentStage = "connected" // Ya no esperamos más credenciales
+			if m.dynamic != nil {
+				m.dynamicAnswered = true
+			}
+			m.otp = otp
+			m.mu.Unlock()
+			return m.askNext()
+		},
+		Credentials: func(username, password, otp string) error {
+			m.mu.Lock()
+			m.username = username
+			m.password = password
+			m.otp = otp
 			m.mu.Unlock()
-			return m.sendCommand(otp)
+			return m.askNext()
 		},
 	}
 }
 
-// Stop detiene el manager y mata el proceso OpenVPN
-func (m *Manager) Stop() {
+// Stop cierra la sesión: pide a OpenVPN que termine y espera su salida antes de
+// escalar a SIGTERM y SIGKILL, para que no queden el túnel, las rutas o el DNS
+// configurados. Retorna un error si algún proceso openvpn de la sesión sigue vivo.
+func (m *Manager) Stop() error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	select {
 	case <-m.stopCh:
 		// Ya está cerrado
+		m.mu.Unlock()
+		return nil
+	default:
+	}
+	close(m.stopCh)
+	client := m.client
+	if m.webAuthTimer != nil {
+		m.webAuthTimer.Stop()
+	}
+	m.mu.Unlock()
+
+	// El DNS se restaura mientras la interfaz del túnel todavía existe
+	m.revertDNS()
+	m.shutdown(client)
+
+	// Cerrar el management interface para liberar al lector
+	if client != nil {
+		client.Close()
+	}
+
+	m.wg.Wait()
+
+	err := m.checkSurvivors()
+	if m.endpoint != nil && err == nil {
+		// Si OpenVPN sigue vivo se conserva el registro para recuperar la sesión
+		m.endpoint.Cleanup()
+	}
+
+	// Ya no se publican eventos: el estado final queda disponible en State()
+	if m.fsm.Current() != StateFailed {
+		m.fsm.Transition(StateDisconnecting)
+		m.fsm.Transition(StateIdle)
+	}
+
+	m.eventsMu.Lock()
+	m.eventsClosed = true
+	close(m.events)
+	m.eventsMu.Unlock()
+
+	return err
+}
+
+// Detach suelta la sesión sin detener OpenVPN: se cierra el management
+// interface y se conserva el registro de la sesión, marcado para retomarla
+// en el próximo arranque (ver FindOrphanSessions y AdoptSession)
+func (m *Manager) Detach() error {
+	if !m.release() {
+		return nil
+	}
+	return setSessionDetached(m.endpoint, true)
+}
+
+// release detiene las goroutines y timers del manager y cierra el management
+// interface sin tocar el proceso OpenVPN ni el registro de la sesión.
+// Retorna false si el manager ya estaba detenido.
+func (m *Manager) release() bool {
+	m.mu.Lock()
+	select {
+	case <-m.stopCh:
+		m.mu.Unlock()
+		return false
+	default:
+	}
+	close(m.stopCh)
+	client := m.client
+	if m.webAuthTimer != nil {
+		m.webAuthTimer.Stop()
+	}
+	m.mu.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+	m.wg.Wait()
+
+	m.eventsMu.Lock()
+	m.eventsClosed = true
+	close(m.events)
+	m.eventsMu.Unlock()
+
+	return true
+}
+
+// shutdown termina el proceso OpenVPN escalando de menos a más forzado
+func (m *Manager) shutdown(client *mgmt.Client) {
+	// 1. "signal SIGTERM" por el management interface: OpenVPN (que corre como
+	// root) limpia la interfaz, las rutas y el DNS antes de salir
+	if client != nil {
+		done := make(chan error, 1)
+		go func() { done <- client.Signal("SIGTERM") }()
+
+		select {
+		case err := <-done:
+			if err == nil && m.waitExit(gracefulStopTimeout) {
+				return
+			}
+		case <-m.exited:
+			return
+		case <-time.After(mgmtSignalTimeout):
+		}
+	}
+
+	// Una sesión adoptada no tiene un proceso propio al que enviar señales
+	if m.proc == nil {
+		return
+	}
+
+	// 2. SIGTERM al grupo de procesos (sudo lo reenvía a openvpn)
+	if m.waitExit(0) {
 		return
+	}
+	m.proc.Terminate()
+	if m.waitExit(terminateTimeout) {
+		return
+	}
+
+	// 3. SIGKILL como último recurso
+	m.proc.Kill()
+	m.waitExit(terminateTimeout)
+}
+
+// waitExit espera hasta timeout a que el proceso termine
+func (m *Manager) waitExit(timeout time.Duration) bool {
+	select {
+	case <-m.exited:
+		return true
 	default:
-		close(m.stopCh)
+	}
+	if timeout <= 0 {
+		return false
+	}
 
-		// Cerrar el PTY primero
-		if m.ptmx != nil {
-			m.ptmx.Close()
+	select {
+	case <-m.exited:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// checkSurvivors verifica que no quede ningún openvpn de esta sesión
+// (p.ej. el hijo de sudo si solo murió sudo)
+func (m *Manager) checkSurvivors() error {
+	if m.plat == nil || m.endpoint == nil || m.endpoint.Network != "unix" {
+		return nil
+	}
+
+	// En una sesión adoptada el management interface se cierra antes de que
+	// OpenVPN termine de limpiar, así que se le da un margen para salir
+	deadline := time.Now().Add(terminateTimeout)
+	for {
+		pids, err := m.plat.FindOpenVPNProcesses(m.endpoint.Address)
+		if err != nil || len(pids) == 0 {
+			return nil
 		}
+		if m.proc != nil || time.Now().After(deadline) {
+			return fmt.Errorf("OpenVPN sigue en ejecución (PID %v); puede ser necesario detenerlo manualmente", pids)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// emit publica un evento salvo que el manager ya se haya detenido
+func (m *Manager) emit(event Event) {
+	select {
+	case <-m.stopCh:
+		return
+	default:
+	}
 
-		if m.cmd != nil && m.cmd.Process != nil {
-			// Intentar terminarlo limpiamente
-			m.cmd.Process.Kill()
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+
+	if m.eventsClosed {
+		return
+	}
+
+	select {
+	case m.events <- event:
+	case <-m.stopCh:
+	}
+}
+
+// waitProcess espera a que OpenVPN termine y avisa a la UI
+func (m *Manager) waitProcess() {
+	m.proc.Wait()
+	m.processExited()
+}
+
+// processExited cierra la sesión cuando OpenVPN terminó
+func (m *Manager) processExited() {
+	close(m.exited)
+
+	// Las últimas notificaciones (p.ej. >FATAL:) deben llegar antes que el fin del proceso
+	select {
+	case <-m.drained:
+	case <-m.stopCh:
+	case <-time.After(2 * time.Second):
+	}
+
+	if m.fsm.Current() != StateFailed {
+		m.transition(StateDisconnecting)
+	}
+
+	m.emit(Event{Type: EventDisconnected, Message: "Proceso OpenVPN terminado"})
+	m.Stop() // Asegurarse de cerrar todo
+}
+
+// dialManagement reintenta la conexión hasta que OpenVPN abra el management
+// interface y luego se autentica con la contraseña de la sesión
+func (m *Manager) dialManagement() (*mgmt.Client, error) {
+	deadline := time.Now().Add(mgmtConnectTimeout)
+
+	for {
+		client, err := mgmt.Dial(m.endpoint.Network, m.endpoint.Address, time.Second)
+		if err == nil {
+			if err := client.Authenticate(m.endpoint.Password, 5*time.Second); err != nil {
+				client.Close()
+				return nil, err
+			}
+			return client, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		select {
+		case <-m.exited:
+			return nil, fmt.Errorf("OpenVPN terminó antes de abrir el management interface")
+		case <-time.After(200 * time.Millisecond):
 		}
-		m.wg.Wait()
-		close(m.events)
 	}
 }
 
-// sendCommand envía un comando (credencial) al PTY
-func (m *Manager) sendCommand(cmd string) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// readNotifications procesa las notificaciones del management interface
+func (m *Manager) readNotifications() {
+	defer m.wg.Done()
+	defer close(m.drained)
 
-	if m.ptmx == nil {
-		return fmt.Errorf("PTY no está disponible")
+	for n := range m.client.Notifications() {
+		m.handleNotification(n)
 	}
-	// Escribimos la credencial seguida de un salto de línea
-	_, err := m.ptmx.Write([]byte(cmd + "\n"))
-	return err
 }
 
-// readPTY lee continuamente del pseudo-terminal
-// IMPORTANTE: No usamos Scanner porque los prompts de OpenVPN no tienen newline
-func (m *Manager) readPTY() {
+// pollCounters lee los contadores de la interfaz del túnel cuando OpenVPN
+// deja de enviar >BYTECOUNT: (versiones antiguas o management ocupado)
+func (m *Manager) pollCounters() {
 	defer m.wg.Done()
 
-	reader := bufio.NewReader(m.ptmx)
-	var buffer strings.Builder
-	buf := make([]byte, 1024)
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-m.stopCh:
 			return
-		default:
-			// Leer con timeout implícito (bloqueante pero responsive)
-			n, err := reader.Read(buf)
+		case now := <-ticker.C:
+			m.mu.Lock()
+			device := m.info.Device
+			m.mu.Unlock()
+
+			if m.plat == nil || device == "" || !m.stats.stale(now, 3*statsInterval) {
+				continue
+			}
+			rx, tx, err := m.plat.InterfaceCounters(device)
 			if err != nil {
-				if err.Error() != "EOF" {
-					// Error de lectura, terminar
-					return
-				}
+				continue
 			}
+			m.stats.update(sourceInterface, rx, tx, now)
+		}
+	}
+}
 
-			if n > 0 {
-				chunk := string(buf[:n])
-				buffer.WriteString(chunk)
-
-				// Procesar líneas completas (con \n)
-				data := buffer.String()
-				lines := strings.Split(data, "\n")
-
-				// La última parte puede ser incompleta (sin \n)
-				buffer.Reset()
-				if !strings.HasSuffix(data, "\n") {
-					// Guardamos la línea incompleta en el buffer
-					buffer.WriteString(lines[len(lines)-1])
-					lines = lines[:len(lines)-1]
-				}
-
-				// Procesar líneas completas
-				for _, line := range lines {
-					if line != "" {
-						m.events <- Event{
-							Type:    EventLogLine,
-							Message: line,
-						}
-						m.parseLine(line)
-					}
-				}
-
-				// Procesar línea incompleta si contiene prompts conocidos
-				incomplete := buffer.String()
-				if incomplete != "" {
-					// Detectar prompts sin newline
-					if strings.Contains(incomplete, "Enter Auth Username:") ||
-						strings.Contains(incomplete, "Enter Auth Password:") ||
-						strings.Contains(incomplete, "CHALLENGE:") ||
-						strings.HasSuffix(incomplete, "Response:") {
-						m.events <- Event{
-							Type:    EventLogLine,
-							Message: incomplete,
-						}
-						m.parseLine(incomplete)
-						buffer.Reset()
-					}
-				}
-			}
+// handleNotification traduce una notificación del protocolo a eventos de la UI
+func (m *Manager) handleNotification(n mgmt.Notification) {
+	switch n.Type {
+	case mgmt.NotifyHold:
+		// OpenVPN espera por --management-hold (al inicio y tras cada reinicio)
+		if err := m.client.StateOn(); err != nil {
+			m.emit(Event{Type: EventLogLine, Message: "Error al activar notificaciones de estado: " + err.Error()})
+		}
+		if err := m.client.HoldRelease(); err != nil {
+			m.emit(Event{Type: EventLogLine, Message: "Error al liberar hold: " + err.Error()})
+		}
+
+	case mgmt.NotifyPassword:
+		m.handlePassword(mgmt.ParsePassword(n.Payload))
+
+	case mgmt.NotifyInfo, mgmt.NotifyInfoPre:
+		if url, ok := mgmt.ParseWebAuth(n.Payload); ok {
+			m.handleWebAuth(url)
+		}
+
+	case mgmt.NotifyState:
+		m.handleState(mgmt.ParseState(n.Payload))
+
+	case mgmt.NotifyLog:
+		// Solo llegan en sesiones adoptadas; las propias se leen de la salida del proceso
+		m.logLine(mgmt.ParseLog(n.Payload).Message)
+
+	case mgmt.NotifyRemote:
+		if r, ok := mgmt.ParseRemote(n.Payload); ok {
+			m.handleRemote(r)
+		}
+
+	case mgmt.NotifyByteCount:
+		if in, out, ok := mgmt.ParseByteCount(n.Payload); ok {
+			m.stats.update(sourceByteCount, in, out, time.Now())
 		}
+
+	case mgmt.NotifyFatal:
+		m.transition(StateFailed)
+		m.emit(Event{
+			Type:    EventFatal,
+			Message: n.Payload,
+		})
 	}
 }
 
-// parseLine "raspa" la salida de la consola para encontrar prompts
-func (m *Manager) parseLine(line string) {
-	line = strings.TrimSpace(line)
+// logLine publica una línea del log de OpenVPN y extrae de ella la
+// configuración de red (interfaz del túnel, servidor, opciones empujadas)
+func (m *Manager) logLine(line string) {
+	m.mu.Lock()
+	m.info.applyLog(line)
+	m.mu.Unlock()
 
-	// --- Lógica de Detección de Prompts (Basada en tu captura) ---
+	m.emit(Event{Type: EventLogLine, Message: line})
+}
 
-	// 1. Pedir Usuario
-	// Cambiado de HasSuffix a Contains porque OpenVPN imprime el input en la misma línea
-	if strings.Contains(line, "Enter Auth Username:") {
-		m.mu.Lock()
-		m.currentStage = "username"
-		m.mu.Unlock()
-		m.events <- Event{
-			Type:    EventAskUser,
-			Message: "Ingresa tu usuario corporativo",
+// handleState atiende un cambio de estado de OpenVPN (>STATE:)
+func (m *Manager) handleState(state mgmt.State) {
+	if state.Name == "AUTH_PENDING" {
+		// Algunas versiones anuncian el tiempo máximo: "timeout 180"
+		var secs int
+		if _, err := fmt.Sscanf(state.Description, "timeout %d", &secs); err == nil && secs > 0 {
+			m.mu.Lock()
+			m.pendingTimeout = time.Duration(secs) * time.Second
+			m.mu.Unlock()
 		}
 		return
 	}
-
-	// 2. Pedir Contraseña
-	// Cambiado de HasSuffix a Contains porque OpenVPN imprime el input en la misma línea
-	if strings.Contains(line, "Enter Auth Password:") {
+	if state.Name == "RECONNECTING" && state.Description != "auth-failure" {
+		// Reinicio interno de OpenVPN (ping-restart, cambio de red, ...).
+		// Los reinicios por auth-failure ya se reportan como AuthFailed.
+		m.transition(StateReconnecting)
+		m.emit(Event{
+			Type:    EventReconnecting,
+			Message: state.Description,
+		})
+		return
+	}
+	if state.Name == "ASSIGN_IP" {
 		m.mu.Lock()
-		m.currentStage = "password"
+		m.info.applyState(state)
 		m.mu.Unlock()
-		m.events <- Event{
-			Type:    EventAskPass,
-			Message: "Ingresa tu contraseña",
-		}
 		return
 	}
+	if state.Name != "CONNECTED" {
+		return
+	}
+	m.mu.Lock()
+	m.info.applyState(state)
+	if m.webAuthTimer != nil {
+		m.webAuthTimer.Stop()
+		m.webAuthTimer = nil
+	}
+	m.mu.Unlock()
 
-	// 3. Pedir OTP - Múltiples variaciones posibles
-	// Detectar diferentes formatos de challenge de OTP
-	if strings.HasPrefix(line, "CHALLENGE:") ||
-		strings.Contains(line, "static challenge") ||
-		strings.Contains(line, "Static challenge") ||
-		(strings.Contains(line, "OTP") && strings.Contains(line, "Enter")) ||
-		strings.HasSuffix(line, "Response:") {
-		m.mu.Lock()
-		m.currentStage = "otp"
-		m.mu.Unlock()
+	// Tras un reinicio interno se conserva la hora de conexión original
+	if m.stats.snapshot().ConnectedSince.IsZero() {
+		m.stats.start(time.Now())
+	}
+	if err := m.client.ByteCount(int(statsInterval / time.Second)); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "Error al activar estadísticas de tráfico: " + err.Error()})
+	}
 
-		// Extraer el mensaje del challenge si queremos
-		msg := "Ingresa tu código OTP"
-		if strings.HasPrefix(line, "CHALLENGE:") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
-				msg = strings.TrimSpace(parts[1])
-			}
-		}
+	m.health.reset()
+	m.transition(StateConnected)
+	m.emit(Event{
+		Type:    EventConnected,
+		Message: "Conexión establecida ✅",
+		LocalIP: state.LocalIP,
+	})
+	m.refreshKillSwitch()
+	m.applyDNS()
+}
 
-		m.events <- Event{
-			Type:    EventAskOTP,
-			Message: msg,
-		}
+// handleWebAuth pide a la UI que abra la URL de inicio de sesión web y
+// arma un temporizador: si la conexión no se completa a tiempo se aborta
+func (m *Manager) handleWebAuth(url string) {
+	m.mu.Lock()
+	timeout := m.pendingTimeout
+	if timeout <= 0 {
+		timeout = webAuthTimeout
+	}
+	if m.webAuthTimer != nil {
+		m.webAuthTimer.Stop()
+	}
+	m.webAuthTimer = time.AfterFunc(timeout, func() {
+		m.transition(StateFailed)
+		m.emit(Event{
+			Type:    EventFatal,
+			Message: "Tiempo de espera agotado para el inicio de sesión en el navegador",
+		})
+	})
+	m.mu.Unlock()
+
+	m.emit(Event{
+		Type:    EventWebAuth,
+		Message: "Completa el inicio de sesión en el navegador",
+		URL:     url,
+		Timeout: timeout,
+	})
+}
+
+// handlePassword atiende una notificación >PASSWORD:
+func (m *Manager) handlePassword(req mgmt.PasswordRequest) {
+	if req.AuthType != authType {
+		m.emit(Event{
+			Type:    EventLogLine,
+			Message: fmt.Sprintf("OpenVPN solicita una credencial no soportada: '%s'", req.AuthType),
+		})
 		return
 	}
 
-	// --- Lógica de Detección de Estado ---
+	// Fallo de autenticación: con --auth-retry interact OpenVPN volverá a pedir
+	// credenciales, así que descartamos la que la UI va a solicitar de nuevo
+	if req.VerificationFailed {
+		if challenge, ok := mgmt.ParseDynamicChallenge(req.Reason); ok {
+			m.handleDynamicChallenge(challenge)
+			return
+		}
 
-	// 4. Fallo de Autenticación
-	if strings.Contains(line, "AUTH_FAILED") {
 		m.mu.Lock()
-		stage := m.currentStage
+		// Si se rechazó la respuesta a un CRV1, reenviamos usuario/contraseña
+		// para que el servidor emita un challenge nuevo
+		if m.dynamicSent {
+			m.dynamicSent = false
+			m.otp = ""
+			m.mu.Unlock()
+
+			m.emit(Event{
+				Type:    EventAuthFailed,
+				Message: getAuthFailedMessage(StateAwaitingOTP),
+				Stage:   "challenge",
+			})
+			return
+		}
+
+		// Con static-challenge la contraseña y el OTP viajan juntos y no se
+		// sabe cuál se rechazó: se vuelven a pedir los dos
+		if m.staticChallenge {
+			m.password = ""
+			m.otp = ""
+			m.retryStage = "credentials"
+			challenge := m.challengeText
+			if challenge == "" {
+				challenge = "Ingresa tu código OTP"
+			}
+			echo := m.challengeEcho
+			m.mu.Unlock()
+
+			m.transition(StateAwaitingPassword)
+			m.emit(Event{
+				Type:      EventAuthFailed,
+				Message:   "Contraseña u OTP incorrectos",
+				Stage:     "credentials",
+				Challenge: challenge,
+				Echo:      echo,
+			})
+			return
+		}
+
+		m.password = ""
+		m.otp = ""
+		m.retryStage = "password"
 		m.mu.Unlock()
 
-		m.events <- Event{
+		m.transition(StateAwaitingPassword)
+		m.emit(Event{
 			Type:    EventAuthFailed,
-			Message: getAuthFailedMessage(stage),
-			Stage:   stage,
-		}
+			Message: getAuthFailedMessage(StateAwaitingPassword),
+			Stage:   "password",
+		})
 		return
 	}
 
-	// 5. Conexión Exitosa
-	// Este es el mensaje más común cuando la VPN se establece
-	if strings.Contains(line, "Initialization Sequence Completed") {
-		m.events <- Event{
-			Type:    EventConnected,
-			Message: "Conexión establecida ✅",
-		}
+	m.mu.Lock()
+	m.needAuth = true
+	m.needUsername = req.NeedUsername
+	m.staticChallenge = req.StaticChallenge
+	m.challengeText = req.ChallengeText
+	m.challengeEcho = req.ChallengeEcho
+	if pc := m.profileChallenge; pc != nil {
+		// El perfil es la fuente del texto y del eco del challenge
+		m.staticChallenge = true
+		m.challengeText = pc.Text
+		m.challengeEcho = pc.Echo
+	}
+
+	// Si la UI ya está pidiendo la credencial rechazada, esperamos su respuesta
+	waiting := m.retryStage != "" && m.missing(m.retryStage)
+	m.retryStage = ""
+	m.mu.Unlock()
+
+	if waiting {
 		return
 	}
+	if err := m.askNext(); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "Error al enviar credenciales: " + err.Error()})
+	}
+}
 
-	// 6. Error Fatal
-	if strings.HasPrefix(line, "FATAL:") {
-		m.events <- Event{
-			Type:    EventFatal,
-			Message: strings.TrimPrefix(line, "FATAL:"),
+// handleDynamicChallenge guarda un challenge CRV1 y pide la respuesta a la UI.
+// OpenVPN se reinicia y vuelve a pedir credenciales; la respuesta se envía
+// como contraseña "CRV1::<state_id>::<respuesta>".
+func (m *Manager) handleDynamicChallenge(challenge mgmt.DynamicChallenge) {
+	m.mu.Lock()
+	m.dynamic = &challenge
+	m.dynamicAnswered = false
+	m.dynamicSent = false
+	m.otp = ""
+	m.retryStage = ""
+	m.mu.Unlock()
+
+	m.transition(StateAwaitingOTP)
+
+	msg := challenge.Text
+	if msg == "" {
+		msg = "Ingresa tu código OTP"
+	}
+
+	m.emit(Event{
+		Type:             EventAskOTP,
+		Message:          msg,
+		Echo:             challenge.Echo,
+		ResponseRequired: challenge.ResponseRequired,
+	})
+}
+
+// askNext pide a la UI la siguiente credencial que falta o,
+// si ya están todas, las envía a OpenVPN
+func (m *Manager) askNext() error {
+	m.mu.Lock()
+	if !m.needAuth {
+		m.mu.Unlock()
+		return nil
+	}
+
+	var (
+		event *Event
+		next  State
+	)
+	switch {
+	case m.dynamic != nil:
+		// El challenge ya se pidió a la UI; esperamos la respuesta
+		if !m.dynamicAnswered {
+			m.mu.Unlock()
+			return nil
 		}
-		return
+	case m.needUsername && m.missing("username"):
+		next = StateAwaitingUser
+		event = &Event{Type: EventAskUser, Message: "Ingresa tu usuario corporativo"}
+		if m.staticChallenge {
+			event.Challenge = m.challengeText
+			event.Echo = m.challengeEcho
+			if event.Challenge == "" {
+				event.Challenge = "Ingresa tu código OTP"
+			}
+		}
+	case m.missing("password"):
+		next = StateAwaitingPassword
+		event = &Event{Type: EventAskPass, Message: "Ingresa tu contraseña"}
+	case m.staticChallenge && m.missing("otp"):
+		next = StateAwaitingOTP
+		msg := "Ingresa tu código OTP"
+		if m.challengeText != "" {
+			msg = m.challengeText
+		}
+		event = &Event{
+			Type:             EventAskOTP,
+			Message:          msg,
+			Echo:             m.challengeEcho,
+			ResponseRequired: true,
+		}
+	}
+	m.mu.Unlock()
+
+	if event != nil {
+		m.transition(next)
+		m.emit(*event)
+		return nil
+	}
+	return m.flush()
+}
+
+// flush envía las credenciales reunidas por el management interface
+func (m *Manager) flush() error {
+	m.mu.Lock()
+	username := m.username
+	password := m.password
+	switch {
+	case m.dynamic != nil:
+		username = m.dynamic.Username
+		password = mgmt.DynamicChallengeResponse(m.dynamic.StateID, m.otp)
+		m.dynamic = nil
+		m.dynamicAnswered = false
+		m.dynamicSent = true
+	case m.staticChallenge:
+		password = mgmt.StaticChallengeResponse(m.password, m.otp)
+		m.dynamicSent = false
+	default:
+		m.dynamicSent = false
 	}
+	needUsername := m.needUsername
+	client := m.client
+	m.needAuth = false
+	m.otp = ""
+	m.mu.Unlock()
+
+	// Ya no esperamos más credenciales
+	m.transition(StateConnecting)
+
+	if client == nil {
+		return fmt.Errorf("management interface no está disponible")
+	}
+
+	if needUsername {
+		if err := client.Username(authType, username); err != nil {
+			return err
+		}
+	}
+	return client.Password(authType, password)
 }
 
-// getAuthFailedMessage retorna el mensaje apropiado según la etapa
-func getAuthFailedMessage(stage string) string {
+// missing indica si falta la credencial de la etapa indicada (requiere m.mu)
+func (m *Manager) missing(stage string) bool {
 	switch stage {
+	case "username":
+		return m.username == ""
 	case "password":
-		return "Contraseña incorrecta"
+		return m.password == ""
 	case "otp":
+		return m.otp == ""
+	case "credentials":
+		return m.password == "" || m.otp == ""
+	}
+	return false
+}
+
+// getAuthFailedMessage retorna el mensaje de un fallo de autenticación
+// según la credencial que se vuelve a pedir
+func getAuthFailedMessage(next State) string {
+	switch next {
+	case StateAwaitingPassword:
+		return "Contraseña incorrecta"
+	case StateAwaitingOTP:
 		return "OTP inválido o expirado"
-	case "username":
+	case StateAwaitingUser:
 		return "Usuario incorrecto"
 	default:
-		// Si el fallo ocurre después de enviar el OTP
-		if stage == "connected" {
-			return "OTP inválido o expirado"
-		}
 		return "Error de autenticación"
 	}
 }
 
-// FindFreePort ya no es necesario para este método.
-// Lo comento por si lo necesitas en otro lado, pero este manager no lo usa.
-/*
-func FindFreePort() (int, error) {
-        rand.Seed(time.Now().UnixNano())
-        for i := 0; i < 10; i++ {
-                port := 49152 + rand.Intn(65535-49152)
-                ln, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
-                if err == nil {
-                        ln.Close()
-                        return port, nil
-                }
-        }
-        return 0, fmt.Errorf("no se pudo encontrar un puerto libre")
+// findFreePort pide al sistema un puerto TCP libre en loopback
+func findFreePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("no se pudo encontrar un puerto libre: %w", err)
+	}
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port, nil
 }
-*/
@@ -0,0 +1,490 @@
+//go:build !windows
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// fakeOpenVPN es la ruta del binario compilado desde testdata/fakeopenvpn
+var fakeOpenVPN string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "navtunnel-fake")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fakeOpenVPN = filepath.Join(dir, "openvpn")
+	build := exec.Command("go", "build", "-o", fakeOpenVPN, "./testdata/fakeopenvpn")
+	if out, err := build.CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "no se pudo compilar el OpenVPN falso: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// fakeLauncher lanza el OpenVPN falso con el escenario indicado, sin elevación
+func fakeLauncher(scenario string) Launcher {
+	return func(config platform.StartConfig) (*platform.Process, error) {
+		args := []string{
+			"--config", config.ConfigPath,
+			"--management", config.MgmtSocket, "unix", config.MgmtPasswordFile,
+			"--scenario", scenario,
+		}
+		cmd := exec.Command(config.OpenVPNPath, append(args, config.ExtraArgs...)...)
+		// Igual que la plataforma: grupo de procesos propio
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		go forwardLog(stdout, config.LogCallback)
+
+		return &platform.Process{Cmd: cmd, Running: true, PID: cmd.Process.Pid}, nil
+	}
+}
+
+func forwardLog(r io.Reader, callback func(string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if callback != nil {
+			callback(scanner.Text())
+		}
+	}
+}
+
+// answers son las respuestas que da la "UI" del test, en orden
+type answers struct {
+	passwords []string
+	otps      []string
+}
+
+func (a *answers) password() string {
+	return pop(&a.passwords)
+}
+
+func (a *answers) otp() string {
+	return pop(&a.otps)
+}
+
+func pop(values *[]string) string {
+	if len(*values) == 0 {
+		return ""
+	}
+	v := (*values)[0]
+	*values = (*values)[1:]
+	return v
+}
+
+var eventNames = map[EventType]string{
+	EventAskUser:       "AskUser",
+	EventAskPass:       "AskPass",
+	EventAskOTP:        "AskOTP",
+	EventConnected:     "Connected",
+	EventAuthFailed:    "AuthFailed",
+	EventFatal:         "Fatal",
+	EventLogLine:       "LogLine",
+	EventDisconnected:  "Disconnected",
+	EventWebAuth:       "WebAuth",
+	EventReconnecting:  "Reconnecting",
+	EventStateChanged:  "StateChanged",
+	EventHealth:        "Health",
+	EventHealthRestart: "HealthRestart",
+}
+
+// describe resume un evento con los campos relevantes para el test.
+// Los cambios de estado se muestran como "→Estado".
+func describe(e Event) string {
+	name := eventNames[e.Type]
+	switch e.Type {
+	case EventStateChanged:
+		return "→" + e.State.String()
+	case EventAskUser:
+		if e.Challenge != "" {
+			return name + ":" + e.Challenge
+		}
+	case EventAskOTP:
+		return name + ":" + e.Message
+	case EventAuthFailed:
+		if e.Challenge != "" {
+			return name + ":" + e.Stage + ":" + e.Challenge
+		}
+		return name + ":" + e.Stage
+	case EventConnected:
+		return name + ":" + e.LocalIP
+	case EventWebAuth:
+		return fmt.Sprintf("%s:%s:%s", name, e.URL, e.Timeout)
+	}
+	return name
+}
+
+func TestManagerScenarios(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		profile  string
+		answers  answers
+		want     []string
+	}{
+		{
+			name:     "login exitoso",
+			scenario: "success",
+			answers:  answers{passwords: []string{"secret"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser", "→AwaitingPassword", "AskPass",
+				"→Connecting", "→Connected", "Connected:10.8.0.6",
+			},
+		},
+		{
+			name:     "contraseña incorrecta",
+			scenario: "wrong-password",
+			answers:  answers{passwords: []string{"wrong", "secret"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser", "→AwaitingPassword", "AskPass",
+				"→Connecting", "→AwaitingPassword", "AuthFailed:password",
+				"→Connecting", "→Connected", "Connected:10.8.0.6",
+			},
+		},
+		{
+			name:     "reintento de OTP",
+			scenario: "otp-retry",
+			profile:  `static-challenge "Enter OTP" 1`,
+			answers:  answers{passwords: []string{"secret", "secret"}, otps: []string{"000000", "123456"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser:Enter OTP",
+				"→Connecting", "→AwaitingPassword", "AuthFailed:credentials:Enter OTP",
+				"→Connecting", "→Connected", "Connected:10.8.0.6",
+			},
+		},
+		{
+			// La contraseña viaja con el OTP: al fallar se piden las dos de nuevo
+			name:     "contraseña incorrecta con static-challenge",
+			scenario: "otp-retry",
+			profile:  `static-challenge "Enter OTP" 1`,
+			answers:  answers{passwords: []string{"wrong", "secret"}, otps: []string{"123456", "123456"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser:Enter OTP",
+				"→Connecting", "→AwaitingPassword", "AuthFailed:credentials:Enter OTP",
+				"→Connecting", "→Connected", "Connected:10.8.0.6",
+			},
+		},
+		{
+			name:     "challenge dinámico CRV1",
+			scenario: "crv1",
+			answers:  answers{passwords: []string{"secret"}, otps: []string{"123456"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser", "→AwaitingPassword", "AskPass",
+				"→Connecting", "→AwaitingOTP", "AskOTP:Enter PIN",
+				"→Connecting", "→Connected", "Connected:10.8.0.6",
+			},
+		},
+		{
+			name:     "inicio de sesión web",
+			scenario: "web-auth",
+			answers:  answers{passwords: []string{"secret"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser", "→AwaitingPassword", "AskPass",
+				"→Connecting", "WebAuth:https://sso.example.com/login?session=fake:1m0s",
+				"→Connected", "Connected:10.8.0.6",
+			},
+		},
+		{
+			name:     "error fatal",
+			scenario: "fatal",
+			want:     []string{"→Starting", "→Failed", "Fatal", "Disconnected"},
+		},
+		{
+			name:     "salida inesperada",
+			scenario: "exit",
+			answers:  answers{passwords: []string{"secret"}},
+			want: []string{
+				"→Starting", "→AwaitingUser", "AskUser", "→AwaitingPassword", "AskPass",
+				"→Connecting", "→Connected", "Connected:10.8.0.6",
+				"→Disconnecting", "Disconnected",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := startScenario(t, tt.scenario, tt.profile)
+			defer m.Stop()
+
+			got := collectEvents(t, m, tt.answers, len(tt.want))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eventos:\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// startScenario inicia un Manager contra el OpenVPN falso con el escenario indicado
+func startScenario(t *testing.T, scenario, profileExtra string) *Manager {
+	t.Helper()
+	return startScenarioWithOptions(t, scenario, profileExtra, SessionOptions{})
+}
+
+// testProfile prepara el directorio de runtime y un perfil de prueba con las
+// líneas extra indicadas; retorna la ruta del perfil
+func testProfile(t *testing.T, profileExtra string) string {
+	t.Helper()
+
+	// Directorio corto: la ruta del socket Unix tiene un límite de longitud
+	runtimeDir, err := os.MkdirTemp("", "nt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(runtimeDir) })
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	profile := filepath.Join(t.TempDir(), "test.ovpn")
+	if err := os.WriteFile(profile, []byte("client\nremote vpn.example.com 1194\n"+profileExtra+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return profile
+}
+
+// startScenarioWithOptions es startScenario con preferencias del perfil
+func startScenarioWithOptions(t *testing.T, scenario, profileExtra string, options SessionOptions) *Manager {
+	t.Helper()
+
+	profile := testProfile(t, profileExtra)
+	m, err := startWithCredentials(fakeLauncher(scenario), profile, fakeOpenVPN, "", "", options)
+	if err != nil {
+		t.Fatalf("startWithCredentials: %v", err)
+	}
+	return m
+}
+
+func TestManagerPinnedRemote(t *testing.T) {
+	// Con el kill switch activo el nombre del servidor no se puede resolver:
+	// OpenVPN debe conectar a la IP fijada al activar el bloqueo
+	options := SessionOptions{KillSwitch: KillSwitchOptions{
+		pinned: map[string][]string{"vpn.example.com": {"198.51.100.7"}},
+	}}
+	m, err := startWithCredentials(fakeLauncher("success"), testProfile(t, ""), fakeOpenVPN, "alice", "secret", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	var logs []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-m.Events():
+			if !ok {
+				t.Fatalf("la sesión terminó antes de conectar; log: %q", logs)
+			}
+			if e.Type == EventLogLine {
+				logs = append(logs, e.Message)
+			}
+			if e.Type != EventConnected {
+				continue
+			}
+			if !slices.Contains(logs, "UDPv4 link remote: [AF_INET]198.51.100.7:1194") {
+				t.Errorf("no se conectó a la IP fijada; log: %q", logs)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("timeout esperando la conexión; log: %q", logs)
+		}
+	}
+}
+
+func TestManagerStop(t *testing.T) {
+	// Tiempos cortos para que la escalada a SIGKILL no alargue el test
+	defer func(graceful, terminate time.Duration) {
+		gracefulStopTimeout, terminateTimeout = graceful, terminate
+	}(gracefulStopTimeout, terminateTimeout)
+	gracefulStopTimeout = 500 * time.Millisecond
+	terminateTimeout = 500 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		scenario   string
+		wantSignal syscall.Signal // 0: salida limpia con código 0
+	}{
+		{name: "signal SIGTERM por management", scenario: "success"},
+		{name: "escala a SIGKILL si OpenVPN no sale", scenario: "ignore-sigterm", wantSignal: syscall.SIGKILL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := startScenario(t, tt.scenario, "")
+			collectEvents(t, m, answers{passwords: []string{"secret"}}, 1)
+
+			start := time.Now()
+			if err := m.Stop(); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Stop tardó %s", elapsed)
+			}
+			if m.State() != StateIdle {
+				t.Errorf("estado final = %s, se esperaba Idle", m.State())
+			}
+
+			ps := m.proc.proc.Cmd.ProcessState
+			if ps == nil {
+				t.Fatal("el proceso no terminó")
+			}
+			status := ps.Sys().(syscall.WaitStatus)
+			switch {
+			case tt.wantSignal == 0 && ps.ExitCode() != 0:
+				t.Errorf("se esperaba salida limpia, got %v", ps)
+			case tt.wantSignal != 0 && (!status.Signaled() || status.Signal() != tt.wantSignal):
+				t.Errorf("se esperaba terminación por %v, got %v", tt.wantSignal, ps)
+			}
+
+			if pids, err := m.plat.FindOpenVPNProcesses(m.endpoint.Address); err != nil || len(pids) > 0 {
+				t.Errorf("procesos openvpn sobrevivientes: %v (%v)", pids, err)
+			}
+		})
+	}
+}
+
+// collectEvents responde a los pedidos de credenciales como lo haría la UI y
+// registra los eventos (sin líneas de log) hasta que la sesión termina o se
+// reciben los esperados
+func collectEvents(t *testing.T, m *Manager, ans answers, want int) []string {
+	t.Helper()
+
+	send := m.SendFunctions()
+	timeout := time.After(10 * time.Second)
+	var got []string
+
+	for {
+		select {
+		case e, ok := <-m.Events():
+			if !ok {
+				return got
+			}
+			if e.Type == EventLogLine {
+				continue
+			}
+			got = append(got, describe(e))
+
+			var err error
+			switch e.Type {
+			case EventAskUser:
+				if e.Challenge != "" {
+					err = send.Credentials("alice", ans.password(), ans.otp())
+				} else {
+					err = send.Username("alice")
+				}
+			case EventAskPass:
+				err = send.Password(ans.password())
+			case EventAskOTP:
+				err = send.OTP(ans.otp())
+			case EventAuthFailed:
+				if e.Stage == "credentials" {
+					err = send.Credentials("alice", ans.password(), ans.otp())
+				} else {
+					err = send.Password(ans.password())
+				}
+			}
+			if err != nil {
+				t.Errorf("error al responder a %s: %v", describe(e), err)
+			}
+
+			// Las sesiones que siguen conectadas no terminan solas
+			if len(got) >= want && e.Type == EventConnected {
+				return got
+			}
+
+		case <-timeout:
+			t.Fatalf("timeout esperando eventos; recibidos: %q", got)
+		}
+	}
+}
+
+func TestHealthDegradedAndRecovered(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	go acceptAll(ln)
+
+	m := startScenarioWithOptions(t, "success", "", SessionOptions{Health: HealthCheck{
+		Probe:    ProbeTCP,
+		Target:   addr,
+		Interval: 50 * time.Millisecond,
+		Failures: 2,
+		Restart:  true,
+	}})
+	defer m.Stop()
+	collectEvents(t, m, answers{passwords: []string{"secret"}}, 8)
+
+	// El host deja de responder: la conexión se degrada y OpenVPN se reinicia
+	ln.Close()
+	waitEvents(t, m, "→Degraded", "HealthRestart")
+	if h := m.Health(); !h.Degraded || h.Failures < 2 || h.Loss == 0 {
+		t.Errorf("salud degradada: %+v", h)
+	}
+
+	// El host vuelve: la conexión se recupera
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go acceptAll(ln)
+	waitEvents(t, m, "→Connected")
+	if h := m.Health(); h.Degraded || h.Failures != 0 {
+		t.Errorf("salud recuperada: %+v", h)
+	}
+}
+
+// acceptAll acepta y cierra conexiones hasta que se cierre el listener
+func acceptAll(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}
+}
+
+// waitEvents espera que lleguen los eventos indicados, en orden
+func waitEvents(t *testing.T, m *Manager, want ...string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for len(want) > 0 {
+		select {
+		case e, ok := <-m.Events():
+			if !ok {
+				t.Fatalf("la sesión terminó esperando %q", want)
+			}
+			if describe(e) == want[0] {
+				want = want[1:]
+			}
+		case <-timeout:
+			t.Fatalf("timeout esperando %q", want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// networkDebounce es cuánto tiempo sin avisos se espera antes de revisar la
+// red: un cambio de Wi-Fi a cable produce decenas de avisos en pocos segundos
+const networkDebounce = 2 * time.Second
+
+// networkWatcher agrupa los avisos del monitor de red y, cuando la red se
+// estabiliza, informa si cambió la salida a Internet del sistema
+type networkWatcher struct {
+	monitor  platform.NetworkMonitor
+	uplink   func() string // Huella de la salida a Internet; vacía sin red
+	debounce time.Duration
+	onChange func(online bool)
+}
+
+// run procesa avisos hasta que se cierra stop o el monitor
+func (w *networkWatcher) run(stop <-chan struct{}) {
+	defer w.monitor.Close()
+
+	last := w.uplink()
+	if last == "" {
+		w.onChange(false)
+	}
+
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return
+		case _, ok := <-w.monitor.Changes():
+			if !ok {
+				return
+			}
+			settle = time.After(w.debounce)
+		case <-settle:
+			settle = nil
+			current := w.uplink()
+			if current == last {
+				continue // Cambios del túnel u otros que no afectan la salida
+			}
+			last = current
+			w.onChange(current != "")
+		}
+	}
+}
+
+// uplinkInterface es una interfaz de red tal como la ve el monitor
+type uplinkInterface struct {
+	Name  string
+	Up    bool     // Levantada y con portadora
+	Addrs []string // Direcciones sin prefijo
+	// Temporary son las direcciones de Addrs que se renuevan solas
+	// (extensiones de privacidad de IPv6)
+	Temporary []string
+}
+
+// stableAddrs retorna las direcciones que identifican la red: sin loopback,
+// enlace local ni direcciones temporales
+func (u uplinkInterface) stableAddrs() []string {
+	temporary := make(map[string]bool, len(u.Temporary))
+	for _, addr := range u.Temporary {
+		temporary[addr] = true
+	}
+
+	var addrs []string
+	for _, addr := range u.Addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || temporary[addr] {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// tunnelInterface indica si la interfaz es el túnel (o parece uno de OpenVPN
+// cuando todavía no se conoce el de la sesión)
+func tunnelInterface(name, tunnel string) bool {
+	return name == tunnel || strings.HasPrefix(name, "tun") || strings.HasPrefix(name, "tap")
+}
+
+// defaultRoute indica si el destino es una ruta por defecto (IPv4 o IPv6)
+func defaultRoute(destination string) bool {
+	return destination == "0.0.0.0/0" || destination == "::/0"
+}
+
+// uplinkFingerprint resume la salida a Internet: la interfaz de la ruta por
+// defecto (sin el túnel), su pasarela y sus direcciones estables. Otras
+// interfaces y las direcciones temporales, que cambian sin que cambie la red,
+// no cuentan. Con routes nil (tabla de rutas no disponible) cuentan todas las
+// interfaces activas. Es vacía si la salida no tiene una dirección utilizable.
+func uplinkFingerprint(ifaces []uplinkInterface, routes []platform.Route, tunnel string) string {
+	uplinks := make(map[string]bool)
+	for _, r := range routes {
+		if defaultRoute(r.Destination) && !tunnelInterface(r.Device, tunnel) {
+			uplinks[r.Device] = true
+		}
+	}
+
+	var parts []string
+	active := make(map[string]bool)
+	for _, iface := range ifaces {
+		if !iface.Up || tunnelInterface(iface.Name, tunnel) || (routes != nil && !uplinks[iface.Name]) {
+			continue
+		}
+		for _, addr := range iface.stableAddrs() {
+			parts = append(parts, iface.Name+" "+addr)
+			active[iface.Name] = true
+		}
+	}
+
+	for _, r := range routes {
+		if defaultRoute(r.Destination) && r.Gateway != "" && active[r.Device] {
+			parts = append(parts, "via "+r.Gateway+" "+r.Device)
+		}
+	}
+
+	sort.Strings(parts)
+	unique := parts[:0]
+	for i, p := range parts {
+		if i == 0 || p != parts[i-1] {
+			unique = append(unique, p)
+		}
+	}
+	return strings.Join(unique, ";")
+}
+
+// systemUplink calcula la huella de la salida a Internet del sistema
+func systemUplink(plat platform.Platform, tunnel string) string {
+	list, err := net.Interfaces()
+	if err != nil {
+		return ""
+	}
+
+	temporary := make(map[string]bool)
+	if addrs, err := plat.TemporaryAddresses(); err == nil {
+		for _, addr := range addrs {
+			temporary[addr] = true
+		}
+	}
+
+	ifaces := make([]uplinkInterface, 0, len(list))
+	for _, iface := range list {
+		u := uplinkInterface{
+			Name: iface.Name,
+			Up:   iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagRunning != 0,
+		}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				ip := ipnet.IP.String()
+				u.Addrs = append(u.Addrs, ip)
+				if temporary[ip] {
+					u.Temporary = append(u.Temporary, ip)
+				}
+			}
+		}
+		ifaces = append(ifaces, u)
+	}
+
+	// Sin tabla de rutas (otras plataformas) alcanzan las direcciones; una
+	// tabla vacía, en cambio, es que no hay salida
+	routes, err := plat.RoutingTable()
+	if err != nil {
+		routes = nil
+	} else if routes == nil {
+		routes = []platform.Route{}
+	}
+	return uplinkFingerprint(ifaces, routes, tunnel)
+}
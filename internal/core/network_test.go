@@ -0,0 +1,203 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// fakeNetworkMonitor es un monitor de red controlado por el test
+type fakeNetworkMonitor struct {
+	changes chan platform.NetworkChange
+	closed  chan struct{}
+}
+
+func newFakeNetworkMonitor() *fakeNetworkMonitor {
+	return &fakeNetworkMonitor{
+		changes: make(chan platform.NetworkChange, 16),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (f *fakeNetworkMonitor) Changes() <-chan platform.NetworkChange { return f.changes }
+func (f *fakeNetworkMonitor) Close() error                           { close(f.closed); return nil }
+
+// fakeUplink es una huella de red que el test cambia
+type fakeUplink struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (u *fakeUplink) get() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.value
+}
+
+func (u *fakeUplink) set(value string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.value = value
+}
+
+func TestNetworkWatcherDebounce(t *testing.T) {
+	monitor := newFakeNetworkMonitor()
+	uplink := &fakeUplink{value: "wlan0 192.168.1.20"}
+	results := make(chan bool, 10)
+	w := &networkWatcher{
+		monitor:  monitor,
+		uplink:   uplink.get,
+		debounce: 50 * time.Millisecond,
+		onChange: func(online bool) { results <- online },
+	}
+	stop := make(chan struct{})
+	go w.run(stop)
+
+	expect := func(want bool) {
+		t.Helper()
+		select {
+		case online := <-results:
+			if online != want {
+				t.Errorf("online = %v, se esperaba %v", online, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no se informó el cambio de red (online = %v)", want)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		select {
+		case online := <-results:
+			t.Errorf("cambio de red inesperado (online = %v)", online)
+		case <-time.After(150 * time.Millisecond):
+		}
+	}
+
+	// Avisos que no cambian la salida a Internet (rutas del túnel)
+	monitor.changes <- platform.NetworkChange{Kind: "route", Device: "tun0"}
+	expectNone()
+
+	// Una ráfaga de avisos al pasar de Wi-Fi a cable produce un solo cambio
+	uplink.set("eth0 192.168.1.30")
+	for i := 0; i < 5; i++ {
+		monitor.changes <- platform.NetworkChange{Kind: "address", Device: "eth0"}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expect(true)
+	expectNone()
+
+	uplink.set("")
+	monitor.changes <- platform.NetworkChange{Kind: "link", Device: "eth0"}
+	expect(false)
+
+	close(stop)
+	select {
+	case <-monitor.closed:
+	case <-time.After(time.Second):
+		t.Fatal("el monitor no se cerró")
+	}
+}
+
+func TestUplinkFingerprint(t *testing.T) {
+	ifaces := []uplinkInterface{
+		{Name: "lo", Up: true, Addrs: []string{"127.0.0.1", "::1"}},
+		{Name: "wlan0", Up: true, Addrs: []string{"192.168.1.20", "fe80::1"}},
+		{Name: "eth0", Up: false, Addrs: []string{"192.168.1.30"}},
+		{Name: "tun0", Up: true, Addrs: []string{"10.8.0.6"}},
+		{Name: "vpn", Up: true, Addrs: []string{"10.9.0.6"}},
+	}
+	routes := []platform.Route{
+		{Destination: "0.0.0.0/0", Gateway: "192.168.1.1", Device: "wlan0"},
+		{Destination: "10.9.0.0/16", Gateway: "192.168.1.1", Device: "wlan0"}, // net_gateway
+		{Destination: "192.168.1.0/24", Device: "wlan0"},
+		{Destination: "0.0.0.0/1", Gateway: "10.8.0.5", Device: "tun0"},
+		{Destination: "0.0.0.0/0", Gateway: "192.168.1.1", Device: "eth0"},
+	}
+
+	got := uplinkFingerprint(ifaces, routes, "vpn")
+	want := "via 192.168.1.1 wlan0;wlan0 192.168.1.20"
+	if got != want {
+		t.Errorf("uplinkFingerprint = %q, se esperaba %q", got, want)
+	}
+
+	if got := uplinkFingerprint(ifaces[:1], routes, ""); got != "" {
+		t.Errorf("sin interfaces activas: %q, se esperaba vacía", got)
+	}
+}
+
+func TestUplinkFingerprintIgnoresOtherInterfaces(t *testing.T) {
+	uplink := func(temporary string, extra ...uplinkInterface) string {
+		ifaces := append([]uplinkInterface{{
+			Name:      "wlan0",
+			Up:        true,
+			Addrs:     []string{"192.168.1.20", "2001:db8::211:22ff:fe33:4455", temporary},
+			Temporary: []string{temporary},
+		}}, extra...)
+		routes := []platform.Route{
+			{Destination: "0.0.0.0/0", Gateway: "192.168.1.1", Device: "wlan0"},
+			{Destination: "::/0", Gateway: "fe80::1", Device: "wlan0"},
+			{Destination: "172.17.0.0/16", Device: "docker0"},
+		}
+		return uplinkFingerprint(ifaces, routes, "")
+	}
+
+	base := uplink("2001:db8::1a2b:3c4d:5e6f:7081")
+	want := "via 192.168.1.1 wlan0;via fe80::1 wlan0;wlan0 192.168.1.20;wlan0 2001:db8::211:22ff:fe33:4455"
+	if base != want {
+		t.Fatalf("uplinkFingerprint = %q, se esperaba %q", base, want)
+	}
+
+	// La dirección temporal se renueva: la red es la misma
+	if got := uplink("2001:db8::9f8e:7d6c:5b4a:3921"); got != base {
+		t.Errorf("con la temporal renovada: %q", got)
+	}
+
+	// Aparece otra interfaz sin ruta por defecto (Docker, una VM)
+	docker := uplinkInterface{Name: "docker0", Up: true, Addrs: []string{"172.17.0.1"}}
+	if got := uplink("2001:db8::1a2b:3c4d:5e6f:7081", docker); got != base {
+		t.Errorf("con otra interfaz: %q", got)
+	}
+
+	// Sin tabla de rutas se usan todas las interfaces activas
+	if got := uplinkFingerprint([]uplinkInterface{docker}, nil, ""); got != "docker0 172.17.0.1" {
+		t.Errorf("sin tabla de rutas: %q", got)
+	}
+	if got := uplinkFingerprint([]uplinkInterface{docker}, []platform.Route{}, ""); got != "" {
+		t.Errorf("sin ruta por defecto: %q, se esperaba vacía", got)
+	}
+}
+
+func TestReconnectWaitsForNetwork(t *testing.T) {
+	s := NewSupervisor("test.ovpn", "", ReconnectPolicy{MaxAttempts: 3, InitialDelay: time.Hour, Multiplier: 1})
+	s.networkChanged(false)
+
+	done := make(chan bool)
+	go func() { done <- s.countdown(1) }()
+
+	// Sin red la espera no avanza y se avisa a la UI
+	offline := false
+	timeout := time.After(time.Second)
+	for !offline {
+		select {
+		case e := <-s.Events():
+			offline = e.Type == EventReconnecting && e.Offline
+		case <-done:
+			t.Fatal("la espera terminó sin red")
+		case <-timeout:
+			t.Fatal("no se avisó que la reconexión espera a la red")
+		}
+	}
+
+	// Al volver la red se reintenta en el acto, sin esperar el backoff
+	s.networkChanged(true)
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Error("countdown = false, se esperaba true")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("la reconexión no se reanudó al volver la red")
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 
 	"github.com/lavp2393/navtunnel/internal/platform"
@@ -32,20 +33,50 @@ func CheckConfigExists() bool {
 	return err == nil
 }
 
-// StartOpenVPN inicia el proceso de OpenVPN con elevación de privilegios usando la abstracción de plataforma
-func StartOpenVPN(configPath string, mgmtPort int, logCallback func(string)) (*OpenVPNProcess, error) {
+// OpenBrowser abre una URL de inicio de sesión web en el navegador del usuario.
+// Solo acepta http/https, ya que la URL la envía el servidor.
+func OpenBrowser(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("URL inválida: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("esquema de URL no permitido: %q", u.Scheme)
+	}
+
+	plat := platform.New()
+	return plat.OpenURL(u.String())
+}
+
+// Launcher lanza el proceso de OpenVPN con la configuración indicada.
+// El de la plataforma ejecuta OpenVPN con elevación de privilegios; los tests
+// inyectan uno que ejecuta un OpenVPN falso sin root.
+type Launcher func(config platform.StartConfig) (*platform.Process, error)
+
+// DefaultLauncher retorna el launcher de la plataforma actual
+func DefaultLauncher() Launcher {
+	return platform.New().StartOpenVPN
+}
+
+// StartOpenVPN inicia el proceso de OpenVPN con el launcher indicado
+// (nil usa el de la plataforma actual) y las opciones extra al final
+func StartOpenVPN(launch Launcher, configPath, openvpnPath string, endpoint *ManagementEndpoint, extraArgs []string, logCallback func(string)) (*OpenVPNProcess, error) {
 	// Obtener la plataforma actual
 	plat := platform.New()
+	if launch == nil {
+		launch = plat.StartOpenVPN
+	}
 
 	// Configurar el inicio de OpenVPN
-	config := platform.StartConfig{
+	config := endpoint.StartConfig(platform.StartConfig{
 		ConfigPath:  configPath,
-		MgmtPort:    mgmtPort,
+		OpenVPNPath: openvpnPath,
+		ExtraArgs:   extraArgs,
 		LogCallback: logCallback,
-	}
+	})
 
-	// Iniciar OpenVPN usando la abstracción de plataforma
-	proc, err := plat.StartOpenVPN(config)
+	// Iniciar OpenVPN
+	proc, err := launch(config)
 	if err != nil {
 		return nil, fmt.Errorf("error al iniciar OpenVPN: %w", err)
 	}
@@ -56,12 +87,30 @@ func StartOpenVPN(configPath string, mgmtPort int, logCallback func(string)) (*O
 	}, nil
 }
 
-// Stop detiene el proceso de OpenVPN limpiamente usando la abstracción de plataforma
-func (p *OpenVPNProcess) Stop() error {
+// Terminate pide a OpenVPN que termine (SIGTERM a su grupo de procesos)
+func (p *OpenVPNProcess) Terminate() error {
+	if p.proc == nil {
+		return nil
+	}
+	return p.platform.TerminateOpenVPN(p.proc, false)
+}
+
+// Wait espera a que el proceso termine. Solo debe llamarse una vez.
+func (p *OpenVPNProcess) Wait() error {
+	if p.proc == nil || p.proc.Cmd == nil {
+		return nil
+	}
+	err := p.proc.Cmd.Wait()
+	p.proc.Running = false
+	return err
+}
+
+// Kill fuerza la terminación del proceso y de su grupo
+func (p *OpenVPNProcess) Kill() error {
 	if p.proc == nil {
 		return nil
 	}
-	return p.platform.StopOpenVPN(p.proc)
+	return p.platform.TerminateOpenVPN(p.proc, true)
 }
 
 // IsRunning retorna si el proceso está corriendo
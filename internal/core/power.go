@@ -0,0 +1,22 @@
+package core
+
+import "github.com/lavp2393/navtunnel/internal/platform"
+
+// SessionEvent es un evento de suspensión o bloqueo de la sesión del usuario
+type SessionEvent = platform.SessionEvent
+
+const (
+	SessionSleep  = platform.SessionSleep
+	SessionResume = platform.SessionResume
+	SessionLock   = platform.SessionLock
+	SessionUnlock = platform.SessionUnlock
+)
+
+// SessionMonitor entrega los eventos de suspensión y bloqueo (ver platform.SessionMonitor)
+type SessionMonitor = platform.SessionMonitor
+
+// WatchSession se suscribe a la suspensión y al bloqueo de la sesión del
+// usuario (systemd-logind en Linux)
+func WatchSession() (SessionMonitor, error) {
+	return platform.New().WatchSession()
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// RouteRules modifica las rutas que empuja el servidor. Cada destino es un
+// prefijo CIDR, una IP o un nombre de host (OpenVPN lo resuelve al agregar la ruta).
+type RouteRules struct {
+	IgnorePushed bool     // Ignorar las rutas del servidor (--route-nopull)
+	Include      []string // Destinos que van por el túnel
+	Exclude      []string // Destinos que no van por el túnel
+}
+
+// Route es una entrada de la tabla de rutas del sistema
+type Route = platform.Route
+
+// hostnameRe reconoce nombres de host (RFC 1123)
+var hostnameRe = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*\.?$`)
+
+// routeTarget es un destino de una regla ya validado
+type routeTarget struct {
+	host string     // Nombre de host (si no es una red)
+	net  *net.IPNet // Red, con la IP ya enmascarada
+}
+
+// isIPv6 indica si el destino es una red IPv6
+func (t routeTarget) isIPv6() bool {
+	return t.net != nil && t.net.IP.To4() == nil
+}
+
+// routeArgs retorna destino y máscara como los espera --route
+func (t routeTarget) routeArgs() []string {
+	if t.host != "" {
+		return []string{t.host, "255.255.255.255"}
+	}
+	return []string{t.net.IP.String(), net.IP(t.net.Mask).String()}
+}
+
+// key identifica el destino para detectar repetidos
+func (t routeTarget) key() string {
+	if t.host != "" {
+		return strings.ToLower(strings.TrimSuffix(t.host, "."))
+	}
+	return t.net.String()
+}
+
+// parseRouteTarget valida un destino de una regla
+func parseRouteTarget(s string) (routeTarget, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return routeTarget{}, errors.New("destino vacío")
+	case strings.Contains(s, "/"):
+		_, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return routeTarget{}, fmt.Errorf("prefijo inválido: %q", s)
+		}
+		if ip4 := ipnet.IP.To4(); ip4 != nil {
+			ipnet.IP = ip4
+		}
+		return routeTarget{net: ipnet}, nil
+	case net.ParseIP(s) != nil:
+		ip := net.ParseIP(s)
+		if ip4 := ip.To4(); ip4 != nil {
+			return routeTarget{net: &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}}, nil
+		}
+		return routeTarget{net: &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}}, nil
+	case hostnameRe.MatchString(s) && !strings.HasPrefix(s, "-"):
+		return routeTarget{host: s}, nil
+	default:
+		return routeTarget{}, fmt.Errorf("destino inválido: %q (se espera un prefijo CIDR, una IP o un nombre de host)", s)
+	}
+}
+
+// Validate revisa las reglas y retorna todos los errores encontrados
+func (r RouteRules) Validate() error {
+	_, err := r.Args()
+	return err
+}
+
+// Args valida las reglas y las compila en opciones de OpenVPN. Las
+// exclusiones se aplican con --pull-filter (si el servidor empuja
+// exactamente esa ruta) y con una ruta por el gateway original (net_gateway).
+func (r RouteRules) Args() ([]string, error) {
+	var errs []error
+	seen := make(map[string]string)
+
+	parse := func(list []string, kind string) []routeTarget {
+		var targets []routeTarget
+		for _, entry := range list {
+			target, err := parseRouteTarget(entry)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", kind, err))
+				continue
+			}
+			if prev, ok := seen[target.key()]; ok {
+				if prev == kind {
+					errs = append(errs, fmt.Errorf("%s: %q está repetido", kind, entry))
+				} else {
+					errs = append(errs, fmt.Errorf("%q está a la vez en incluir y excluir", entry))
+				}
+				continue
+			}
+			seen[target.key()] = kind
+			targets = append(targets, target)
+		}
+		return targets
+	}
+	include := parse(r.Include, "incluir")
+	exclude := parse(r.Exclude, "excluir")
+
+	for _, target := range exclude {
+		if target.isIPv6() {
+			errs = append(errs, fmt.Errorf("excluir: %s: las exclusiones IPv6 no están soportadas", target.net))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var args []string
+	if r.IgnorePushed {
+		args = append(args, "--route-nopull")
+	}
+	for _, target := range include {
+		if target.isIPv6() {
+			args = append(args, "--route-ipv6", target.net.String())
+			continue
+		}
+		args = append(args, "--route")
+		args = append(args, target.routeArgs()...)
+	}
+	for _, target := range exclude {
+		if !r.IgnorePushed && target.net != nil {
+			args = append(args, "--pull-filter", "ignore", "route "+strings.Join(target.routeArgs(), " "))
+		}
+		args = append(args, "--route")
+		args = append(args, target.routeArgs()...)
+		args = append(args, "net_gateway")
+	}
+	return args, nil
+}
+
+// RoutingTable retorna la tabla de rutas efectiva del sistema
+func RoutingTable() ([]Route, error) {
+	return platform.New().RoutingTable()
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRouteRulesArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules RouteRules
+		want  []string
+	}{
+		{
+			name:  "sin reglas",
+			rules: RouteRules{},
+			want:  nil,
+		},
+		{
+			name:  "incluir redes y hosts",
+			rules: RouteRules{Include: []string{"10.20.0.0/16", "192.168.50.7", "git.corp.example.com", "fd00:1::/48"}},
+			want: []string{
+				"--route", "10.20.0.0", "255.255.0.0",
+				"--route", "192.168.50.7", "255.255.255.255",
+				"--route", "git.corp.example.com", "255.255.255.255",
+				"--route-ipv6", "fd00:1::/48",
+			},
+		},
+		{
+			name:  "excluir una ruta empujada",
+			rules: RouteRules{Exclude: []string{"10.1.2.3/8", "video.example.com"}},
+			want: []string{
+				"--pull-filter", "ignore", "route 10.0.0.0 255.0.0.0",
+				"--route", "10.0.0.0", "255.0.0.0", "net_gateway",
+				"--route", "video.example.com", "255.255.255.255", "net_gateway",
+			},
+		},
+		{
+			name:  "ignorar las rutas del servidor",
+			rules: RouteRules{IgnorePushed: true, Include: []string{" 10.0.0.0/8 "}, Exclude: []string{"10.9.0.0/16"}},
+			want: []string{
+				"--route-nopull",
+				"--route", "10.0.0.0", "255.0.0.0",
+				"--route", "10.9.0.0", "255.255.0.0", "net_gateway",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rules.Args()
+			if err != nil {
+				t.Fatalf("Args: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Args:\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteRulesValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules RouteRules
+		want  []string // Fragmentos esperados en el error
+	}{
+		{"prefijo inválido", RouteRules{Include: []string{"10.0.0.0/33"}}, []string{"prefijo inválido"}},
+		{"destino vacío", RouteRules{Exclude: []string{"  "}}, []string{"excluir: destino vacío"}},
+		{"no es un host", RouteRules{Include: []string{"--script-security 3"}}, []string{"destino inválido"}},
+		{"repetido", RouteRules{Include: []string{"10.0.0.0/8", "10.1.0.0/8"}}, []string{"repetido"}},
+		{"incluir y excluir", RouteRules{Include: []string{"Host.example.com"}, Exclude: []string{"host.example.com."}}, []string{"a la vez"}},
+		{"exclusión IPv6", RouteRules{Exclude: []string{"fd00::/8"}}, []string{"IPv6"}},
+		{"todos los errores", RouteRules{Include: []string{"", "x/y"}}, []string{"destino vacío", "prefijo inválido"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if err == nil {
+				t.Fatal("se esperaba un error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q no contiene %q", err, want)
+				}
+			}
+		})
+	}
+}
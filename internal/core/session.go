@@ -0,0 +1,294 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/mgmt"
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// SessionRecord describe una sesión de OpenVPN en curso. Se guarda en el
+// directorio de ejecución mientras la sesión vive; si NavTunnel termina sin
+// cerrarla (crash, kill), el siguiente arranque lo usa para encontrar el
+// proceso y volver a conectarse a su management interface o terminarlo.
+type SessionRecord struct {
+	ID           string    `json:"id"`
+	PID          int       `json:"pid"` // Proceso lanzado (sudo/pkexec en Linux)
+	Profile      string    `json:"profile"`
+	Network      string    `json:"network"`
+	Address      string    `json:"address"`
+	PasswordFile string    `json:"password_file"`
+	StartedAt    time.Time `json:"started_at"`
+
+	// Detached indica que la UI se cerró a propósito dejando la VPN conectada;
+	// en el próximo arranque la sesión se retoma sin preguntar
+	Detached bool `json:"detached,omitempty"`
+}
+
+// OrphanSession es una sesión de OpenVPN que sigue viva sin un NavTunnel que la controle
+type OrphanSession struct {
+	Record SessionRecord
+	PIDs   []int // Procesos openvpn de la sesión (vacío si no se pueden listar)
+}
+
+// writeSessionRecord guarda el registro de una sesión recién lanzada
+func writeSessionRecord(endpoint *ManagementEndpoint, pid int, profile string) error {
+	if abs, err := filepath.Abs(profile); err == nil {
+		profile = abs
+	}
+	return saveSessionRecord(endpoint.RecordPath(), SessionRecord{
+		ID:           endpoint.ID,
+		PID:          pid,
+		Profile:      profile,
+		Network:      endpoint.Network,
+		Address:      endpoint.Address,
+		PasswordFile: endpoint.PasswordFile,
+		StartedAt:    time.Now(),
+	})
+}
+
+// setSessionDetached marca (o desmarca) la sesión del endpoint como soltada a propósito
+func setSessionDetached(endpoint *ManagementEndpoint, detached bool) error {
+	path := endpoint.RecordPath()
+	record, err := readSessionRecord(path)
+	if err != nil {
+		return err
+	}
+	if record.Detached == detached {
+		return nil
+	}
+	record.Detached = detached
+	return saveSessionRecord(path, record)
+}
+
+// saveSessionRecord escribe el registro en un archivo temporal y lo renombra
+// para no dejar registros a medias
+func saveSessionRecord(path string, record SessionRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("no se pudo guardar el registro de la sesión: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("no se pudo guardar el registro de la sesión: %w", err)
+	}
+	return nil
+}
+
+// readSessionRecord lee un registro de sesión
+func readSessionRecord(path string) (SessionRecord, error) {
+	var record SessionRecord
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return record, err
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, err
+	}
+	if record.ID == "" || record.Address == "" || record.PasswordFile == "" {
+		return record, fmt.Errorf("registro de sesión incompleto")
+	}
+	return record, nil
+}
+
+// endpoint reconstruye el endpoint de la sesión, sin la contraseña
+func (r SessionRecord) endpoint() *ManagementEndpoint {
+	e := &ManagementEndpoint{
+		ID:           r.ID,
+		Network:      r.Network,
+		Address:      r.Address,
+		PasswordFile: r.PasswordFile,
+	}
+	if r.Network == "tcp" {
+		if _, port, err := net.SplitHostPort(r.Address); err == nil {
+			e.Port, _ = strconv.Atoi(port)
+		}
+	}
+	return e
+}
+
+// FindOrphanSessions busca sesiones de OpenVPN que quedaron corriendo de una
+// ejecución anterior. Los registros de sesiones que ya terminaron se eliminan
+// junto con su socket y su contraseña.
+func FindOrphanSessions() ([]OrphanSession, error) {
+	plat := platform.New()
+	dir, err := plat.EnsureRuntimeDir()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo preparar el directorio de ejecución: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "session-*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []OrphanSession
+	for _, path := range paths {
+		record, err := readSessionRecord(path)
+		if err != nil {
+			// Registro ilegible: no hay forma de recuperar la sesión
+			os.Remove(path)
+			continue
+		}
+
+		pids, alive := sessionAlive(plat, record)
+		if !alive {
+			record.endpoint().Cleanup()
+			continue
+		}
+		orphans = append(orphans, OrphanSession{Record: record, PIDs: pids})
+	}
+	return orphans, nil
+}
+
+// sessionAlive indica si OpenVPN sigue corriendo para la sesión del registro.
+// Donde no se pueden listar los procesos, se intenta conectar al management interface.
+func sessionAlive(plat platform.Platform, record SessionRecord) ([]int, bool) {
+	if record.Network == "unix" {
+		if pids, err := plat.FindOpenVPNProcesses(record.Address); err == nil {
+			return pids, len(pids) > 0
+		}
+	}
+
+	conn, err := net.DialTimeout(record.Network, record.Address, time.Second)
+	if err != nil {
+		return nil, false
+	}
+	conn.Close()
+	return nil, true
+}
+
+// AdoptSession toma el control de una sesión huérfana a través de su
+// management interface. El Manager resultante no tiene un proceso propio:
+// la sesión termina cuando OpenVPN cierra el management interface.
+func AdoptSession(orphan OrphanSession) (*Manager, error) {
+	return adoptSession(orphan, SessionOptions{})
+}
+
+// adoptSession es AdoptSession con las preferencias del perfil
+func adoptSession(orphan OrphanSession, options SessionOptions) (*Manager, error) {
+	endpoint := orphan.Record.endpoint()
+	data, err := os.ReadFile(endpoint.PasswordFile)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer la contraseña del management interface: %w", err)
+	}
+	endpoint.Password = strings.TrimSpace(string(data))
+
+	m := newManager(endpoint)
+	m.plat = platform.New()
+	m.profile = orphan.Record.Profile
+	m.options = options
+	m.transition(StateStarting)
+
+	client, err := m.dialManagement()
+	if err != nil {
+		// La sesión sigue siendo de OpenVPN: se conserva su registro para otro intento
+		m.release()
+		return nil, fmt.Errorf("no se pudo conectar a la sesión existente: %w", err)
+	}
+
+	go m.waitManagement(client)
+	m.attach(client)
+	m.resume()
+
+	// La sesión vuelve a tener una UI: si esta muere, el próximo arranque pregunta
+	if err := setSessionDetached(endpoint, false); err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo actualizar el registro de la sesión: " + err.Error()})
+	}
+
+	return m, nil
+}
+
+// TerminateOrphan cierra una sesión huérfana sin mostrarla en la UI
+func TerminateOrphan(orphan OrphanSession) error {
+	m, err := AdoptSession(orphan)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for range m.Events() {
+		}
+	}()
+	return m.Stop()
+}
+
+// resume sincroniza una sesión adoptada con OpenVPN: reproduce el log y los
+// estados recientes, activa sus notificaciones y restaura el estado actual
+func (m *Manager) resume() {
+	logs, err := m.client.LogOnAll()
+	if err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo leer el log de OpenVPN: " + err.Error()})
+	}
+	states, err := m.client.StateOnAll()
+	if err != nil {
+		m.emit(Event{Type: EventLogLine, Message: "No se pudo consultar el estado de OpenVPN: " + err.Error()})
+	}
+
+	for _, line := range replayHistory(logs, states) {
+		m.logLine(line)
+	}
+
+	if len(states) == 0 {
+		m.transition(StateConnecting)
+		return
+	}
+	switch current := states[len(states)-1]; current.Name {
+	case "CONNECTED":
+		// Las estadísticas cuentan desde la conexión original
+		if current.Time > 0 {
+			m.stats.start(time.Unix(current.Time, 0))
+		}
+		m.handleState(current)
+	case "RECONNECTING":
+		m.handleState(current)
+	default:
+		m.transition(StateConnecting)
+	}
+}
+
+// replayHistory combina el historial de log y de estados de OpenVPN en orden
+// cronológico, con el mismo formato que su salida estándar
+func replayHistory(logs []mgmt.LogLine, states []mgmt.State) []string {
+	type entry struct {
+		time int64
+		text string
+	}
+	entries := make([]entry, 0, len(logs)+len(states))
+	for _, l := range logs {
+		entries = append(entries, entry{l.Time, l.Message})
+	}
+	for _, st := range states {
+		text := "Estado: " + st.Name
+		if st.Description != "" {
+			text += " (" + st.Description + ")"
+		}
+		entries = append(entries, entry{st.Time, text})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time < entries[j].time })
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		lines[i] = time.Unix(e.time, 0).Format("2006-01-02 15:04:05") + " " + e.text
+	}
+	return lines
+}
+
+// waitManagement hace de waitProcess en una sesión adoptada: sin un proceso
+// propio, el cierre del management interface indica que OpenVPN terminó
+func (m *Manager) waitManagement(client *mgmt.Client) {
+	<-client.Done()
+	m.processExited()
+}
@@ -0,0 +1,128 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAdoptOrphanSession(t *testing.T) {
+	m := startScenario(t, "success", "")
+	defer m.Stop()
+	collectEvents(t, m, answers{passwords: []string{"secret"}}, 8)
+
+	// Simular que NavTunnel murió: se pierde el management interface pero
+	// OpenVPN y el registro de la sesión siguen ahí
+	m.client.Close()
+
+	// Registro de una sesión que ya terminó
+	stale := &ManagementEndpoint{
+		ID:           "stale",
+		Network:      "unix",
+		Address:      filepath.Join(filepath.Dir(m.endpoint.Address), "mgmt-stale.sock"),
+		PasswordFile: filepath.Join(filepath.Dir(m.endpoint.Address), "mgmt-stale.pw"),
+	}
+	if err := writeSessionRecord(stale, 1, "stale.ovpn"); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := FindOrphanSessions()
+	if err != nil {
+		t.Fatalf("FindOrphanSessions: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Record.ID != m.endpoint.ID {
+		t.Fatalf("sesiones huérfanas = %+v, se esperaba solo %s", orphans, m.endpoint.ID)
+	}
+	if len(orphans[0].PIDs) == 0 {
+		t.Error("no se encontró el proceso openvpn de la sesión")
+	}
+	if _, err := os.Stat(stale.RecordPath()); !os.IsNotExist(err) {
+		t.Errorf("el registro obsoleto no se eliminó: %v", err)
+	}
+
+	adopted, err := AdoptSession(orphans[0])
+	if err != nil {
+		t.Fatalf("AdoptSession: %v", err)
+	}
+	got := collectEvents(t, adopted, answers{}, 3)
+	want := []string{"→Starting", "→Connected", "Connected:10.8.0.6"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("eventos:\n got: %q\nwant: %q", got, want)
+	}
+	if adopted.Stats().ConnectedSince.IsZero() {
+		t.Error("la sesión adoptada no conserva la hora de conexión")
+	}
+
+	if err := adopted.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if _, err := os.Stat(m.endpoint.RecordPath()); !os.IsNotExist(err) {
+		t.Errorf("el registro de la sesión no se eliminó: %v", err)
+	}
+	if orphans, err := FindOrphanSessions(); err != nil || len(orphans) != 0 {
+		t.Errorf("quedan sesiones huérfanas: %+v (%v)", orphans, err)
+	}
+}
+
+func TestDetachAndReattach(t *testing.T) {
+	m := startScenario(t, "success", "")
+	defer m.Stop()
+	collectEvents(t, m, answers{passwords: []string{"secret"}}, 8)
+
+	// Salir de la UI dejando el túnel arriba
+	if err := m.Detach(); err != nil {
+		t.Fatalf("Detach: %v", err)
+	}
+	if pids, err := m.plat.FindOpenVPNProcesses(m.endpoint.Address); err != nil || len(pids) == 0 {
+		t.Fatalf("OpenVPN no sigue en ejecución tras Detach: %v (%v)", pids, err)
+	}
+
+	orphans, err := FindOrphanSessions()
+	if err != nil || len(orphans) != 1 {
+		t.Fatalf("sesiones huérfanas = %+v (%v), se esperaba una", orphans, err)
+	}
+	if !orphans[0].Record.Detached {
+		t.Error("la sesión no quedó marcada como soltada a propósito")
+	}
+
+	adopted, err := AdoptSession(orphans[0])
+	if err != nil {
+		t.Fatalf("AdoptSession: %v", err)
+	}
+	defer adopted.Stop()
+
+	// El log reciente se reproduce antes de restaurar la vista de conectado
+	var replayed []string
+	for e := range adopted.Events() {
+		if e.Type == EventLogLine {
+			replayed = append(replayed, e.Message)
+		}
+		if e.Type == EventConnected {
+			break
+		}
+	}
+	if len(replayed) == 0 || !strings.HasSuffix(replayed[len(replayed)-1], "Estado: CONNECTED (SUCCESS)") {
+		t.Errorf("historial reproducido:\n%s", strings.Join(replayed, "\n"))
+	}
+	if adopted.State() != StateConnected {
+		t.Errorf("estado = %s, se esperaba Connected", adopted.State())
+	}
+	info := adopted.ConnectionInfo()
+	want := ConnectionInfo{
+		LocalIP:       "10.8.0.6",
+		RemoteIP:      "203.0.113.1",
+		RemotePort:    "1194",
+		Protocol:      "udp",
+		Device:        "tun0",
+		Routes:        []string{"10.10.0.0/16"},
+		DNSServers:    []string{"10.10.0.2"},
+		SearchDomains: []string{"corp.example.com"},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("ConnectionInfo (del log reproducido):\n got: %+v\nwant: %+v", info, want)
+	}
+}
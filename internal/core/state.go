@@ -0,0 +1,140 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// State es el estado de una conexión VPN
+type State int
+
+const (
+	StateIdle             State = iota // Sin sesión
+	StateStarting                      // Lanzando OpenVPN y conectando al management interface
+	StateAwaitingUser                  // Esperando el usuario (y la contraseña/OTP si van juntos)
+	StateAwaitingPassword              // Esperando la contraseña
+	StateAwaitingOTP                   // Esperando el OTP o la respuesta a un challenge
+	StateConnecting                    // Credenciales enviadas, estableciendo el túnel
+	StateConnected                     // Túnel establecido
+	StateDegraded                      // Túnel establecido que no responde al chequeo de salud
+	StateReconnecting                  // El túnel se cayó y se está restableciendo
+	StateDisconnecting                 // Cerrando la sesión
+	StateFailed                        // La sesión terminó con un error
+)
+
+var stateNames = map[State]string{
+	StateIdle:             "Idle",
+	StateStarting:         "Starting",
+	StateAwaitingUser:     "AwaitingUser",
+	StateAwaitingPassword: "AwaitingPassword",
+	StateAwaitingOTP:      "AwaitingOTP",
+	StateConnecting:       "Connecting",
+	StateConnected:        "Connected",
+	StateDegraded:         "Degraded",
+	StateReconnecting:     "Reconnecting",
+	StateDisconnecting:    "Disconnecting",
+	StateFailed:           "Failed",
+}
+
+// String retorna el nombre del estado
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// AwaitingCredentials indica si la sesión espera una credencial de la UI
+func (s State) AwaitingCredentials() bool {
+	return s == StateAwaitingUser || s == StateAwaitingPassword || s == StateAwaitingOTP
+}
+
+// TunnelUp indica si el túnel está establecido, responda o no al chequeo de salud
+func (s State) TunnelUp() bool {
+	return s == StateConnected || s == StateDegraded
+}
+
+// Active indica si hay una sesión en curso que se puede desconectar
+func (s State) Active() bool {
+	return s != StateIdle && s != StateFailed
+}
+
+// awaiting son los estados de espera de credenciales, que pueden sucederse
+// en cualquier orden según lo que pida OpenVPN
+var awaiting = []State{StateAwaitingUser, StateAwaitingPassword, StateAwaitingOTP}
+
+// transitions enumera las transiciones válidas desde cada estado
+var transitions = map[State][]State{
+	StateIdle:             {StateStarting},
+	StateStarting:         append([]State{StateConnecting, StateConnected, StateReconnecting, StateDisconnecting, StateFailed}, awaiting...),
+	StateAwaitingUser:     append([]State{StateConnecting, StateReconnecting, StateDisconnecting, StateFailed}, awaiting...),
+	StateAwaitingPassword: append([]State{StateConnecting, StateReconnecting, StateDisconnecting, StateFailed}, awaiting...),
+	StateAwaitingOTP:      append([]State{StateConnecting, StateReconnecting, StateDisconnecting, StateFailed}, awaiting...),
+	StateConnecting:       append([]State{StateConnected, StateReconnecting, StateDisconnecting, StateFailed}, awaiting...),
+	StateConnected:        {StateDegraded, StateReconnecting, StateDisconnecting, StateFailed},
+	StateDegraded:         {StateConnected, StateReconnecting, StateDisconnecting, StateFailed},
+	StateReconnecting:     append([]State{StateStarting, StateConnecting, StateConnected, StateDisconnecting, StateFailed}, awaiting...),
+	StateDisconnecting:    {StateIdle, StateFailed},
+	StateFailed:           {StateIdle, StateStarting},
+}
+
+// CanTransition indica si se puede pasar del estado s al estado to
+func (s State) CanTransition(to State) bool {
+	for _, next := range transitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidTransition se usa cuando se pide una transición no permitida
+var ErrInvalidTransition = errors.New("invalid state transition")
+
+// StateMachine guarda el estado de una conexión y valida sus transiciones
+type StateMachine struct {
+	mu       sync.Mutex // Protege state
+	pubMu    sync.Mutex // Serializa las transiciones para publicarlas en orden
+	state    State
+	onChange func(from, to State)
+}
+
+// NewStateMachine crea una máquina de estados en Idle. onChange (opcional) se
+// llama tras cada transición, en el mismo orden en que ocurren.
+func NewStateMachine(onChange func(from, to State)) *StateMachine {
+	return &StateMachine{onChange: onChange}
+}
+
+// Current retorna el estado actual
+func (sm *StateMachine) Current() State {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.state
+}
+
+// Transition pasa al estado indicado. Pasar al estado actual no hace nada;
+// una transición no permitida retorna ErrInvalidTransition sin cambiar el estado.
+func (sm *StateMachine) Transition(to State) error {
+	sm.pubMu.Lock()
+	defer sm.pubMu.Unlock()
+
+	sm.mu.Lock()
+	from := sm.state
+	if from == to {
+		sm.mu.Unlock()
+		return nil
+	}
+	if !from.CanTransition(to) {
+		sm.mu.Unlock()
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	sm.state = to
+	sm.mu.Unlock()
+
+	// Current no se bloquea mientras se publica el cambio
+	if sm.onChange != nil {
+		sm.onChange(from, to)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStateMachineTransitions(t *testing.T) {
+	var published []string
+	sm := NewStateMachine(func(from, to State) {
+		published = append(published, from.String()+"→"+to.String())
+	})
+
+	steps := []struct {
+		to      State
+		wantErr bool
+	}{
+		{StateConnected, true}, // Idle no puede pasar directo a Connected
+		{StateStarting, false},
+		{StateStarting, false}, // Mismo estado: no hace nada
+		{StateAwaitingUser, false},
+		{StateAwaitingPassword, false},
+		{StateConnecting, false},
+		{StateIdle, true},
+		{StateConnected, false},
+		{StateAwaitingOTP, true},
+		{StateReconnecting, false},
+		{StateConnected, false},
+		{StateDisconnecting, false},
+		{StateConnected, true},
+		{StateIdle, false},
+	}
+
+	for _, step := range steps {
+		before := sm.Current()
+		err := sm.Transition(step.to)
+		if step.wantErr {
+			if !errors.Is(err, ErrInvalidTransition) {
+				t.Errorf("%s -> %s: se esperaba ErrInvalidTransition, got %v", before, step.to, err)
+			}
+			if sm.Current() != before {
+				t.Errorf("%s -> %s: una transición inválida cambió el estado a %s", before, step.to, sm.Current())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s -> %s: error inesperado: %v", before, step.to, err)
+		}
+	}
+
+	want := []string{
+		"Idle→Starting",
+		"Starting→AwaitingUser",
+		"AwaitingUser→AwaitingPassword",
+		"AwaitingPassword→Connecting",
+		"Connecting→Connected",
+		"Connected→Reconnecting",
+		"Reconnecting→Connected",
+		"Connected→Disconnecting",
+		"Disconnecting→Idle",
+	}
+	if !reflect.DeepEqual(published, want) {
+		t.Errorf("transiciones publicadas:\n got: %q\nwant: %q", published, want)
+	}
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats es una instantánea del tráfico de la sesión actual
+type Stats struct {
+	ConnectedSince time.Time // Cero si la sesión aún no conectó
+	BytesIn        uint64
+	BytesOut       uint64
+	RateIn         float64 // Bytes por segundo
+	RateOut        float64 // Bytes por segundo
+	UpdatedAt      time.Time
+}
+
+// statsSource es de dónde vienen los contadores. Cada fuente cuenta desde un
+// origen distinto, así que sus lecturas no se pueden comparar entre sí.
+type statsSource int
+
+const (
+	sourceByteCount statsSource = iota // >BYTECOUNT: de OpenVPN
+	sourceInterface                    // Contadores de la interfaz del túnel
+)
+
+// statsTracker acumula contadores de bytes y calcula tasas de transferencia.
+// Tolera que los contadores vuelvan a cero (p.ej. tras un ping-restart) y que
+// cambie la fuente: lo ya contado se conserva como base para los totales de
+// la sesión.
+type statsTracker struct {
+	mu sync.Mutex
+
+	since               time.Time
+	baseIn, baseOut     uint64
+	source              statsSource
+	offsetIn, offsetOut uint64 // Lectura de la fuente cuando empezó a usarse
+	lastIn, lastOut     uint64 // Contado por la fuente desde offset
+	lastAt              time.Time
+	rateIn, rateOut     float64
+	hasSample           bool
+}
+
+// start marca el inicio de la conexión y descarta cualquier dato previo
+func (t *statsTracker) start(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.since = now
+	t.baseIn, t.baseOut = 0, 0
+	t.offsetIn, t.offsetOut = 0, 0
+	t.lastIn, t.lastOut = 0, 0
+	t.lastAt = time.Time{}
+	t.rateIn, t.rateOut = 0, 0
+	t.hasSample = false
+}
+
+// update registra una lectura de contadores acumulados de la fuente indicada
+func (t *statsTracker) update(source statsSource, in, out uint64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.since.IsZero() {
+		return
+	}
+
+	if !t.hasSample {
+		t.source = source
+	} else if source != t.source {
+		// La primera lectura de la nueva fuente es su punto de partida: el
+		// tráfico entre la última lectura anterior y esta no se cuenta
+		t.baseIn += t.lastIn
+		t.baseOut += t.lastOut
+		t.source = source
+		t.offsetIn, t.offsetOut = in, out
+		t.lastIn, t.lastOut = 0, 0
+		t.lastAt = now
+		return
+	}
+
+	if t.hasSample {
+		if in < t.offsetIn+t.lastIn || out < t.offsetOut+t.lastOut {
+			// Los contadores se reiniciaron
+			t.baseIn += t.lastIn
+			t.baseOut += t.lastOut
+			t.offsetIn, t.offsetOut = 0, 0
+			t.lastIn, t.lastOut = 0, 0
+		}
+		if elapsed := now.Sub(t.lastAt).Seconds(); elapsed > 0 {
+			t.rateIn = float64(in-t.offsetIn-t.lastIn) / elapsed
+			t.rateOut = float64(out-t.offsetOut-t.lastOut) / elapsed
+		}
+	}
+
+	t.lastIn, t.lastOut = in-t.offsetIn, out-t.offsetOut
+	t.lastAt = now
+	t.hasSample = true
+}
+
+// snapshot retorna el estado actual
+func (t *statsTracker) snapshot() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return Stats{
+		ConnectedSince: t.since,
+		BytesIn:        t.baseIn + t.lastIn,
+		BytesOut:       t.baseOut + t.lastOut,
+		RateIn:         t.rateIn,
+		RateOut:        t.rateOut,
+		UpdatedAt:      t.lastAt,
+	}
+}
+
+// stale indica si no llegaron datos en el tiempo indicado
+func (t *statsTracker) stale(now time.Time, maxAge time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.since.IsZero() {
+		return false
+	}
+	last := t.lastAt
+	if last.IsZero() {
+		last = t.since
+	}
+	return now.Sub(last) > maxAge
+}
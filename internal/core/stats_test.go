@@ -0,0 +1,108 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsTracker(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	type sample struct {
+		source  statsSource
+		in, out uint64
+		at      int
+	}
+	tests := []struct {
+		name            string
+		samples         []sample
+		wantIn, wantOut uint64
+		wantRateIn      float64
+	}{
+		{
+			name:       "contadores que crecen",
+			samples:    []sample{{sourceByteCount, 1000, 100, 1}, {sourceByteCount, 3000, 300, 3}},
+			wantIn:     3000,
+			wantOut:    300,
+			wantRateIn: 1000,
+		},
+		{
+			name: "reinicio de OpenVPN",
+			samples: []sample{
+				{sourceByteCount, 5000, 500, 1},
+				{sourceByteCount, 200, 20, 2}, // ping-restart: vuelven a cero
+				{sourceByteCount, 1200, 120, 3},
+			},
+			wantIn:     6200,
+			wantOut:    620,
+			wantRateIn: 1000,
+		},
+		{
+			name: "cambio de fuente",
+			samples: []sample{
+				{sourceByteCount, 1000, 100, 1},
+				// La interfaz ya contaba tráfico antes de conectar
+				{sourceInterface, 90000, 9000, 5},
+				{sourceInterface, 92000, 9200, 7},
+				// Vuelve >BYTECOUNT:, con sus propios totales
+				{sourceByteCount, 4000, 400, 8},
+				{sourceByteCount, 4500, 450, 9},
+			},
+			wantIn:     1000 + 2000 + 500,
+			wantOut:    100 + 200 + 50,
+			wantRateIn: 500,
+		},
+		{
+			name: "reinicio de la interfaz tras cambiar de fuente",
+			samples: []sample{
+				{sourceByteCount, 800, 80, 1},
+				{sourceInterface, 51000, 5100, 5},
+				{sourceInterface, 52000, 5200, 6},
+				{sourceInterface, 300, 30, 8}, // El túnel se volvió a crear
+			},
+			wantIn:     800 + 1000 + 300,
+			wantOut:    80 + 100 + 30,
+			wantRateIn: 150,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker statsTracker
+			tracker.update(sourceByteCount, 999, 999, start) // Antes de conectar no cuenta
+			tracker.start(start)
+			for _, s := range tt.samples {
+				tracker.update(s.source, s.in, s.out, at(s.at))
+			}
+
+			got := tracker.snapshot()
+			if got.BytesIn != tt.wantIn || got.BytesOut != tt.wantOut {
+				t.Errorf("totales = %d/%d, se esperaba %d/%d", got.BytesIn, got.BytesOut, tt.wantIn, tt.wantOut)
+			}
+			if got.RateIn != tt.wantRateIn {
+				t.Errorf("tasa = %v, se esperaba %v", got.RateIn, tt.wantRateIn)
+			}
+		})
+	}
+}
+
+func TestStatsTrackerStale(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	var tracker statsTracker
+	if tracker.stale(start.Add(time.Hour), time.Second) {
+		t.Error("sin sesión no hay datos vencidos")
+	}
+
+	tracker.start(start)
+	if tracker.stale(start.Add(time.Second), 3*time.Second) {
+		t.Error("recién conectado no está vencido")
+	}
+	if !tracker.stale(start.Add(4*time.Second), 3*time.Second) {
+		t.Error("sin lecturas desde el inicio debería estar vencido")
+	}
+	tracker.update(sourceInterface, 10, 10, start.Add(4*time.Second))
+	if tracker.stale(start.Add(5*time.Second), 3*time.Second) {
+		t.Error("con una lectura reciente no está vencido")
+	}
+}
@@ -0,0 +1,576 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+// ReconnectPolicy define cómo se reintenta una sesión que se cayó
+type ReconnectPolicy struct {
+	MaxAttempts  int           // 0 desactiva la reconexión automática
+	InitialDelay time.Duration // Espera antes del primer reintento
+	MaxDelay     time.Duration // Tope de la espera entre reintentos
+	Multiplier   float64       // Factor de crecimiento exponencial
+	Jitter       float64       // Variación aleatoria (0.2 = ±20%)
+}
+
+// DefaultReconnectPolicy retorna la política de reconexión por defecto
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		MaxAttempts:  5,
+		InitialDelay: 2 * time.Second,
+		MaxDelay:     60 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+// Delay calcula la espera antes del intento indicado (empezando en 1)
+func (p ReconnectPolicy) Delay(attempt int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if max := float64(p.MaxDelay); p.MaxDelay > 0 && delay > max {
+		delay = max
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// Supervisor mantiene una sesión VPN por encima de Manager: si OpenVPN
+// termina después de haber conectado, la reinicia con backoff exponencial
+// reutilizando las credenciales en memoria. Los errores fatales y las
+// desconexiones pedidas por el usuario no provocan reconexión.
+// También sigue los cambios de red: si cambia la salida a Internet reinicia
+// el túnel, y mientras no hay red las reconexiones esperan.
+// Su máquina de estados resume la de cada sesión y cubre las esperas entre
+// reintentos, así que la UI puede mostrar su estado directamente.
+type Supervisor struct {
+	ovpnPath      string
+	openvpnBinary string
+	policy        ReconnectPolicy
+	options       SessionOptions
+	launch        Launcher
+
+	events chan Event
+	stopCh chan struct{}
+	done   chan struct{}
+	fsm    *StateMachine
+
+	mu      sync.Mutex
+	manager *Manager
+
+	// Monitor de red (nil lo desactiva) y huella de la salida a Internet
+	network      func() (platform.NetworkMonitor, error)
+	uplink       func() string
+	debounce     time.Duration
+	offline      bool          // No hay red: las reconexiones esperan
+	reconnectNow bool          // Reconectar sin esperar el backoff
+	wake         chan struct{} // Interrumpe la espera entre reintentos
+	networkQuit  chan struct{} // Detiene el monitor de red
+	networkDone  chan struct{} // Se cierra cuando terminó el monitor de red
+}
+
+// NewSupervisor crea un supervisor para el perfil indicado
+func NewSupervisor(ovpnPath, openvpnBinary string, policy ReconnectPolicy) *Supervisor {
+	s := &Supervisor{
+		ovpnPath:      ovpnPath,
+		openvpnBinary: openvpnBinary,
+		policy:        policy,
+		launch:        DefaultLauncher(),
+		events:        make(chan Event, 100),
+		stopCh:        make(chan struct{}),
+		done:          make(chan struct{}),
+		wake:          make(chan struct{}, 1),
+		debounce:      networkDebounce,
+	}
+	plat := platform.New()
+	s.network = plat.WatchNetwork
+	s.uplink = func() string { return systemUplink(plat, s.ConnectionInfo().Device) }
+	s.fsm = NewStateMachine(func(from, to State) {
+		s.emit(Event{Type: EventStateChanged, State: to, PrevState: from})
+	})
+	return s
+}
+
+// State retorna el estado de la sesión supervisada
+func (s *Supervisor) State() State {
+	return s.fsm.Current()
+}
+
+// transition cambia el estado; las transiciones inválidas se registran y se ignoran
+func (s *Supervisor) transition(to State) {
+	if err := s.fsm.Transition(to); err != nil {
+		s.emit(Event{Type: EventLogLine, Message: "Cambio de estado ignorado: " + err.Error()})
+	}
+}
+
+// SetLauncher cambia cómo se lanza OpenVPN. Debe llamarse antes de Start.
+func (s *Supervisor) SetLauncher(launch Launcher) {
+	s.launch = launch
+}
+
+// SetOptions cambia las preferencias del perfil. Debe llamarse antes de Start o Adopt.
+func (s *Supervisor) SetOptions(options SessionOptions) {
+	s.options = options
+}
+
+// Start inicia la primera sesión. Los errores de arranque se retornan
+// directamente y no provocan reintentos.
+func (s *Supervisor) Start() error {
+	s.transition(StateStarting)
+	killSwitch, err := prepareKillSwitch(platform.New(), s.ovpnPath, s.options.KillSwitch)
+	if err != nil {
+		s.transition(StateFailed)
+		return err
+	}
+	s.options.KillSwitch = killSwitch
+
+	mgr, err := startWithCredentials(s.launch, s.ovpnPath, s.openvpnBinary, "", "", s.options)
+	if err != nil {
+		s.transition(StateFailed)
+		return err
+	}
+
+	s.mu.Lock()
+	s.manager = mgr
+	s.mu.Unlock()
+
+	s.watchNetwork()
+	go s.run(mgr)
+	return nil
+}
+
+// Adopt toma el control de una sesión que quedó corriendo de una ejecución
+// anterior (ver FindOrphanSessions) en lugar de iniciar una nueva. Si el túnel
+// se cae después, la reconexión usa el perfil del registro de la sesión.
+func (s *Supervisor) Adopt(orphan OrphanSession) error {
+	s.transition(StateStarting)
+	if s.options.KillSwitch.Enabled {
+		// El bloqueo sigue activo: las reconexiones necesitan las IPs de los servidores
+		if pinned, err := pinRemotes(s.ovpnPath); err == nil {
+			s.options.KillSwitch.pinned = pinned
+		}
+	}
+	mgr, err := adoptSession(orphan, s.options)
+	if err != nil {
+		s.transition(StateFailed)
+		return err
+	}
+
+	s.mu.Lock()
+	s.manager = mgr
+	s.mu.Unlock()
+
+	s.watchNetwork()
+	go s.run(mgr)
+	return nil
+}
+
+// Events retorna el canal de eventos de todas las sesiones supervisadas
+func (s *Supervisor) Events() <-chan Event {
+	return s.events
+}
+
+// SendFunctions retorna funciones que envían credenciales a la sesión actual
+func (s *Supervisor) SendFunctions() SendFns {
+	return SendFns{
+		Username: func(v string) error { return s.send(func(f SendFns) error { return f.Username(v) }) },
+		Password: func(v string) error { return s.send(func(f SendFns) error { return f.Password(v) }) },
+		OTP:      func(v string) error { return s.send(func(f SendFns) error { return f.OTP(v) }) },
+		Credentials: func(u, p, o string) error {
+			return s.send(func(f SendFns) error { return f.Credentials(u, p, o) })
+		},
+	}
+}
+
+// Stats retorna las estadísticas de tráfico de la sesión actual
+func (s *Supervisor) Stats() Stats {
+	s.mu.Lock()
+	mgr := s.manager
+	s.mu.Unlock()
+
+	if mgr == nil {
+		return Stats{}
+	}
+	return mgr.Stats()
+}
+
+// Health retorna el resumen del chequeo de salud de la sesión actual
+func (s *Supervisor) Health() Health {
+	s.mu.Lock()
+	mgr := s.manager
+	s.mu.Unlock()
+
+	if mgr == nil {
+		return Health{}
+	}
+	return mgr.Health()
+}
+
+// ConnectionInfo retorna la configuración de red de la sesión actual
+func (s *Supervisor) ConnectionInfo() ConnectionInfo {
+	s.mu.Lock()
+	mgr := s.manager
+	s.mu.Unlock()
+
+	if mgr == nil {
+		return ConnectionInfo{}
+	}
+	return mgr.ConnectionInfo()
+}
+
+// Stop detiene la sesión actual y cancela cualquier reconexión pendiente.
+// Puede tardar varios segundos mientras OpenVPN cierra el túnel; la UI debe
+// llamarlo fuera de su hilo principal. El kill switch sigue activo (ver DisableKillSwitch).
+func (s *Supervisor) Stop() error {
+	s.mu.Lock()
+	select {
+	case <-s.stopCh:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	close(s.stopCh)
+	mgr := s.manager
+	s.mu.Unlock()
+
+	var err error
+	if mgr != nil {
+		err = mgr.Stop()
+	}
+	<-s.done
+
+	// Los eventos ya no se publican: el estado final queda en State()
+	if s.fsm.Current() != StateFailed {
+		s.fsm.Transition(StateDisconnecting)
+		s.fsm.Transition(StateIdle)
+	}
+	return err
+}
+
+// Detach deja de supervisar la sesión sin detener OpenVPN (ver Manager.Detach).
+// Cancela cualquier reconexión pendiente.
+func (s *Supervisor) Detach() error {
+	s.mu.Lock()
+	select {
+	case <-s.stopCh:
+		s.mu.Unlock()
+		return nil
+	default:
+	}
+	close(s.stopCh)
+	mgr := s.manager
+	s.mu.Unlock()
+
+	var err error
+	if mgr != nil {
+		err = mgr.Detach()
+	}
+	<-s.done
+	return err
+}
+
+// send ejecuta fn sobre las funciones de envío de la sesión actual
+func (s *Supervisor) send(fn func(SendFns) error) error {
+	s.mu.Lock()
+	mgr := s.manager
+	s.mu.Unlock()
+
+	if mgr == nil {
+		return fmt.Errorf("no hay una sesión activa")
+	}
+	return fn(mgr.SendFunctions())
+}
+
+// run reenvía los eventos de cada sesión y decide si reconectar
+func (s *Supervisor) run(mgr *Manager) {
+	defer func() {
+		// El monitor de red publica eventos: termina antes de cerrar el canal
+		if s.networkQuit != nil {
+			close(s.networkQuit)
+			<-s.networkDone
+		}
+		close(s.events)
+		close(s.done)
+	}()
+
+	attempt := 0
+	last := mgr
+	for {
+		var (
+			connected, fatal bool
+			disconnected     *Event
+		)
+		if mgr != nil {
+			connected, fatal, disconnected = s.forward(mgr)
+			last = mgr
+		}
+		if connected {
+			attempt = 0
+		}
+
+		if s.stopped() || fatal {
+			return
+		}
+
+		// Solo se reconecta una sesión que llegó a conectar, o que ya
+		// estaba en una secuencia de reintentos, o que se cerró por un cambio de red
+		immediate := s.takeReconnectRequest()
+		if !connected && attempt == 0 && !immediate {
+			s.emitDisconnected(disconnected)
+			return
+		}
+
+		attempt++
+		if attempt > s.policy.MaxAttempts {
+			if s.policy.MaxAttempts == 0 {
+				s.emitDisconnected(disconnected)
+			} else {
+				s.transition(StateFailed)
+				s.emit(Event{
+					Type:    EventFatal,
+					Message: fmt.Sprintf("No se pudo reconectar tras %d intentos", s.policy.MaxAttempts),
+				})
+			}
+			return
+		}
+
+		s.transition(StateReconnecting)
+		if !immediate && !s.countdown(attempt) {
+			return
+		}
+
+		username, password := last.credentials()
+		next, err := startWithCredentials(s.launch, s.ovpnPath, s.openvpnBinary, username, password, s.options)
+		if err != nil {
+			// El arranque falló: se cuenta como un intento más
+			s.emit(Event{Type: EventLogLine, Message: "Error al reconectar: " + err.Error()})
+			mgr = nil
+			continue
+		}
+
+		s.mu.Lock()
+		if s.stopped() {
+			s.mu.Unlock()
+			next.Stop()
+			return
+		}
+		s.manager = next
+		s.mu.Unlock()
+		mgr = next
+	}
+}
+
+// forward reenvía los eventos de una sesión hasta que termina.
+// EventDisconnected se retiene porque puede convertirse en una reconexión.
+func (s *Supervisor) forward(mgr *Manager) (connected, fatal bool, disconnected *Event) {
+	for event := range mgr.Events() {
+		switch event.Type {
+		case EventConnected:
+			connected = true
+		case EventFatal:
+			fatal = true
+		case EventDisconnected:
+			ev := event
+			disconnected = &ev
+			continue
+		case EventStateChanged:
+			// El fin de cada sesión lo decide el supervisor (puede reconectar)
+			if event.State != StateDisconnecting && event.State != StateIdle {
+				s.transition(event.State)
+			}
+			continue
+		}
+		s.emit(event)
+	}
+	return connected, fatal, disconnected
+}
+
+// countdown espera el backoff del intento publicando el tiempo restante
+// cada segundo. Sin red la espera se prolonga hasta que vuelva, sin gastar
+// intentos; un cambio de red la corta. Retorna false si el supervisor se
+// detuvo mientras tanto.
+func (s *Supervisor) countdown(attempt int) bool {
+	deadline := time.Now().Add(s.policy.Delay(attempt))
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if s.isOffline() {
+			s.emit(Event{
+				Type:        EventReconnecting,
+				Message:     "Sin conexión de red",
+				Attempt:     attempt,
+				MaxAttempts: s.policy.MaxAttempts,
+				Offline:     true,
+			})
+			select {
+			case <-s.stopCh:
+				return false
+			case <-s.wake:
+				if !s.isOffline() {
+					return true // La red volvió: se reintenta ya
+				}
+				continue
+			}
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return true
+		}
+
+		s.emit(Event{
+			Type:        EventReconnecting,
+			Message:     "Conexión perdida",
+			Attempt:     attempt,
+			MaxAttempts: s.policy.MaxAttempts,
+			Delay:       remaining.Round(time.Second),
+		})
+
+		select {
+		case <-s.stopCh:
+			return false
+		case <-s.wake:
+			if !s.isOffline() {
+				return true
+			}
+		case <-ticker.C:
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// watchNetwork inicia el monitor de red de la supervisión. Sin monitor (por
+// ejemplo en plataformas que no lo implementan) la sesión funciona igual.
+func (s *Supervisor) watchNetwork() {
+	if s.network == nil {
+		return
+	}
+	monitor, err := s.network()
+	if err != nil {
+		s.emit(Event{Type: EventLogLine, Message: "No se vigilarán los cambios de red: " + err.Error()})
+		return
+	}
+
+	w := &networkWatcher{
+		monitor:  monitor,
+		uplink:   s.uplink,
+		debounce: s.debounce,
+		onChange: s.networkChanged,
+	}
+	s.networkQuit = make(chan struct{})
+	s.networkDone = make(chan struct{})
+	go func() {
+		defer close(s.networkDone)
+		w.run(s.networkQuit)
+	}()
+}
+
+// networkChanged reacciona a un cambio de la salida a Internet: sin red las
+// reconexiones esperan; con una red nueva el túnel se reinicia (RestartTunnel)
+func (s *Supervisor) networkChanged(online bool) {
+	s.mu.Lock()
+	wasOffline := s.offline
+	s.offline = !online
+	s.mu.Unlock()
+
+	if !online {
+		s.emit(Event{Type: EventLogLine, Message: "Sin conexión de red: las reconexiones esperan a que vuelva"})
+		return
+	}
+	if wasOffline {
+		s.emit(Event{Type: EventLogLine, Message: "La conexión de red volvió"})
+	} else {
+		s.emit(Event{Type: EventLogLine, Message: "La red cambió"})
+	}
+
+	s.RestartTunnel("el cambio de red")
+}
+
+// RestartTunnel reinicia un túnel activo en suave (SIGUSR1) para que OpenVPN
+// abra un socket nuevo; si no se puede, la sesión se cierra y se reconecta de
+// inmediato. Una espera entre reintentos se corta para reintentar ya.
+func (s *Supervisor) RestartTunnel(reason string) {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.mu.Lock()
+	mgr := s.manager
+	s.mu.Unlock()
+	if mgr == nil {
+		return
+	}
+	if state := mgr.State(); !state.TunnelUp() && state != StateReconnecting {
+		return // Autenticando o terminando: no hay túnel que reiniciar
+	}
+	err := mgr.softRestart()
+	if err == nil {
+		s.emit(Event{Type: EventLogLine, Message: "Reiniciando el túnel por " + reason + " (SIGUSR1)"})
+		return
+	}
+
+	s.emit(Event{Type: EventLogLine, Message: "No se pudo reiniciar el túnel (" + err.Error() + "); reconectando"})
+	s.mu.Lock()
+	s.reconnectNow = true
+	s.mu.Unlock()
+	go mgr.Stop()
+}
+
+// takeReconnectRequest indica (y olvida) si se pidió reconectar sin esperar
+func (s *Supervisor) takeReconnectRequest() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.reconnectNow
+	s.reconnectNow = false
+	return now
+}
+
+// isOffline indica si el monitor de red informó que no hay red
+func (s *Supervisor) isOffline() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offline
+}
+
+// emitDisconnected publica el fin definitivo de la sesión
+func (s *Supervisor) emitDisconnected(event *Event) {
+	s.transition(StateDisconnecting)
+	if event == nil {
+		event = &Event{Type: EventDisconnected, Message: "Proceso OpenVPN terminado"}
+	}
+	s.emit(*event)
+	s.transition(StateIdle)
+}
+
+// emit publica un evento salvo que el supervisor ya se haya detenido
+func (s *Supervisor) emit(event Event) {
+	select {
+	case <-s.stopCh:
+		return
+	default:
+	}
+
+	select {
+	case s.events <- event:
+	case <-s.stopCh:
+	}
+}
+
+// stopped indica si se pidió detener el supervisor
+func (s *Supervisor) stopped() bool {
+	select {
+	case <-s.stopCh:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,219 @@
+//go:build !windows
+
+package core
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform"
+)
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	policy := ReconnectPolicy{InitialDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := policy.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %s, se esperaba %s", i+1, got, w)
+		}
+	}
+
+	// Con jitter la espera varía dentro de ±20% del valor sin jitter
+	policy.Jitter = 0.2
+	for attempt, base := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: 5 * time.Second} {
+		seen := make(map[time.Duration]bool)
+		for i := 0; i < 200; i++ {
+			got := policy.Delay(attempt)
+			if got < base*8/10 || got > base*12/10 {
+				t.Fatalf("Delay(%d) = %s, fuera de ±20%% de %s", attempt, got, base)
+			}
+			seen[got] = true
+		}
+		if len(seen) < 2 {
+			t.Errorf("Delay(%d) no varía con jitter", attempt)
+		}
+	}
+}
+
+// scenarioLauncher lanza el OpenVPN falso con un escenario por arranque (el
+// último se repite; "" hace fallar el arranque) y cuenta los arranques
+type scenarioLauncher struct {
+	mu        sync.Mutex
+	scenarios []string
+	launches  int
+}
+
+func (l *scenarioLauncher) launch(config platform.StartConfig) (*platform.Process, error) {
+	l.mu.Lock()
+	scenario := l.scenarios[min(l.launches, len(l.scenarios)-1)]
+	l.launches++
+	l.mu.Unlock()
+
+	if scenario == "" {
+		return nil, errors.New("no se pudo lanzar OpenVPN")
+	}
+	return fakeLauncher(scenario)(config)
+}
+
+func (l *scenarioLauncher) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.launches
+}
+
+// startSupervisor inicia un Supervisor contra el OpenVPN falso, sin monitor de red
+func startSupervisor(t *testing.T, policy ReconnectPolicy, scenarios ...string) (*Supervisor, *scenarioLauncher) {
+	t.Helper()
+
+	s := NewSupervisor(testProfile(t, ""), fakeOpenVPN, policy)
+	s.network = nil
+	launcher := &scenarioLauncher{scenarios: scenarios}
+	s.SetLauncher(launcher.launch)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { s.Stop() })
+	return s, launcher
+}
+
+// superviseEvents responde a los pedidos de credenciales y registra los
+// eventos (sin líneas de log) hasta que until los acepta o la supervisión
+// termina (until nil)
+func superviseEvents(t *testing.T, s *Supervisor, until func(got []string) bool) []string {
+	t.Helper()
+
+	send := s.SendFunctions()
+	timeout := time.After(10 * time.Second)
+	var got []string
+	for {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				return got
+			}
+			if e.Type == EventLogLine {
+				continue
+			}
+			got = append(got, describe(e))
+
+			switch e.Type {
+			case EventAskUser:
+				send.Username("alice")
+			case EventAskPass:
+				send.Password("secret")
+			}
+			if until != nil && until(got) {
+				return got
+			}
+		case <-timeout:
+			t.Fatalf("timeout esperando eventos; recibidos: %q", got)
+		}
+	}
+}
+
+// countEvents cuenta cuántas veces aparece el evento
+func countEvents(events []string, name string) int {
+	n := 0
+	for _, e := range events {
+		if e == name {
+			n++
+		}
+	}
+	return n
+}
+
+// connectedTimes espera hasta la n-ésima conexión
+func connectedTimes(n int) func([]string) bool {
+	return func(got []string) bool { return countEvents(got, "Connected:10.8.0.6") == n }
+}
+
+var fastReconnect = ReconnectPolicy{MaxAttempts: 2, InitialDelay: 10 * time.Millisecond, Multiplier: 2}
+
+func TestSupervisorReconnects(t *testing.T) {
+	s, launcher := startSupervisor(t, fastReconnect, "exit", "success")
+
+	got := superviseEvents(t, s, connectedTimes(2))
+	if launcher.count() != 2 {
+		t.Errorf("arranques = %d, se esperaban 2", launcher.count())
+	}
+
+	// La reconexión reutiliza las credenciales: no se vuelven a pedir
+	reconnect := slices.Index(got, "Reconnecting")
+	if reconnect < 0 {
+		t.Fatalf("no hubo reconexión: %q", got)
+	}
+	if slices.Contains(got[reconnect:], "AskUser") || slices.Contains(got[reconnect:], "AskPass") {
+		t.Errorf("se pidieron credenciales al reconectar: %q", got)
+	}
+	if s.State() != StateConnected {
+		t.Errorf("estado = %s, se esperaba Connected", s.State())
+	}
+}
+
+func TestSupervisorGivesUpAfterMaxAttempts(t *testing.T) {
+	// Después de la primera sesión ningún arranque funciona
+	s, launcher := startSupervisor(t, fastReconnect, "exit", "")
+
+	got := superviseEvents(t, s, nil)
+	if launcher.count() != 1+fastReconnect.MaxAttempts {
+		t.Errorf("arranques = %d, se esperaban %d", launcher.count(), 1+fastReconnect.MaxAttempts)
+	}
+	if n := countEvents(got, "Reconnecting"); n != fastReconnect.MaxAttempts {
+		t.Errorf("esperas de reconexión = %d, se esperaban %d: %q", n, fastReconnect.MaxAttempts, got)
+	}
+	if got[len(got)-1] != "Fatal" || s.State() != StateFailed {
+		t.Errorf("eventos = %q, estado = %s; se esperaba Fatal y Failed", got, s.State())
+	}
+}
+
+func TestSupervisorDoesNotReconnect(t *testing.T) {
+	t.Run("error fatal", func(t *testing.T) {
+		s, launcher := startSupervisor(t, fastReconnect, "fatal")
+
+		got := superviseEvents(t, s, nil)
+		if launcher.count() != 1 || slices.Contains(got, "Reconnecting") {
+			t.Errorf("arranques = %d, eventos = %q; no debía reconectar", launcher.count(), got)
+		}
+		if s.State() != StateFailed {
+			t.Errorf("estado = %s, se esperaba Failed", s.State())
+		}
+	})
+
+	t.Run("desconexión del usuario", func(t *testing.T) {
+		s, launcher := startSupervisor(t, fastReconnect, "success")
+		superviseEvents(t, s, connectedTimes(1))
+
+		if err := s.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(100 * time.Millisecond) // Más que la espera entre reintentos
+		if launcher.count() != 1 {
+			t.Errorf("arranques = %d, no debía reconectar", launcher.count())
+		}
+		if s.State() != StateIdle {
+			t.Errorf("estado = %s, se esperaba Idle", s.State())
+		}
+	})
+}
+
+func TestSupervisorStopDuringCountdown(t *testing.T) {
+	s, launcher := startSupervisor(t, ReconnectPolicy{MaxAttempts: 3, InitialDelay: time.Hour, Multiplier: 1}, "exit")
+	superviseEvents(t, s, func(got []string) bool { return slices.Contains(got, "Reconnecting") })
+
+	start := time.Now()
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Stop tardó %s durante la espera", elapsed)
+	}
+	if launcher.count() != 1 {
+		t.Errorf("arranques = %d, no debía reconectar", launcher.count())
+	}
+	if s.State() != StateIdle {
+		t.Errorf("estado = %s, se esperaba Idle", s.State())
+	}
+}
@@ -0,0 +1,362 @@
+// fakeopenvpn imita a OpenVPN para los tests de integración de internal/core.
+// Abre el management interface protegido con contraseña, imprime líneas de log
+// como las de OpenVPN y sigue un escenario predefinido:
+//
+//	fakeopenvpn --config perfil.ovpn --management <socket> unix <pwfile> --scenario <nombre>
+//
+// Escenarios:
+//
+//	success         acepta alice/secret y conecta
+//	wrong-password  igual que success; una contraseña distinta se rechaza
+//	otp-retry       pide OTP con static-challenge; solo acepta secret y 123456
+//	web-auth        pide inicio de sesión web (WEB_AUTH) antes de conectar
+//	crv1            responde a alice/secret con un challenge dinámico CRV1;
+//	                solo acepta CRV1::<state>::123456
+//	fatal           envía >FATAL: al liberar el hold y termina
+//	exit            termina de golpe después de conectar
+//	ignore-sigterm  conecta pero ignora SIGTERM (por management y por señal)
+//
+// Con --management-query-remote pregunta por >REMOTE: a qué servidor conectar
+// (vpn.example.com, como el perfil de los tests) y usa la IP de "remote MOD".
+//
+// Como OpenVPN, sigue corriendo si el cliente del management interface se
+// desconecta y acepta un cliente nuevo (para adoptar sesiones huérfanas).
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	validUsername = "alice"
+	validPassword = "secret"
+	validOTP      = "123456"
+	crv1State     = "Om01u7"
+)
+
+type fakeOpenVPN struct {
+	scenario    string
+	conn        net.Conn
+	username    string
+	queryRemote bool     // --management-query-remote
+	remoteIP    string   // IP del servidor al que "conecta"
+	released    bool     // Ya se liberó el hold
+	crv1Sent    bool     // Se envió el challenge CRV1 y se espera su respuesta
+	states      []string // Historial de estados, para el comando "state"
+	logs        []string // Historial del log, para "log on all"
+}
+
+func main() {
+	var (
+		socket, pwFile, scenario string
+		queryRemote              bool
+	)
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--management":
+			if i+3 < len(args) {
+				socket, pwFile = args[i+1], args[i+3]
+				i += 3
+			}
+		case "--scenario":
+			if i+1 < len(args) {
+				scenario = args[i+1]
+				i++
+			}
+		case "--management-query-remote":
+			queryRemote = true
+		}
+	}
+	if socket == "" || pwFile == "" {
+		fatalf("se requiere --management <socket> unix <pwfile>")
+	}
+	if scenario == "ignore-sigterm" {
+		signal.Ignore(syscall.SIGTERM)
+	}
+
+	data, err := os.ReadFile(pwFile)
+	if err != nil {
+		fatalf("no se pudo leer %s: %v", pwFile, err)
+	}
+	password := strings.TrimSpace(string(data))
+
+	os.Remove(socket)
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		fatalf("no se pudo abrir el management interface: %v", err)
+	}
+	defer os.Remove(socket)
+
+	f := &fakeOpenVPN{
+		scenario:    scenario,
+		queryRemote: queryRemote,
+		remoteIP:    "203.0.113.1",
+		states:      []string{stateLine("CONNECTING", "", "")},
+	}
+	f.log("OpenVPN 2.6.0 [fake] x86_64-pc-linux-gnu")
+	f.log("MANAGEMENT: unix domain socket listening on " + socket)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			fatalf("accept: %v", err)
+		}
+		f.serve(conn, password)
+	}
+}
+
+// serve atiende a un cliente del management interface hasta que se desconecta
+func (f *fakeOpenVPN) serve(conn net.Conn, password string) {
+	defer conn.Close()
+	f.conn = conn
+	reader := bufio.NewReader(conn)
+
+	// Autenticación del management interface
+	f.write("ENTER PASSWORD:")
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	if strings.TrimSpace(line) != password {
+		f.writeln("ERROR: bad password")
+		return
+	}
+	f.writeln("SUCCESS: password is correct")
+	f.writeln(">INFO:OpenVPN Management Interface Version 5 -- type 'help' for more info")
+	if !f.released {
+		f.writeln(">HOLD:Waiting for hold release:0")
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f.command(strings.TrimSpace(line))
+	}
+}
+
+// command responde a un comando del management interface
+func (f *fakeOpenVPN) command(cmd string) {
+	verb, rest, _ := strings.Cut(cmd, " ")
+	switch verb {
+	case "version", "bytecount":
+		f.writeln("SUCCESS: " + cmd)
+
+	case "state":
+		switch rest {
+		case "":
+			f.history(f.states[len(f.states)-1:])
+		case "on all":
+			f.history(f.states)
+			f.writeln("SUCCESS: real-time state notification set to ON")
+		default:
+			f.writeln("SUCCESS: " + cmd)
+		}
+
+	case "log":
+		if rest == "on all" {
+			f.history(f.logs)
+		}
+		f.writeln("SUCCESS: real-time log notification set to ON")
+
+	case "hold":
+		f.released = true
+		f.writeln("SUCCESS: hold release succeeded")
+		if f.scenario == "fatal" {
+			f.writeln(">FATAL:Cannot open TUN/TAP dev /dev/net/tun: No such file or directory (errno=2)")
+			f.exit(1)
+		}
+		if f.queryRemote {
+			f.writeln(">REMOTE:vpn.example.com,1194,udp")
+			return
+		}
+		f.needAuth()
+
+	case "remote":
+		if action, addr, _ := strings.Cut(rest, " "); action == "MOD" {
+			f.remoteIP, _, _ = strings.Cut(addr, " ")
+		}
+		f.writeln("SUCCESS: remote command succeeded")
+		f.needAuth()
+
+	case "username":
+		f.username = unquote(secondField(rest))
+		f.writeln("SUCCESS: 'Auth' username entered, but not yet verified")
+
+	case "password":
+		f.writeln("SUCCESS: 'Auth' password entered, but not yet verified")
+		f.verify(unquote(secondField(rest)))
+
+	case "signal":
+		f.writeln("SUCCESS: signal " + rest + " thrown")
+		if (rest == "SIGTERM" || rest == "SIGINT") && f.scenario != "ignore-sigterm" {
+			f.exit(0)
+		}
+
+	default:
+		f.writeln("ERROR: unknown command, enter 'help' for more options")
+	}
+}
+
+// needAuth pide usuario y contraseña (con static-challenge en otp-retry)
+func (f *fakeOpenVPN) needAuth() {
+	if f.scenario == "otp-retry" {
+		f.writeln(">PASSWORD:Need 'Auth' username/password SC:1,Enter OTP")
+		return
+	}
+	f.writeln(">PASSWORD:Need 'Auth' username/password")
+}
+
+// verify comprueba las credenciales recibidas y continúa el escenario
+func (f *fakeOpenVPN) verify(password string) {
+	otp := validOTP
+	if f.scenario == "crv1" {
+		var ok bool
+		if password, ok = f.verifyCRV1(password); !ok {
+			return
+		}
+	}
+	if f.scenario == "otp-retry" {
+		var ok bool
+		password, otp, ok = decodeSCRV1(password)
+		if !ok {
+			password = ""
+		}
+	}
+
+	if f.username != validUsername || password != validPassword || otp != validOTP {
+		f.log("AUTH: Received control message: AUTH_FAILED")
+		f.writeln(">PASSWORD:Verification Failed: 'Auth'")
+		f.notifyState("RECONNECTING", "auth-failure", "")
+		f.needAuth()
+		return
+	}
+
+	if f.scenario == "web-auth" {
+		f.notifyState("AUTH_PENDING", "timeout 60", "")
+		f.writeln(">INFO_PRE:WEB_AUTH::https://sso.example.com/login?session=fake")
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	f.log("UDPv4 link remote: [AF_INET]" + f.remoteIP + ":1194")
+	f.log("PUSH: Received control message: 'PUSH_REPLY,route 10.10.0.0 255.255.0.0,dhcp-option DNS 10.10.0.2," +
+		"dhcp-option DOMAIN corp.example.com,route-gateway 10.8.0.1,topology subnet,ifconfig 10.8.0.6 255.255.255.0'")
+	f.notifyState("ASSIGN_IP", "", "10.8.0.6")
+	f.log("TUN/TAP device tun0 opened")
+	f.notifyState("CONNECTED", "SUCCESS", "10.8.0.6")
+
+	if f.scenario == "exit" {
+		time.Sleep(100 * time.Millisecond)
+		f.exit(1)
+	}
+}
+
+// verifyCRV1 envía el challenge dinámico ante usuario y contraseña válidos
+// (retorna false: la verificación terminó) y traduce su respuesta a la
+// contraseña que comprueba verify
+func (f *fakeOpenVPN) verifyCRV1(password string) (string, bool) {
+	if f.crv1Sent {
+		f.crv1Sent = false
+		if password == "CRV1::"+crv1State+"::"+validOTP {
+			return validPassword, true
+		}
+		return "", true
+	}
+	if f.username != validUsername || password != validPassword {
+		return password, true
+	}
+
+	f.crv1Sent = true
+	username := base64.StdEncoding.EncodeToString([]byte(validUsername))
+	f.log("AUTH: Received control message: AUTH_FAILED,CRV1:R,E:" + crv1State + ":" + username + ":Enter PIN")
+	f.writeln(">PASSWORD:Verification Failed: 'Auth' ['CRV1:R,E:" + crv1State + ":" + username + ":Enter PIN']")
+	f.notifyState("RECONNECTING", "auth-failure", "")
+	f.needAuth()
+	return "", false
+}
+
+// notifyState cambia el estado y envía la notificación >STATE:
+func (f *fakeOpenVPN) notifyState(name, desc, localIP string) {
+	line := stateLine(name, desc, localIP)
+	f.states = append(f.states, line)
+	f.writeln(">STATE:" + line)
+}
+
+// log imprime una línea en la salida estándar y la guarda en el historial
+func (f *fakeOpenVPN) log(msg string) {
+	fmt.Println(msg)
+	f.logs = append(f.logs, fmt.Sprintf("%d,I,%s", time.Now().Unix(), msg))
+}
+
+// history envía un historial terminado en END, como "state" o "log on all"
+func (f *fakeOpenVPN) history(lines []string) {
+	for _, line := range lines {
+		f.writeln(line)
+	}
+	f.writeln("END")
+}
+
+// stateLine arma una línea de estado como las de >STATE: y el comando "state"
+func stateLine(name, desc, localIP string) string {
+	remote := ","
+	if name == "CONNECTED" {
+		remote = "203.0.113.1,1194"
+	}
+	return fmt.Sprintf("%d,%s,%s,%s,%s,,,", time.Now().Unix(), name, desc, localIP, remote)
+}
+
+func (f *fakeOpenVPN) write(s string) {
+	f.conn.Write([]byte(s))
+}
+
+func (f *fakeOpenVPN) writeln(s string) {
+	f.write(s + "\r\n")
+}
+
+// exit cierra el management interface y termina, como OpenVPN al salir
+func (f *fakeOpenVPN) exit(code int) {
+	time.Sleep(50 * time.Millisecond)
+	f.conn.Close()
+	os.Exit(code)
+}
+
+// secondField retorna el segundo argumento entrecomillado de un comando
+// (username "Auth" "valor")
+func secondField(s string) string {
+	_, value, _ := strings.Cut(s, `" `)
+	return value
+}
+
+func unquote(s string) string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, `"`), `"`)
+	s = strings.ReplaceAll(s, `\"`, `"`)
+	return strings.ReplaceAll(s, `\\`, `\`)
+}
+
+// decodeSCRV1 separa una respuesta SCRV1:<b64 contraseña>:<b64 respuesta>
+func decodeSCRV1(s string) (string, string, bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 || parts[0] != "SCRV1" {
+		return "", "", false
+	}
+	password, err1 := base64.StdEncoding.DecodeString(parts[1])
+	response, err2 := base64.StdEncoding.DecodeString(parts[2])
+	if err1 != nil || err2 != nil {
+		return "", "", false
+	}
+	return string(password), string(response), true
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "fakeopenvpn: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,215 @@
+package history
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/config"
+)
+
+// EndReason indica por qué terminó una sesión
+type EndReason string
+
+const (
+	EndUser        EndReason = "user"         // El usuario desconectó
+	EndFatal       EndReason = "fatal"        // OpenVPN reportó un error fatal
+	EndAuthFailure EndReason = "auth_failure" // Las credenciales fueron rechazadas
+	EndNetwork     EndReason = "network"      // El túnel se cayó y no se pudo recuperar
+	EndSleep       EndReason = "sleep"        // Se desconectó antes de suspender el equipo
+	EndLock        EndReason = "lock"         // Se desconectó al bloquear la pantalla
+	EndDetached    EndReason = "detached"     // La app se cerró y dejó el túnel conectado
+)
+
+// Record es el registro de una sesión VPN
+type Record struct {
+	Profile         string    `json:"profile"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	AssignedIP      string    `json:"assigned_ip,omitempty"`
+	BytesIn         uint64    `json:"bytes_in"`
+	BytesOut        uint64    `json:"bytes_out"`
+	EndReason       EndReason `json:"end_reason"`
+	AuthRetries     int       `json:"auth_retries"`
+	Degraded        int       `json:"degraded,omitempty"`        // Veces que el chequeo de salud marcó la conexión degradada
+	HealthRestarts  int       `json:"health_restarts,omitempty"` // Reinicios pedidos por el chequeo de salud
+}
+
+// Duration retorna la duración de la sesión
+func (r Record) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds) * time.Second
+}
+
+// Filter selecciona registros por fecha y perfil. Los campos vacíos no filtran.
+type Filter struct {
+	From    time.Time // Sesiones iniciadas a partir de este instante
+	To      time.Time // Sesiones iniciadas antes de este instante
+	Profile string
+}
+
+// Match indica si el registro cumple el filtro
+func (f Filter) Match(r Record) bool {
+	if !f.From.IsZero() && r.Start.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !r.Start.Before(f.To) {
+		return false
+	}
+	if f.Profile != "" && r.Profile != f.Profile {
+		return false
+	}
+	return true
+}
+
+// Store es el historial de sesiones en formato JSON Lines (un registro por línea,
+// solo se agregan líneas al final)
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultPath retorna la ruta del historial dentro del directorio de configuración
+func DefaultPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// Open retorna el historial almacenado en path (el archivo se crea al primer Append)
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append agrega un registro al final del historial
+func (s *Store) Append(r Record) error {
+	if r.DurationSeconds == 0 && !r.End.IsZero() {
+		r.DurationSeconds = int64(r.End.Sub(r.Start) / time.Second)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	// Si una escritura anterior quedó a medias, el registro empieza en una
+	// línea nueva para no quedar pegado a la línea dañada
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load lee los registros que cumplen el filtro, del más reciente al más antiguo.
+// Las líneas dañadas (p.ej. una escritura interrumpida) se ignoran.
+func (s *Store) Load(filter Filter) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if filter.Match(r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Start.After(records[j].Start)
+	})
+	return records, nil
+}
+
+// Profiles retorna los perfiles distintos presentes en los registros, ordenados
+func Profiles(records []Record) []string {
+	seen := make(map[string]bool)
+	var profiles []string
+	for _, r := range records {
+		if !seen[r.Profile] {
+			seen[r.Profile] = true
+			profiles = append(profiles, r.Profile)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// WriteCSV exporta los registros en formato CSV con encabezado
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"profile", "start", "end", "duration_seconds", "assigned_ip",
+		"bytes_in", "bytes_out", "end_reason", "auth_retries",
+		"degraded", "health_restarts",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		row := []string{
+			r.Profile,
+			r.Start.Format(time.RFC3339),
+			r.End.Format(time.RFC3339),
+			strconv.FormatInt(r.DurationSeconds, 10),
+			r.AssignedIP,
+			strconv.FormatUint(r.BytesIn, 10),
+			strconv.FormatUint(r.BytesOut, 10),
+			string(r.EndReason),
+			strconv.Itoa(r.AuthRetries),
+			strconv.Itoa(r.Degraded),
+			strconv.Itoa(r.HealthRestarts),
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("error al exportar el historial: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,159 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+// sessions son tres sesiones de dos perfiles, en el orden en que terminaron
+var sessions = []Record{
+	{Profile: "Oficina", Start: day, End: day.Add(90 * time.Minute), AssignedIP: "10.8.0.6", BytesIn: 2048, BytesOut: 512, EndReason: EndUser},
+	{Profile: "Producción", Start: day.Add(24 * time.Hour), End: day.Add(25 * time.Hour), EndReason: EndNetwork, Degraded: 2, HealthRestarts: 1},
+	{Profile: "Oficina", Start: day.Add(48 * time.Hour), End: day.Add(48*time.Hour + 30*time.Second), EndReason: EndAuthFailure, AuthRetries: 3},
+}
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	store := Open(filepath.Join(t.TempDir(), "navtunnel", "history.jsonl"))
+	for _, r := range sessions {
+		if err := store.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	store := openStore(t)
+
+	got, err := store.Load(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("registros = %d, se esperaban 3", len(got))
+	}
+	// Del más reciente al más antiguo, con la duración calculada
+	if got[0].Start != sessions[2].Start || got[2].Start != sessions[0].Start {
+		t.Errorf("orden incorrecto: %v, %v, %v", got[0].Start, got[1].Start, got[2].Start)
+	}
+	if got[2].Duration() != 90*time.Minute || got[0].DurationSeconds != 30 {
+		t.Errorf("duraciones = %s, %d s", got[2].Duration(), got[0].DurationSeconds)
+	}
+	want := sessions[0]
+	want.DurationSeconds = 90 * 60
+	if !reflect.DeepEqual(got[2], want) {
+		t.Errorf("registro leído:\n got: %+v\nwant: %+v", got[2], want)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	got, err := Open(filepath.Join(t.TempDir(), "history.jsonl")).Load(Filter{})
+	if err != nil || got != nil {
+		t.Errorf("Load() = %v, %v; se esperaba un historial vacío", got, err)
+	}
+}
+
+func TestLoadSkipsDamagedLines(t *testing.T) {
+	store := openStore(t)
+
+	// Una línea dañada en el medio y una escritura interrumpida al final
+	f, err := os.OpenFile(store.path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("no es json\n")
+	f.WriteString(`{"profile": "Oficina", "start": "2024-03-05T09:00:00Z", "end": "2024-`)
+	f.Close()
+
+	got, err := store.Load(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("registros = %d, se esperaban los 3 sanos", len(got))
+	}
+
+	// Lo que se agrega después sigue leyéndose
+	if err := store.Append(Record{Profile: "Oficina", Start: day.Add(72 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = store.Load(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 || got[0].Start != day.Add(72*time.Hour) {
+		t.Errorf("registros = %d; se perdió el agregado tras la línea truncada", len(got))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	store := openStore(t)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []time.Time
+	}{
+		{"por perfil", Filter{Profile: "Oficina"}, []time.Time{sessions[2].Start, sessions[0].Start}},
+		{"desde", Filter{From: day.Add(24 * time.Hour)}, []time.Time{sessions[2].Start, sessions[1].Start}},
+		{"hasta (excluido)", Filter{To: day.Add(24 * time.Hour)}, []time.Time{sessions[0].Start}},
+		{"rango y perfil", Filter{From: day, To: day.Add(72 * time.Hour), Profile: "Producción"}, []time.Time{sessions[1].Start}},
+		{"sin resultados", Filter{Profile: "Casa"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := store.Load(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []time.Time
+			for _, r := range records {
+				got = append(got, r.Start)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\n got: %v\nwant: %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Profiles(sessions); !reflect.DeepEqual(got, []string{"Oficina", "Producción"}) {
+		t.Errorf("Profiles = %q", got)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	records := []Record{
+		{Profile: `Cliente "ACME", sede norte`, Start: day, End: day.Add(time.Hour), DurationSeconds: 3600, BytesIn: 10, BytesOut: 20, EndReason: EndUser},
+		{Profile: "Oficina\nsegunda línea", Start: day, End: day, EndReason: EndSleep},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "profile,start,end,duration_seconds,assigned_ip,bytes_in,bytes_out,end_reason,auth_retries,degraded,health_restarts\n" +
+		`"Cliente ""ACME"", sede norte",2024-03-01T09:00:00Z,2024-03-01T10:00:00Z,3600,,10,20,user,0,0,0` + "\n" +
+		"\"Oficina\nsegunda línea\",2024-03-01T09:00:00Z,2024-03-01T09:00:00Z,0,,0,0,sleep,0,0,0\n"
+	if buf.String() != want {
+		t.Errorf("CSV:\n%s\nse esperaba:\n%s", buf.String(), want)
+	}
+
+	// Se vuelve a leer igual con un lector CSV
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][0] != records[0].Profile || rows[2][0] != records[1].Profile {
+		t.Errorf("filas = %q", rows)
+	}
+}
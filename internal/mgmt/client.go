@@ -0,0 +1,485 @@
+package mgmt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// passwordPrompt es lo que OpenVPN envía (sin salto de línea) cuando el
+// management interface está protegido con un archivo de contraseña
+var passwordPrompt = []byte("ENTER PASSWORD:")
+
+// CommandTimeout es cuánto se espera la respuesta de un comando. Si OpenVPN
+// no responde a tiempo la conexión se cierra: una respuesta tardía se
+// confundiría con la del comando siguiente.
+const CommandTimeout = 30 * time.Second
+
+var (
+	// ErrBadPassword se usa cuando OpenVPN rechaza la contraseña del management interface
+	ErrBadPassword = errors.New("management password rejected")
+
+	// ErrClosed se usa cuando la conexión con el management interface ya se cerró
+	ErrClosed = errors.New("management connection closed")
+
+	errTimeout = errors.New("timeout esperando respuesta del management interface")
+)
+
+// Client es un cliente del management interface de OpenVPN.
+// Separa las notificaciones en tiempo real (líneas que empiezan con ">")
+// de las respuestas a comandos, que se entregan en orden a quien los envió.
+type Client struct {
+	conn          net.Conn
+	notifications chan Notification
+	responses     chan string
+	done          chan struct{}
+	timeout       time.Duration
+
+	// pending guarda las notificaciones que aún no se entregaron: readLoop no
+	// puede bloquearse esperando al consumidor, porque este suele enviar
+	// comandos cuyas respuestas lee readLoop
+	pendingMu sync.Mutex
+	pending   []Notification
+	queued    chan struct{}
+
+	// cmdMu serializa los comandos: el protocolo no etiqueta las respuestas,
+	// así que solo puede haber un comando en vuelo a la vez
+	cmdMu sync.Mutex
+
+	// idle está cerrado mientras ningún comando espera respuesta: entonces
+	// readLoop descarta las líneas que no caben en el buffer en vez de bloquearse
+	idleMu    sync.Mutex
+	idle      chan struct{}
+	closeOnce sync.Once
+	readErr   error
+}
+
+// Dial abre una conexión con el management interface
+func Dial(network, address string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient crea un cliente sobre una conexión ya establecida y comienza a leerla
+func NewClient(conn net.Conn) *Client {
+	c := &Client{
+		conn:          conn,
+		notifications: make(chan Notification),
+		responses:     make(chan string, 100),
+		done:          make(chan struct{}),
+		timeout:       CommandTimeout,
+		queued:        make(chan struct{}, 1),
+		idle:          make(chan struct{}),
+	}
+	close(c.idle)
+	go c.readLoop()
+	go c.deliverLoop()
+	return c
+}
+
+// Notifications retorna el canal de notificaciones en tiempo real.
+// Se cierra cuando la conexión termina y se entregaron las pendientes.
+func (c *Client) Notifications() <-chan Notification {
+	return c.notifications
+}
+
+// Done se cierra cuando la conexión con OpenVPN termina
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err retorna el error que terminó la lectura (nil si fue un cierre normal)
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.readErr
+	default:
+		return nil
+	}
+}
+
+// Close cierra la conexión
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Command envía un comando de una sola línea de respuesta (SUCCESS/ERROR)
+func (c *Client) Command(cmd string) (string, error) {
+	c.begin()
+	defer c.end()
+
+	if err := c.write(cmd); err != nil {
+		return "", err
+	}
+
+	line, err := c.commandResponse()
+	if err != nil {
+		return "", err
+	}
+	return parseResult(line)
+}
+
+// MultiLineCommand envía un comando cuya respuesta termina con "END"
+// (por ejemplo "status" o "state")
+func (c *Client) MultiLineCommand(cmd string) ([]string, error) {
+	c.begin()
+	defer c.end()
+
+	if err := c.write(cmd); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := c.commandResponse()
+		if err != nil {
+			return lines, err
+		}
+		if len(lines) == 0 && strings.HasPrefix(line, "ERROR:") {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(strings.TrimPrefix(line, "ERROR:")))
+		}
+		if line == "END" {
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// HistoryCommand envía un comando "<tipo> on all": OpenVPN responde con el
+// historial terminado en "END" y luego confirma las notificaciones en tiempo real
+func (c *Client) HistoryCommand(cmd string) ([]string, error) {
+	c.begin()
+	defer c.end()
+
+	if err := c.write(cmd); err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for {
+		line, err := c.commandResponse()
+		if err != nil {
+			return lines, err
+		}
+		if len(lines) == 0 && strings.HasPrefix(line, "ERROR:") {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(strings.TrimPrefix(line, "ERROR:")))
+		}
+		if line == "END" {
+			break
+		}
+		lines = append(lines, line)
+	}
+
+	line, err := c.commandResponse()
+	if err != nil {
+		return lines, err
+	}
+	_, err = parseResult(line)
+	return lines, err
+}
+
+// Authenticate responde al prompt "ENTER PASSWORD:" de un management interface
+// protegido con contraseña. Debe llamarse justo después de conectar.
+func (c *Client) Authenticate(password string, timeout time.Duration) error {
+	c.begin()
+	defer c.end()
+
+	prompt, err := c.nextResponseTimeout(timeout)
+	if err != nil {
+		return err
+	}
+	if prompt != string(passwordPrompt) {
+		return fmt.Errorf("respuesta inesperada del management interface: %q", prompt)
+	}
+
+	if err := c.write(password); err != nil {
+		return err
+	}
+
+	line, err := c.nextResponseTimeout(timeout)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "SUCCESS:") {
+		return ErrBadPassword
+	}
+	return nil
+}
+
+// Username responde a una petición de usuario (username "Auth" "valor")
+func (c *Client) Username(authType, value string) error {
+	_, err := c.Command(fmt.Sprintf("username %s %s", Quote(authType), Quote(value)))
+	return err
+}
+
+// Password responde a una petición de contraseña (password "Auth" "valor")
+func (c *Client) Password(authType, value string) error {
+	_, err := c.Command(fmt.Sprintf("password %s %s", Quote(authType), Quote(value)))
+	return err
+}
+
+// HoldRelease libera a OpenVPN del estado de espera de --management-hold
+func (c *Client) HoldRelease() error {
+	_, err := c.Command("hold release")
+	return err
+}
+
+// RemoteAccept responde a un >REMOTE: conectando al servidor indicado
+func (c *Client) RemoteAccept() error {
+	_, err := c.Command("remote ACCEPT")
+	return err
+}
+
+// RemoteModify responde a un >REMOTE: conectando a otro host y puerto
+func (c *Client) RemoteModify(host, port string) error {
+	_, err := c.Command(fmt.Sprintf("remote MOD %s %s", host, port))
+	return err
+}
+
+// Signal envía una señal a OpenVPN (SIGHUP, SIGTERM, SIGUSR1, SIGUSR2)
+func (c *Client) Signal(sig string) error {
+	_, err := c.Command("signal " + sig)
+	return err
+}
+
+// StateOn activa las notificaciones >STATE: en tiempo real
+func (c *Client) StateOn() error {
+	_, err := c.Command("state on")
+	return err
+}
+
+// StateOnAll activa las notificaciones >STATE: y retorna el historial de estados
+func (c *Client) StateOnAll() ([]State, error) {
+	lines, err := c.HistoryCommand("state on all")
+	if err != nil {
+		return nil, err
+	}
+	states := make([]State, 0, len(lines))
+	for _, line := range lines {
+		states = append(states, ParseState(line))
+	}
+	return states, nil
+}
+
+// LogOnAll activa las notificaciones >LOG: y retorna las líneas de log
+// recientes que OpenVPN conserva (--management-log-cache)
+func (c *Client) LogOnAll() ([]LogLine, error) {
+	lines, err := c.HistoryCommand("log on all")
+	if err != nil {
+		return nil, err
+	}
+	logs := make([]LogLine, 0, len(lines))
+	for _, line := range lines {
+		logs = append(logs, ParseLog(line))
+	}
+	return logs, nil
+}
+
+// ByteCount activa las notificaciones >BYTECOUNT: cada n segundos (0 las desactiva)
+func (c *Client) ByteCount(n int) error {
+	_, err := c.Command(fmt.Sprintf("bytecount %d", n))
+	return err
+}
+
+// Version anuncia la versión del protocolo que entiende el cliente.
+// Con versión 3 o superior OpenVPN envía >INFO_PRE: para la autenticación web.
+func (c *Client) Version(n int) error {
+	_, err := c.Command(fmt.Sprintf("version %d", n))
+	return err
+}
+
+// begin toma el turno para enviar un comando
+func (c *Client) begin() {
+	c.cmdMu.Lock()
+	c.idleMu.Lock()
+	c.idle = make(chan struct{})
+	c.idleMu.Unlock()
+}
+
+// end libera el turno tomado con begin
+func (c *Client) end() {
+	c.idleMu.Lock()
+	close(c.idle)
+	c.idleMu.Unlock()
+	c.cmdMu.Unlock()
+}
+
+// write escribe una línea de comando. Las respuestas que llegaron antes no
+// pueden ser suyas, así que se descartan.
+func (c *Client) write(cmd string) error {
+	select {
+	case <-c.done:
+		return ErrClosed
+	default:
+	}
+
+	for drained := false; !drained; {
+		select {
+		case <-c.responses:
+		default:
+			drained = true
+		}
+	}
+
+	_, err := c.conn.Write([]byte(cmd + "\n"))
+	return err
+}
+
+// commandResponse espera la respuesta a un comando hasta c.timeout. Si no
+// llega cierra la conexión, que ya no se puede sincronizar.
+func (c *Client) commandResponse() (string, error) {
+	line, err := c.nextResponseTimeout(c.timeout)
+	if errors.Is(err, errTimeout) {
+		c.Close()
+	}
+	return line, err
+}
+
+// nextResponseTimeout espera la siguiente línea de respuesta a un comando
+func (c *Client) nextResponseTimeout(timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case line := <-c.responses:
+		return line, nil
+	case <-c.done:
+		// Puede haber respuestas pendientes en el buffer
+		select {
+		case line := <-c.responses:
+			return line, nil
+		default:
+			return "", ErrClosed
+		}
+	case <-timer.C:
+		return "", errTimeout
+	}
+}
+
+// readLoop lee línea a línea y reparte notificaciones y respuestas
+func (c *Client) readLoop() {
+	defer c.closeOnce.Do(func() {
+		close(c.done)
+	})
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	scanner.Split(scanLines)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			c.enqueue(ParseNotification(line))
+			continue
+		}
+
+		c.respond(line)
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		c.readErr = err
+	}
+}
+
+// respond entrega una línea de respuesta. Si ningún comando la espera y el
+// buffer está lleno se descarta: readLoop no puede dejar de leer notificaciones.
+func (c *Client) respond(line string) {
+	select {
+	case c.responses <- line:
+		return
+	default:
+	}
+
+	c.idleMu.Lock()
+	idle := c.idle
+	c.idleMu.Unlock()
+
+	select {
+	case c.responses <- line:
+	case <-idle:
+	}
+}
+
+// enqueue agrega una notificación a las pendientes sin bloquear
+func (c *Client) enqueue(n Notification) {
+	c.pendingMu.Lock()
+	c.pending = append(c.pending, n)
+	c.pendingMu.Unlock()
+
+	select {
+	case c.queued <- struct{}{}:
+	default:
+	}
+}
+
+// deliverLoop entrega las notificaciones pendientes en orden y cierra el
+// canal cuando la conexión terminó y no queda ninguna
+func (c *Client) deliverLoop() {
+	defer close(c.notifications)
+
+	for {
+		c.pendingMu.Lock()
+		batch := c.pending
+		c.pending = nil
+		c.pendingMu.Unlock()
+
+		for _, n := range batch {
+			c.notifications <- n
+		}
+		if len(batch) > 0 {
+			continue
+		}
+
+		select {
+		case <-c.queued:
+		case <-c.done:
+			c.pendingMu.Lock()
+			empty := len(c.pending) == 0
+			c.pendingMu.Unlock()
+			if empty {
+				return
+			}
+		}
+	}
+}
+
+// scanLines separa la entrada por líneas, tratando el prompt de contraseña
+// (que no termina en salto de línea) como una línea propia
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if bytes.HasPrefix(data, passwordPrompt) {
+		return len(passwordPrompt), passwordPrompt, nil
+	}
+	return bufio.ScanLines(data, atEOF)
+}
+
+// parseResult interpreta una respuesta SUCCESS:/ERROR:
+func parseResult(line string) (string, error) {
+	switch {
+	case strings.HasPrefix(line, "SUCCESS:"):
+		return strings.TrimSpace(strings.TrimPrefix(line, "SUCCESS:")), nil
+	case strings.HasPrefix(line, "ERROR:"):
+		return "", fmt.Errorf("%s", strings.TrimSpace(strings.TrimPrefix(line, "ERROR:")))
+	default:
+		return line, nil
+	}
+}
+
+// Quote entrecomilla un valor para el management interface,
+// escapando barras invertidas y comillas dobles
+func Quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
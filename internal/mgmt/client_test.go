@@ -0,0 +1,258 @@
+package mgmt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer atiende el otro extremo de la conexión: por cada comando que
+// recibe llama a reply con lo que hay que escribir
+func fakeServer(t *testing.T, reply func(cmd string) string) *Client {
+	t.Helper()
+	server, conn := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+
+	go func() {
+		scanner := bufio.NewScanner(server)
+		for scanner.Scan() {
+			if out := reply(scanner.Text()); out != "" {
+				if _, err := server.Write([]byte(out)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	c := NewClient(conn)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestNotificationsDoNotBlockCommands(t *testing.T) {
+	c := fakeServer(t, func(cmd string) string {
+		// Más notificaciones de las que cabían en el buffer antes de la respuesta
+		var out string
+		for i := 0; i < 500; i++ {
+			out += fmt.Sprintf(">BYTECOUNT:%d,%d\r\n", i, i)
+		}
+		return out + "SUCCESS: " + cmd + "\r\n"
+	})
+
+	// Nadie lee las notificaciones mientras se esperan las respuestas
+	for i := 0; i < 3; i++ {
+		if _, err := c.Command("state on"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 1500; i++ {
+		n := <-c.Notifications()
+		if want := fmt.Sprintf("%d,%d", i%500, i%500); n.Type != NotifyByteCount || n.Payload != want {
+			t.Fatalf("notificación %d = %+v", i, n)
+		}
+	}
+}
+
+func TestUnsolicitedResponsesDoNotBlockNotifications(t *testing.T) {
+	server, conn := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+	c := NewClient(conn)
+	t.Cleanup(func() { c.Close() })
+
+	go func() {
+		// Más respuestas de las que caben en el buffer sin un comando que las espere
+		var out string
+		for i := 0; i < 300; i++ {
+			out += fmt.Sprintf("SUCCESS: %d\r\n", i)
+		}
+		server.Write([]byte(out + ">STATE:1,CONNECTED,SUCCESS,10.8.0.2,1.2.3.4\r\n"))
+
+		scanner := bufio.NewScanner(server)
+		for scanner.Scan() {
+			server.Write([]byte("SUCCESS: " + scanner.Text() + "\r\n"))
+		}
+	}()
+
+	select {
+	case n := <-c.Notifications():
+		if n.Type != NotifyState {
+			t.Fatalf("notificación = %+v", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("la notificación no llegó")
+	}
+
+	// Las respuestas sobrantes no se confunden con la del próximo comando
+	line, err := c.Command("state on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "state on" {
+		t.Errorf("respuesta = %q", line)
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	c := fakeServer(t, func(string) string { return "" })
+	c.timeout = 50 * time.Millisecond
+
+	if _, err := c.Command("hold release"); err == nil {
+		t.Fatal("se esperaba un error sin respuesta")
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("la conexión no se cerró tras el timeout")
+	}
+	if _, ok := <-c.Notifications(); ok {
+		t.Error("el canal de notificaciones sigue abierto")
+	}
+}
+
+func TestScanLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"LF", "SUCCESS: a\n>HOLD:Waiting\n", []string{"SUCCESS: a", ">HOLD:Waiting"}},
+		{"CRLF", "SUCCESS: a\r\n>HOLD:Waiting\r\n", []string{"SUCCESS: a", ">HOLD:Waiting"}},
+		{"última línea sin salto", "SUCCESS: a\r\nEND", []string{"SUCCESS: a", "END"}},
+		{"prompt sin salto de línea", "ENTER PASSWORD:SUCCESS: password is correct\r\n", []string{"ENTER PASSWORD:", "SUCCESS: password is correct"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := bufio.NewScanner(strings.NewReader(tt.input))
+			scanner.Split(scanLines)
+			var got []string
+			for scanner.Scan() {
+				got = append(got, strings.TrimRight(scanner.Text(), "\r"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    string
+		wantErr string
+	}{
+		{"SUCCESS: hold release succeeded", "hold release succeeded", ""},
+		{"SUCCESS:", "", ""},
+		{"ERROR: unknown command, enter 'help' for more options", "", "unknown command, enter 'help' for more options"},
+		{"pid=1234", "pid=1234", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := parseResult(tt.line)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%q: error = %v, se esperaba %q", tt.line, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q = %q, %v; se esperaba %q", tt.line, got, err, tt.want)
+		}
+	}
+}
+
+func TestCommandFraming(t *testing.T) {
+	c := fakeServer(t, func(cmd string) string {
+		switch cmd {
+		case "status":
+			// Las notificaciones intercaladas no son parte de la respuesta
+			return "OpenVPN CLIENT LIST\r\n>BYTECOUNT:1,2\r\nUpdated,2024\r\nEND\r\n"
+		case "state on all":
+			return "1175716944,CONNECTING,,,,,,,\r\n1175716945,CONNECTED,SUCCESS,10.8.0.6,203.0.113.1,1194,,,\r\nEND\r\nSUCCESS: real-time state notification set to ON\r\n"
+		case "state":
+			return "ERROR: state command failed\r\n"
+		case "bogus":
+			return "ERROR: unknown command\r\n"
+		}
+		return "SUCCESS: " + cmd + "\r\n"
+	})
+
+	lines, err := c.MultiLineCommand("status")
+	if err != nil || !reflect.DeepEqual(lines, []string{"OpenVPN CLIENT LIST", "Updated,2024"}) {
+		t.Errorf("status = %q, %v", lines, err)
+	}
+	if n := <-c.Notifications(); n.Type != NotifyByteCount || n.Payload != "1,2" {
+		t.Errorf("notificación = %+v", n)
+	}
+
+	states, err := c.StateOnAll()
+	if err != nil || len(states) != 2 || states[1].Name != "CONNECTED" {
+		t.Errorf("state on all = %+v, %v", states, err)
+	}
+
+	if _, err := c.MultiLineCommand("state"); err == nil || err.Error() != "state command failed" {
+		t.Errorf("state: error = %v", err)
+	}
+	if _, err := c.Command("bogus"); err == nil {
+		t.Error("bogus: se esperaba un error")
+	}
+
+	// Los comandos siguientes siguen sincronizados con sus respuestas
+	if got, err := c.Command("hold release"); err != nil || got != "hold release" {
+		t.Errorf("hold release = %q, %v", got, err)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		wantErr error
+	}{
+		{"correcta", "SUCCESS: password is correct\r\n", nil},
+		{"rechazada", "ERROR: bad password\r\n", ErrBadPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := net.Pipe()
+			defer server.Close()
+			go func() {
+				server.Write(passwordPrompt)
+				bufio.NewReader(server).ReadString('\n')
+				server.Write([]byte(tt.reply))
+			}()
+
+			c := NewClient(conn)
+			defer c.Close()
+			if err := c.Authenticate("secret", time.Second); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Authenticate() = %v, se esperaba %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Auth", `"Auth"`},
+		{"", `""`},
+		{`pa"ss`, `"pa\"ss"`},
+		{`C:\vpn`, `"C:\\vpn"`},
+		{`\"`, `"\\\""`},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.value); got != tt.want {
+			t.Errorf("Quote(%q) = %s, se esperaba %s", tt.value, got, tt.want)
+		}
+	}
+}
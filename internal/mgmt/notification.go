@@ -0,0 +1,283 @@
+package mgmt
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+// NotificationType identifica el tipo de una notificación en tiempo real
+type NotificationType string
+
+const (
+	NotifyPassword  NotificationType = "PASSWORD"
+	NotifyState     NotificationType = "STATE"
+	NotifyHold      NotificationType = "HOLD"
+	NotifyFatal     NotificationType = "FATAL"
+	NotifyInfo      NotificationType = "INFO"
+	NotifyInfoPre   NotificationType = "INFO_PRE"
+	NotifyLog       NotificationType = "LOG"
+	NotifyByteCount NotificationType = "BYTECOUNT"
+	NotifyRemote    NotificationType = "REMOTE"
+)
+
+// Notification representa una línea ">TIPO:payload" del management interface
+type Notification struct {
+	Type    NotificationType
+	Payload string
+}
+
+// ParseNotification separa una línea ">TIPO:payload" en sus partes
+func ParseNotification(line string) Notification {
+	line = strings.TrimPrefix(line, ">")
+	typ, payload, _ := strings.Cut(line, ":")
+	return Notification{
+		Type:    NotificationType(typ),
+		Payload: payload,
+	}
+}
+
+// PasswordRequest es el contenido interpretado de una notificación >PASSWORD:
+type PasswordRequest struct {
+	// AuthType es el tipo de credencial pedida ("Auth", "Private Key", ...)
+	AuthType string
+	// NeedUsername indica que OpenVPN pide usuario y contraseña juntos
+	NeedUsername bool
+	// VerificationFailed indica que las credenciales enviadas fueron rechazadas
+	VerificationFailed bool
+	// Reason es el texto adicional que acompaña a un "Verification Failed"
+	Reason string
+
+	// StaticChallenge indica que el perfil declara static-challenge (SC:)
+	StaticChallenge bool
+	// ChallengeEcho indica si la respuesta al challenge puede mostrarse en claro
+	ChallengeEcho bool
+	// ChallengeText es el texto que el servidor quiere mostrar al usuario
+	ChallengeText string
+}
+
+// ParsePassword interpreta el payload de una notificación >PASSWORD:
+//
+//	Need 'Auth' username/password
+//	Need 'Auth' username/password SC:1,Your OTP
+//	Need 'Auth' password
+//	Verification Failed: 'Auth'
+func ParsePassword(payload string) PasswordRequest {
+	var req PasswordRequest
+
+	if rest, ok := strings.CutPrefix(payload, "Verification Failed:"); ok {
+		req.VerificationFailed = true
+		req.AuthType, rest = cutQuoted(strings.TrimSpace(rest))
+		req.Reason = strings.TrimSpace(rest)
+		return req
+	}
+
+	rest, ok := strings.CutPrefix(payload, "Need ")
+	if !ok {
+		return req
+	}
+
+	req.AuthType, rest = cutQuoted(rest)
+	rest = strings.TrimSpace(rest)
+
+	what, sc, hasSC := strings.Cut(rest, " SC:")
+	req.NeedUsername = strings.HasPrefix(what, "username")
+
+	if hasSC {
+		req.StaticChallenge = true
+		flags, text, _ := strings.Cut(sc, ",")
+		if n, err := strconv.Atoi(flags); err == nil {
+			req.ChallengeEcho = n&1 != 0
+		}
+		req.ChallengeText = text
+	}
+
+	return req
+}
+
+// DynamicChallenge es un challenge dinámico CRV1 enviado por el servidor en
+// un AUTH_FAILED (LinOTP, privacyIDEA, ...):
+//
+//	CRV1:<flags>:<state_id>:<username en base64>:<texto del challenge>
+type DynamicChallenge struct {
+	StateID  string
+	Username string
+	Text     string
+	// Echo indica si la respuesta puede mostrarse en claro (flag E)
+	Echo bool
+	// ResponseRequired indica que el usuario debe responder (flag R)
+	ResponseRequired bool
+}
+
+// ParseDynamicChallenge busca un challenge CRV1 en el texto de un
+// "Verification Failed" (p.ej. "['CRV1:R,E:Om01u7:Y3Ix:Enter PIN']")
+func ParseDynamicChallenge(reason string) (DynamicChallenge, bool) {
+	var ch DynamicChallenge
+
+	idx := strings.Index(reason, "CRV1:")
+	if idx < 0 {
+		return ch, false
+	}
+	raw := strings.TrimSuffix(strings.TrimSuffix(reason[idx:], "]"), "'")
+
+	parts := strings.SplitN(strings.TrimPrefix(raw, "CRV1:"), ":", 4)
+	if len(parts) != 4 {
+		return ch, false
+	}
+
+	for _, flag := range strings.Split(parts[0], ",") {
+		switch flag {
+		case "E":
+			ch.Echo = true
+		case "R":
+			ch.ResponseRequired = true
+		}
+	}
+
+	username, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ch, false
+	}
+
+	ch.StateID = parts[1]
+	ch.Username = string(username)
+	ch.Text = parts[3]
+	return ch, true
+}
+
+// StaticChallengeResponse construye la contraseña SCRV1 para static-challenge
+func StaticChallengeResponse(password, response string) string {
+	return "SCRV1:" +
+		base64.StdEncoding.EncodeToString([]byte(password)) + ":" +
+		base64.StdEncoding.EncodeToString([]byte(response))
+}
+
+// DynamicChallengeResponse construye la contraseña que responde a un challenge CRV1
+func DynamicChallengeResponse(stateID, response string) string {
+	return "CRV1::" + stateID + "::" + response
+}
+
+// ParseWebAuth extrae la URL de una notificación de autenticación web
+// (OpenVPN 2.5+), enviada como >INFO: o >INFO_PRE::
+//
+//	WEB_AUTH:<flags>:https://sso.example.com/...
+//	OPEN_URL:https://sso.example.com/...
+func ParseWebAuth(payload string) (string, bool) {
+	if rest, ok := strings.CutPrefix(payload, "WEB_AUTH:"); ok {
+		_, url, found := strings.Cut(rest, ":")
+		if !found || url == "" {
+			return "", false
+		}
+		return url, true
+	}
+	if url, ok := strings.CutPrefix(payload, "OPEN_URL:"); ok && url != "" {
+		return url, true
+	}
+	return "", false
+}
+
+// ParseByteCount interpreta el payload de una notificación >BYTECOUNT:
+//
+//	<bytes recibidos>,<bytes enviados>
+func ParseByteCount(payload string) (in, out uint64, ok bool) {
+	inStr, outStr, found := strings.Cut(payload, ",")
+	if !found {
+		return 0, 0, false
+	}
+	in, errIn := strconv.ParseUint(strings.TrimSpace(inStr), 10, 64)
+	out, errOut := strconv.ParseUint(strings.TrimSpace(outStr), 10, 64)
+	if errIn != nil || errOut != nil {
+		return 0, 0, false
+	}
+	return in, out, true
+}
+
+// Remote es el servidor al que OpenVPN va a conectar, enviado como >REMOTE:
+// con --management-query-remote
+type Remote struct {
+	Host     string
+	Port     string
+	Protocol string
+}
+
+// ParseRemote interpreta el payload de una notificación >REMOTE:
+//
+//	vpn.example.com,1194,udp
+func ParseRemote(payload string) (Remote, bool) {
+	fields := strings.Split(payload, ",")
+	if len(fields) < 3 || fields[0] == "" {
+		return Remote{}, false
+	}
+	return Remote{Host: fields[0], Port: fields[1], Protocol: fields[2]}, true
+}
+
+// State es el contenido interpretado de una notificación >STATE:
+type State struct {
+	Time        int64
+	Name        string
+	Description string
+	LocalIP     string
+	RemoteIP    string
+	RemotePort  string
+	LocalAddr   string
+	LocalPort   string
+	LocalIPv6   string
+}
+
+// ParseState interpreta el payload de una notificación >STATE:
+//
+//	1175716944,CONNECTED,SUCCESS,10.8.0.6,203.0.113.1,1194,,,fd00::2
+func ParseState(payload string) State {
+	fields := strings.Split(payload, ",")
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	ts, _ := strconv.ParseInt(field(0), 10, 64)
+	return State{
+		Time:        ts,
+		Name:        field(1),
+		Description: field(2),
+		LocalIP:     field(3),
+		RemoteIP:    field(4),
+		RemotePort:  field(5),
+		LocalAddr:   field(6),
+		LocalPort:   field(7),
+		LocalIPv6:   field(8),
+	}
+}
+
+// LogLine es una línea de log recibida por el management interface
+type LogLine struct {
+	Time    int64
+	Flags   string // I (info), N (no fatal), W (warning), D (debug), F (fatal)
+	Message string
+}
+
+// ParseLog interpreta el payload de una notificación >LOG:
+//
+//	1175716944,I,Initialization Sequence Completed
+func ParseLog(payload string) LogLine {
+	fields := strings.SplitN(payload, ",", 3)
+	if len(fields) < 3 {
+		return LogLine{Message: payload}
+	}
+	ts, _ := strconv.ParseInt(fields[0], 10, 64)
+	return LogLine{Time: ts, Flags: fields[1], Message: fields[2]}
+}
+
+// cutQuoted extrae el primer valor entre comillas simples ('Auth')
+// y retorna el resto del texto
+func cutQuoted(s string) (string, string) {
+	if !strings.HasPrefix(s, "'") {
+		return "", s
+	}
+	value, rest, ok := strings.Cut(s[1:], "'")
+	if !ok {
+		return s[1:], ""
+	}
+	return value, rest
+}
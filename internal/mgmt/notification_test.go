@@ -0,0 +1,175 @@
+package mgmt
+
+import "testing"
+
+func TestParseNotification(t *testing.T) {
+	tests := []struct {
+		line string
+		want Notification
+	}{
+		{">HOLD:Waiting for hold release:0", Notification{NotifyHold, "Waiting for hold release:0"}},
+		{">PASSWORD:Need 'Auth' username/password", Notification{NotifyPassword, "Need 'Auth' username/password"}},
+		{">INFO_PRE:WEB_AUTH::https://sso.example.com", Notification{NotifyInfoPre, "WEB_AUTH::https://sso.example.com"}},
+		{">FATAL", Notification{NotifyFatal, ""}},
+	}
+	for _, tt := range tests {
+		if got := ParseNotification(tt.line); got != tt.want {
+			t.Errorf("%q = %+v, se esperaba %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParsePassword(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    PasswordRequest
+	}{
+		{
+			payload: "Need 'Auth' username/password",
+			want:    PasswordRequest{AuthType: "Auth", NeedUsername: true},
+		},
+		{
+			payload: "Need 'Auth' password",
+			want:    PasswordRequest{AuthType: "Auth"},
+		},
+		{
+			payload: "Need 'Private Key' password",
+			want:    PasswordRequest{AuthType: "Private Key"},
+		},
+		{
+			payload: "Need 'Auth' username/password SC:1,Your OTP, please",
+			want: PasswordRequest{AuthType: "Auth", NeedUsername: true,
+				StaticChallenge: true, ChallengeEcho: true, ChallengeText: "Your OTP, please"},
+		},
+		{
+			payload: "Need 'Auth' username/password SC:0,PIN",
+			want: PasswordRequest{AuthType: "Auth", NeedUsername: true,
+				StaticChallenge: true, ChallengeText: "PIN"},
+		},
+		{
+			payload: "Verification Failed: 'Auth'",
+			want:    PasswordRequest{AuthType: "Auth", VerificationFailed: true},
+		},
+		{
+			payload: "Verification Failed: 'Auth' ['CRV1:R,E:Om01u7:Y3Ix:Enter PIN']",
+			want: PasswordRequest{AuthType: "Auth", VerificationFailed: true,
+				Reason: "['CRV1:R,E:Om01u7:Y3Ix:Enter PIN']"},
+		},
+		{
+			payload: "Auth-Token:abc",
+			want:    PasswordRequest{},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := ParsePassword(tt.payload); got != tt.want {
+			t.Errorf("%q:\n got: %+v\nwant: %+v", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestParseState(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    State
+	}{
+		{
+			payload: "1175716944,CONNECTED,SUCCESS,10.8.0.6,203.0.113.1,1194,,,fd00::2",
+			want: State{Time: 1175716944, Name: "CONNECTED", Description: "SUCCESS",
+				LocalIP: "10.8.0.6", RemoteIP: "203.0.113.1", RemotePort: "1194", LocalIPv6: "fd00::2"},
+		},
+		{
+			// Versiones antiguas envían menos campos
+			payload: "1175716944,RECONNECTING,ping-restart",
+			want:    State{Time: 1175716944, Name: "RECONNECTING", Description: "ping-restart"},
+		},
+		{
+			payload: "x,WAIT",
+			want:    State{Name: "WAIT"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := ParseState(tt.payload); got != tt.want {
+			t.Errorf("%q:\n got: %+v\nwant: %+v", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    Remote
+		ok      bool
+	}{
+		{"vpn.example.com,1194,udp", Remote{Host: "vpn.example.com", Port: "1194", Protocol: "udp"}, true},
+		{"2001:db8::1,443,tcp-client", Remote{Host: "2001:db8::1", Port: "443", Protocol: "tcp-client"}, true},
+		{"vpn.example.com", Remote{}, false},
+		{",1194,udp", Remote{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseRemote(tt.payload)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: got %+v, %v; want %+v, %v", tt.payload, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseDynamicChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		want   DynamicChallenge
+		ok     bool
+	}{
+		{
+			name:   "respuesta requerida con eco",
+			reason: "['CRV1:R,E:Om01u7:Y3Ix:Enter PIN']",
+			want:   DynamicChallenge{StateID: "Om01u7", Username: "cr1", Text: "Enter PIN", Echo: true, ResponseRequired: true},
+			ok:     true,
+		},
+		{
+			name:   "sin eco",
+			reason: "CRV1:R:c3RhdGU=:YWxpY2VAY29ycA==:Código enviado por SMS",
+			want:   DynamicChallenge{StateID: "c3RhdGU=", Username: "alice@corp", Text: "Código enviado por SMS", ResponseRequired: true},
+			ok:     true,
+		},
+		{
+			name:   "sin flags",
+			reason: "CRV1::abc:YWxpY2U=:Aprueba el inicio de sesión",
+			want:   DynamicChallenge{StateID: "abc", Username: "alice", Text: "Aprueba el inicio de sesión"},
+			ok:     true,
+		},
+		{
+			name:   "el texto puede tener dos puntos",
+			reason: "CRV1:E:id:YWxpY2U=:Token: 6 dígitos",
+			want:   DynamicChallenge{StateID: "id", Username: "alice", Text: "Token: 6 dígitos", Echo: true},
+			ok:     true,
+		},
+		{name: "sin CRV1", reason: "['Invalid credentials']"},
+		{name: "faltan campos", reason: "CRV1:R,E:Om01u7"},
+		{name: "usuario no es base64", reason: "CRV1:R:Om01u7:alice!:Enter PIN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseDynamicChallenge(tt.reason)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, se esperaba %v", ok, tt.ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("\n got: %+v\nwant: %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChallengeResponses(t *testing.T) {
+	if got := DynamicChallengeResponse("Om01u7", "123456"); got != "CRV1::Om01u7::123456" {
+		t.Errorf("DynamicChallengeResponse = %q", got)
+	}
+	if got := StaticChallengeResponse("secret", "123456"); got != "SCRV1:c2VjcmV0:MTIzNDU2" {
+		t.Errorf("StaticChallengeResponse = %q", got)
+	}
+}
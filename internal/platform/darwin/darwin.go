@@ -1,10 +1,13 @@
 package darwin
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -19,7 +22,15 @@ type Process struct {
 // StartConfig contiene la configuración de inicio
 type StartConfig struct {
 	ConfigPath  string
+	OpenVPNPath string // Opcional: si está vacío se busca con FindOpenVPN
 	MgmtPort    int
+	// MgmtSocket es la ruta del socket Unix del management interface.
+	// Si está vacía se usa 127.0.0.1:MgmtPort.
+	MgmtSocket string
+	// MgmtPasswordFile es el archivo con la contraseña del management interface
+	MgmtPasswordFile string
+	// ExtraArgs son opciones de OpenVPN que se agregan al final (reglas de rutas)
+	ExtraArgs   []string
 	LogCallback func(string)
 }
 
@@ -35,8 +46,8 @@ func NewDarwin() *DarwinPlatform {
 func (p *DarwinPlatform) FindOpenVPN() (string, error) {
 	// Rutas comunes para OpenVPN en macOS
 	paths := []string{
-		"/usr/local/opt/openvpn/sbin/openvpn",      // Homebrew (Intel)
-		"/opt/homebrew/opt/openvpn/sbin/openvpn",   // Homebrew (Apple Silicon)
+		"/usr/local/opt/openvpn/sbin/openvpn",    // Homebrew (Intel)
+		"/opt/homebrew/opt/openvpn/sbin/openvpn", // Homebrew (Apple Silicon)
 		"/usr/local/sbin/openvpn",
 		"/usr/local/bin/openvpn",
 		"/Applications/Tunnelblick.app/Contents/Resources/openvpn", // Tunnelblick
@@ -66,21 +77,29 @@ func (p *DarwinPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	// - O usar AuthorizationExecuteWithPrivileges (deprecated pero funciona)
 	// - Mejor: usar un helper tool con SMJobBless
 
-	openvpnPath, err := p.FindOpenVPN()
-	if err != nil {
-		return nil, err
+	openvpnPath := config.OpenVPNPath
+	if openvpnPath == "" {
+		path, err := p.FindOpenVPN()
+		if err != nil {
+			return nil, err
+		}
+		openvpnPath = path
 	}
 
 	// Construir argumentos base de OpenVPN
 	args := []string{
 		"--config", config.ConfigPath,
-		"--management", "127.0.0.1", fmt.Sprintf("%d", config.MgmtPort),
+	}
+	args = append(args, managementArgs(config)...)
+	args = append(args,
 		"--management-query-passwords",
 		"--management-hold",
+		"--setenv", "IV_SSO", "webauth,openurl", // Anunciar soporte de login web (SSO)
 		"--auth-retry", "interact",
 		"--auth-nocache",
 		"--verb", "4",
-	}
+	)
+	args = append(args, config.ExtraArgs...)
 
 	// TODO: Implementar elevación en macOS
 	_ = openvpnPath
@@ -89,38 +108,64 @@ func (p *DarwinPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	return nil, fmt.Errorf("macOS support not yet implemented")
 }
 
-// StopOpenVPN detiene el proceso de OpenVPN limpiamente
-func (p *DarwinPlatform) StopOpenVPN(proc *Process) error {
-	if !proc.Running || proc.Cmd.Process == nil {
-		return nil
+// managementArgs construye las opciones --management según el endpoint pedido
+func managementArgs(config StartConfig) []string {
+	var args []string
+	if config.MgmtSocket != "" {
+		args = []string{"--management", config.MgmtSocket, "unix"}
+	} else {
+		args = []string{"--management", "127.0.0.1", fmt.Sprintf("%d", config.MgmtPort)}
+	}
+	if config.MgmtPasswordFile != "" {
+		args = append(args, config.MgmtPasswordFile)
 	}
+	return args
+}
 
-	// Intentar SIGTERM primero (cierre limpio)
-	if err := proc.Cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		// Si ya está muerto, ok
-		proc.Running = false
+// TerminateOpenVPN envía SIGTERM (o SIGKILL si force) al grupo de procesos de
+// OpenVPN, sin esperar a que termine
+func (p *DarwinPlatform) TerminateOpenVPN(proc *Process, force bool) error {
+	if proc.Cmd == nil || proc.Cmd.Process == nil {
 		return nil
 	}
 
-	// Esperar hasta 5 segundos a que termine
-	done := make(chan error, 1)
-	go func() {
-		done <- proc.Cmd.Wait()
-	}()
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
 
-	select {
-	case <-done:
-		// Terminó limpiamente
-		proc.Running = false
+	if err := syscall.Kill(-proc.Cmd.Process.Pid, sig); err == nil {
 		return nil
-	case <-time.After(5 * time.Second):
-		// Timeout, forzar con SIGKILL
-		if err := proc.Cmd.Process.Kill(); err != nil {
-			return fmt.Errorf("error al forzar cierre: %w", err)
+	}
+	if err := proc.Cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("error al enviar %v a OpenVPN: %w", sig, err)
+	}
+	return nil
+}
+
+// FindOpenVPNProcesses busca procesos openvpn cuya línea de comandos incluya match
+func (p *DarwinPlatform) FindOpenVPNProcesses(match string) ([]int, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,command=").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || filepath.Base(fields[1]) != "openvpn" {
+			continue
+		}
+		for _, arg := range fields[2:] {
+			if arg == match {
+				if pid, err := strconv.Atoi(fields[0]); err == nil {
+					pids = append(pids, pid)
+				}
+				break
+			}
 		}
-		proc.Running = false
-		return nil
 	}
+	return pids, nil
 }
 
 // RequiresElevation indica si la plataforma requiere elevación de privilegios
@@ -162,6 +207,114 @@ func (p *DarwinPlatform) GetLogPath() string {
 	return filepath.Join(homeDir, "Library", "Logs", "PreyVPN")
 }
 
+// EnsureRuntimeDir crea el directorio privado de ejecución del usuario
+func (p *DarwinPlatform) EnsureRuntimeDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("navtunnel-%d", os.Getuid()))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, os.Chmod(dir, 0o700)
+}
+
+// OpenURL abre una URL en el navegador predeterminado
+func (p *DarwinPlatform) OpenURL(url string) error {
+	return exec.Command("open", url).Start()
+}
+
+// InterfaceCounters lee los contadores de bytes de una interfaz
+func (p *DarwinPlatform) InterfaceCounters(name string) (uint64, uint64, error) {
+	// TODO: Implementar con sysctl (NET_RT_IFLIST2) o netstat -ib
+	return 0, 0, fmt.Errorf("macOS interface counters not yet implemented")
+}
+
+// DNSConfig describe los DNS que se aplican a la interfaz del túnel
+type DNSConfig struct {
+	Device      string
+	Servers     []string
+	Domains     []string
+	RoutingOnly bool
+}
+
+// ConfigureDNS aplica los DNS del túnel a su interfaz
+func (p *DarwinPlatform) ConfigureDNS(config DNSConfig) error {
+	// TODO: Implementar con scutil (State:/Network/Service/<id>/DNS) y
+	// /etc/resolver/<dominio> para split DNS
+	return fmt.Errorf("macOS DNS configuration not yet implemented")
+}
+
+// RevertDNS deshace la configuración DNS de la interfaz
+func (p *DarwinPlatform) RevertDNS(device string) error {
+	return nil
+}
+
+// Route es una entrada de la tabla de rutas del sistema
+type Route struct {
+	Destination string // Prefijo en notación CIDR ("0.0.0.0/0", "fd00::/64")
+	Gateway     string // Vacío si la ruta es directa por la interfaz
+	Device      string
+	Metric      int
+}
+
+// KillSwitchConfig describe el tráfico que se permite con el kill switch activo
+type KillSwitchConfig struct {
+	Device   string // Interfaz del túnel; admite comodín final ("tun*")
+	Remotes  []KillSwitchRemote
+	AllowLAN bool // Permitir redes privadas y de enlace local
+}
+
+// KillSwitchRemote es un servidor VPN al que se permite conectar
+type KillSwitchRemote struct {
+	IP       string
+	Port     int
+	Protocol string // "udp" o "tcp"
+}
+
+// EnableKillSwitch bloquea el tráfico fuera del túnel
+func (p *DarwinPlatform) EnableKillSwitch(config KillSwitchConfig) error {
+	// TODO: Implementar con pf (anchor propio con pfctl)
+	return fmt.Errorf("macOS kill switch not yet implemented")
+}
+
+// DisableKillSwitch quita el bloqueo del kill switch
+func (p *DarwinPlatform) DisableKillSwitch() error {
+	return nil
+}
+
+// KillSwitchActive indica si el kill switch quedó activo
+func (p *DarwinPlatform) KillSwitchActive() (bool, error) {
+	return false, nil
+}
+
+// WatchNetwork se suscribe a los cambios de red del sistema
+func (p *DarwinPlatform) WatchNetwork() error {
+	// TODO: Implementar con un socket PF_ROUTE o SCNetworkReachability
+	return fmt.Errorf("macOS network monitor not yet implemented")
+}
+
+// WatchSession se suscribe a la suspensión y al bloqueo de la sesión
+func (p *DarwinPlatform) WatchSession() error {
+	// TODO: Implementar con IORegisterForSystemPower y com.apple.screenIsLocked
+	return fmt.Errorf("macOS session monitor not yet implemented")
+}
+
+// Ping envía un eco ICMP y retorna el tiempo de respuesta
+func (p *DarwinPlatform) Ping(host string, timeout time.Duration) (time.Duration, error) {
+	// TODO: Implementar con ping -c 1 -t (el timeout de macOS es en segundos con -t)
+	return 0, fmt.Errorf("macOS ping not yet implemented")
+}
+
+// RoutingTable retorna la tabla de rutas del sistema
+func (p *DarwinPlatform) RoutingTable() ([]Route, error) {
+	// TODO: Implementar con sysctl (NET_RT_DUMP) o netstat -rn
+	return nil, fmt.Errorf("macOS routing table not yet implemented")
+}
+
+// TemporaryAddresses retorna las direcciones IPv6 temporales del sistema
+func (p *DarwinPlatform) TemporaryAddresses() ([]string, error) {
+	// TODO: Implementar con getifaddrs y SIOCGIFAFLAG_IN6 (IN6_IFF_TEMPORARY)
+	return nil, nil
+}
+
 // Name retorna el nombre de la plataforma
 func (p *DarwinPlatform) Name() string {
 	return "darwin"
@@ -0,0 +1,187 @@
+package linux
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DNSConfig describe los DNS que se aplican a la interfaz del túnel
+type DNSConfig struct {
+	Device  string
+	Servers []string
+	Domains []string
+	// RoutingOnly hace que los dominios solo enruten sus consultas al túnel
+	// (split DNS). Si es false también son dominios de búsqueda y el túnel
+	// recibe todas las consultas.
+	RoutingOnly bool
+}
+
+const (
+	resolvedName = "org.freedesktop.resolve1"
+	resolvedPath = "/org/freedesktop/resolve1"
+	resolvedIf   = "org.freedesktop.resolve1.Manager"
+)
+
+// resolvedAddress es un servidor DNS en el formato de SetLinkDNS (a(iay))
+type resolvedAddress struct {
+	Family  int32
+	Address []byte
+}
+
+// resolvedDomain es un dominio en el formato de SetLinkDomains (a(sb))
+type resolvedDomain struct {
+	Domain      string
+	RoutingOnly bool
+}
+
+// ConfigureDNS aplica los DNS del túnel a su interfaz en systemd-resolved.
+// Usa la API D-Bus de resolved, que la regla de polkit del paquete autoriza
+// sin contraseña en interfaces tun/tap (systemd 255 o posterior). Si polkit
+// no la autoriza, por ejemplo con un systemd anterior, recurre a resolvectl
+// con privilegios elevados.
+func (p *LinuxPlatform) ConfigureDNS(config DNSConfig) error {
+	link, err := net.InterfaceByName(config.Device)
+	if err != nil {
+		return fmt.Errorf("interfaz %s no encontrada: %w", config.Device, err)
+	}
+
+	addresses, err := resolvedAddresses(config.Servers)
+	if err != nil {
+		return err
+	}
+	domains, defaultRoute := resolvedDomains(config)
+
+	busErr := callResolved(
+		resolvedCall{"SetLinkDNS", []interface{}{int32(link.Index), addresses}},
+		resolvedCall{"SetLinkDomains", []interface{}{int32(link.Index), domains}},
+		resolvedCall{"SetLinkDefaultRoute", []interface{}{int32(link.Index), defaultRoute}},
+	)
+	if busErr == nil {
+		return nil
+	}
+
+	for _, args := range resolvectlArgs(config) {
+		if err := p.resolvectl(args...); err != nil {
+			return fmt.Errorf("systemd-resolved: %v; resolvectl: %w", busErr, err)
+		}
+	}
+	return nil
+}
+
+// RevertDNS deshace la configuración DNS de la interfaz del túnel.
+// Si la interfaz ya no existe no hay nada que deshacer: resolved la olvida.
+func (p *LinuxPlatform) RevertDNS(device string) error {
+	link, err := net.InterfaceByName(device)
+	if err != nil {
+		return nil
+	}
+
+	busErr := callResolved(resolvedCall{"RevertLink", []interface{}{int32(link.Index)}})
+	if busErr == nil {
+		return nil
+	}
+	if err := p.resolvectl("revert", device); err != nil {
+		return fmt.Errorf("systemd-resolved: %v; resolvectl: %w", busErr, err)
+	}
+	return nil
+}
+
+// resolvedCall es una llamada a org.freedesktop.resolve1.Manager
+type resolvedCall struct {
+	method string
+	args   []interface{}
+}
+
+// callResolved ejecuta las llamadas en orden por el bus del sistema. No
+// permite que polkit pregunte: si la regla del paquete no autoriza el cambio
+// se usa resolvectl, que no pide contraseña con el sudoers del paquete.
+func callResolved(calls ...resolvedCall) error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+
+	obj := conn.Object(resolvedName, resolvedPath)
+	for _, c := range calls {
+		call := obj.Call(resolvedIf+"."+c.method, 0, c.args...)
+		if call.Err != nil {
+			return fmt.Errorf("%s: %w", c.method, call.Err)
+		}
+	}
+	return nil
+}
+
+// resolvectl ejecuta resolvectl con privilegios elevados (sudo -n o pkexec)
+func (p *LinuxPlatform) resolvectl(args ...string) error {
+	path, err := exec.LookPath("resolvectl")
+	if err != nil {
+		return fmt.Errorf("resolvectl no está disponible: %w", err)
+	}
+
+	name, elevated, err := p.ElevateCommand(path, args)
+	if err != nil {
+		return err
+	}
+	out, err := exec.Command(name, elevated...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("resolvectl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// resolvedAddresses convierte las IPs de los servidores al formato de resolved
+func resolvedAddresses(servers []string) ([]resolvedAddress, error) {
+	addresses := make([]resolvedAddress, 0, len(servers))
+	for _, server := range servers {
+		ip := net.ParseIP(server)
+		if ip == nil {
+			return nil, fmt.Errorf("servidor DNS inválido: %q", server)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			addresses = append(addresses, resolvedAddress{Family: 2, Address: ip4}) // AF_INET
+		} else {
+			addresses = append(addresses, resolvedAddress{Family: 10, Address: ip.To16()}) // AF_INET6
+		}
+	}
+	return addresses, nil
+}
+
+// resolvedDomains arma los dominios del túnel y decide si es ruta por defecto
+// para las consultas. En modo global el dominio de enrutamiento "." envía
+// todas las consultas al túnel; en split DNS sin dominios el túnel también
+// recibe las consultas generales, si no sus DNS no se usarían nunca.
+func resolvedDomains(config DNSConfig) ([]resolvedDomain, bool) {
+	domains := make([]resolvedDomain, 0, len(config.Domains)+1)
+	for _, domain := range config.Domains {
+		domains = append(domains, resolvedDomain{Domain: domain, RoutingOnly: config.RoutingOnly})
+	}
+
+	if !config.RoutingOnly {
+		return append(domains, resolvedDomain{Domain: ".", RoutingOnly: true}), true
+	}
+	return domains, len(config.Domains) == 0
+}
+
+// resolvectlArgs traduce la configuración a comandos de resolvectl
+func resolvectlArgs(config DNSConfig) [][]string {
+	domains, defaultRoute := resolvedDomains(config)
+
+	domainArgs := []string{"domain", config.Device}
+	for _, d := range domains {
+		name := d.Domain
+		if d.RoutingOnly {
+			name = "~" + name
+		}
+		domainArgs = append(domainArgs, name)
+	}
+
+	return [][]string{
+		append([]string{"dns", config.Device}, config.Servers...),
+		domainArgs,
+		{"default-route", config.Device, fmt.Sprint(defaultRoute)},
+	}
+}
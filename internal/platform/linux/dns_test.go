@@ -0,0 +1,114 @@
+package linux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolvedDomains(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       DNSConfig
+		want         []resolvedDomain
+		defaultRoute bool
+	}{
+		{
+			name:   "split DNS",
+			config: DNSConfig{Domains: []string{"corp.example.com", "lab.example.com"}, RoutingOnly: true},
+			want: []resolvedDomain{
+				{Domain: "corp.example.com", RoutingOnly: true},
+				{Domain: "lab.example.com", RoutingOnly: true},
+			},
+			defaultRoute: false,
+		},
+		{
+			// Sin dominios los DNS del túnel solo se usarían como ruta por defecto
+			name:         "split DNS sin dominios",
+			config:       DNSConfig{RoutingOnly: true},
+			want:         []resolvedDomain{},
+			defaultRoute: true,
+		},
+		{
+			name:   "global",
+			config: DNSConfig{Domains: []string{"corp.example.com"}},
+			want: []resolvedDomain{
+				{Domain: "corp.example.com", RoutingOnly: false},
+				{Domain: ".", RoutingOnly: true},
+			},
+			defaultRoute: true,
+		},
+		{
+			name:         "global sin dominios",
+			config:       DNSConfig{},
+			want:         []resolvedDomain{{Domain: ".", RoutingOnly: true}},
+			defaultRoute: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, defaultRoute := resolvedDomains(tt.config)
+			if !reflect.DeepEqual(got, tt.want) || defaultRoute != tt.defaultRoute {
+				t.Errorf("got %+v, %v; want %+v, %v", got, defaultRoute, tt.want, tt.defaultRoute)
+			}
+		})
+	}
+}
+
+func TestResolvectlArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config DNSConfig
+		want   [][]string
+	}{
+		{
+			name:   "split DNS",
+			config: DNSConfig{Device: "tun0", Servers: []string{"10.0.0.2"}, Domains: []string{"corp.example.com"}, RoutingOnly: true},
+			want: [][]string{
+				{"dns", "tun0", "10.0.0.2"},
+				{"domain", "tun0", "~corp.example.com"},
+				{"default-route", "tun0", "false"},
+			},
+		},
+		{
+			name:   "split DNS sin dominios",
+			config: DNSConfig{Device: "tun0", Servers: []string{"10.0.0.2"}, RoutingOnly: true},
+			want: [][]string{
+				{"dns", "tun0", "10.0.0.2"},
+				{"domain", "tun0"},
+				{"default-route", "tun0", "true"},
+			},
+		},
+		{
+			name:   "global",
+			config: DNSConfig{Device: "tun0", Servers: []string{"10.0.0.2", "fd00::53"}, Domains: []string{"corp.example.com"}},
+			want: [][]string{
+				{"dns", "tun0", "10.0.0.2", "fd00::53"},
+				{"domain", "tun0", "corp.example.com", "~."},
+				{"default-route", "tun0", "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolvectlArgs(tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\n got: %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvedAddresses(t *testing.T) {
+	got, err := resolvedAddresses([]string{"10.0.0.2", "fd00::53"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Family != 2 || len(got[0].Address) != 4 || got[1].Family != 10 || len(got[1].Address) != 16 {
+		t.Errorf("direcciones = %+v", got)
+	}
+
+	if _, err := resolvedAddresses([]string{"dns.example.com"}); err == nil {
+		t.Error("se esperaba error con un servidor que no es una IP")
+	}
+}
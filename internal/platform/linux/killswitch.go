@@ -0,0 +1,238 @@
+package linux
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// KillSwitchConfig describe el tráfico que se permite con el kill switch activo
+type KillSwitchConfig struct {
+	Device   string // Interfaz del túnel; admite comodín final ("tun*")
+	Remotes  []KillSwitchRemote
+	AllowLAN bool // Permitir redes privadas y de enlace local
+}
+
+// KillSwitchRemote es un servidor VPN al que se permite conectar
+type KillSwitchRemote struct {
+	IP       string
+	Port     int
+	Protocol string // "udp" o "tcp"
+}
+
+// killSwitchTable es la tabla nftables propia de NavTunnel
+const killSwitchTable = "navtunnel"
+
+// killSwitchFile es la copia del ruleset aplicado en el directorio de
+// ejecución. Indica que el kill switch está activo y permite quitarlo
+// después de un crash de la aplicación.
+const killSwitchFile = "killswitch.nft"
+
+// killSwitchHelper es el script que instala el .deb (propiedad de root) para
+// aplicar el kill switch: solo crea o borra la tabla inet navtunnel a partir
+// de una plantilla fija. sudoers autoriza este script y no nft, que dejaría
+// a cualquier usuario reescribir el firewall completo.
+const killSwitchHelper = "/usr/lib/navtunnel/navtunnel-killswitch"
+
+var (
+	// deviceRe acepta nombres de interfaz, con un comodín final opcional
+	deviceRe = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,15}\*?$`)
+
+	// Redes locales permitidas con AllowLAN
+	lanIPv4 = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16"}
+	lanIPv6 = []string{"fc00::/7", "fe80::/10"}
+)
+
+// EnableKillSwitch aplica (o reemplaza de forma atómica) las reglas del kill switch
+func (p *LinuxPlatform) EnableKillSwitch(config KillSwitchConfig) error {
+	ruleset, err := killSwitchRuleset(config)
+	if err != nil {
+		return err
+	}
+	if err := p.applyKillSwitch(killSwitchHelperArgs(config), ruleset); err != nil {
+		return err
+	}
+
+	dir, err := p.EnsureRuntimeDir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, killSwitchFile), []byte(ruleset), 0o600)
+}
+
+// DisableKillSwitch elimina la tabla del kill switch. No falla si no existía.
+func (p *LinuxPlatform) DisableKillSwitch() error {
+	if err := p.applyKillSwitch([]string{"disable"}, killSwitchFlush()); err != nil {
+		return err
+	}
+
+	dir, err := p.EnsureRuntimeDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, killSwitchFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// KillSwitchActive indica si hay reglas del kill switch aplicadas por esta
+// sesión de usuario (leer las tablas de nftables requiere root)
+func (p *LinuxPlatform) KillSwitchActive() (bool, error) {
+	dir, err := p.EnsureRuntimeDir()
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(filepath.Join(dir, killSwitchFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// applyKillSwitch usa el helper instalado por el .deb si existe; si no (por
+// ejemplo, al compilar desde el código fuente) ejecuta el script con nft
+func (p *LinuxPlatform) applyKillSwitch(helperArgs []string, script string) error {
+	if _, err := os.Stat(killSwitchHelper); err != nil {
+		return p.nft(script)
+	}
+
+	name, args, err := p.ElevateCommand(killSwitchHelper, helperArgs)
+	if err != nil {
+		return err
+	}
+	if out, err := exec.Command(name, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("kill switch: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// killSwitchHelperArgs traduce la configuración (ya validada por
+// killSwitchRuleset) a los argumentos de "navtunnel-killswitch enable"
+func killSwitchHelperArgs(config KillSwitchConfig) []string {
+	args := []string{"enable"}
+	if config.AllowLAN {
+		args = append(args, "--allow-lan")
+	}
+	args = append(args, config.Device)
+	for _, r := range config.Remotes {
+		args = append(args, r.Protocol, net.ParseIP(r.IP).String(), strconv.Itoa(r.Port))
+	}
+	return args
+}
+
+// nft ejecuta un script de nftables con privilegios elevados
+func (p *LinuxPlatform) nft(script string) error {
+	path, err := findNft()
+	if err != nil {
+		return err
+	}
+
+	name, args, err := p.ElevateCommand(path, []string{"-f", "-"})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("nft: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// findNft busca el binario de nftables; suele estar en sbin, fuera del PATH del usuario
+func findNft() (string, error) {
+	if path, err := exec.LookPath("nft"); err == nil {
+		return path, nil
+	}
+	for _, path := range []string{"/usr/sbin/nft", "/sbin/nft"} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("nftables no está instalado. Instala con: sudo apt install nftables")
+}
+
+// killSwitchFlush retorna el script que elimina la tabla. Declararla antes
+// de borrarla evita el error si no existía.
+func killSwitchFlush() string {
+	return fmt.Sprintf("table inet %[1]s\ndelete table inet %[1]s\n", killSwitchTable)
+}
+
+// killSwitchRuleset genera el script de nftables del kill switch. nft lo
+// aplica en una sola transacción, así que reemplazar las reglas de una
+// sesión en curso no deja ningún instante sin protección.
+func killSwitchRuleset(config KillSwitchConfig) (string, error) {
+	if !deviceRe.MatchString(config.Device) {
+		return "", fmt.Errorf("interfaz del túnel inválida: %q", config.Device)
+	}
+	if len(config.Remotes) == 0 {
+		return "", fmt.Errorf("no hay servidores VPN permitidos")
+	}
+
+	var remotes []string
+	for _, r := range config.Remotes {
+		ip := net.ParseIP(r.IP)
+		if ip == nil {
+			return "", fmt.Errorf("IP de servidor inválida: %q", r.IP)
+		}
+		if r.Protocol != "udp" && r.Protocol != "tcp" {
+			return "", fmt.Errorf("protocolo de servidor inválido: %q", r.Protocol)
+		}
+		if r.Port <= 0 || r.Port > 65535 {
+			return "", fmt.Errorf("puerto de servidor inválido: %d", r.Port)
+		}
+		family := "ip6"
+		if ip.To4() != nil {
+			family = "ip"
+		}
+		remotes = append(remotes, fmt.Sprintf("%s daddr %s %s dport %d accept", family, ip, r.Protocol, r.Port))
+	}
+
+	var b strings.Builder
+	b.WriteString(killSwitchFlush())
+	fmt.Fprintf(&b, "table inet %s {\n", killSwitchTable)
+
+	// Salida: solo loopback, el túnel, los servidores VPN y, opcionalmente, la LAN
+	b.WriteString("\tchain output {\n")
+	b.WriteString("\t\ttype filter hook output priority 0; policy drop;\n")
+	b.WriteString("\t\toifname \"lo\" accept\n")
+	fmt.Fprintf(&b, "\t\toifname %q accept\n", config.Device)
+	for _, rule := range remotes {
+		b.WriteString("\t\t" + rule + "\n")
+	}
+	// DHCP y descubrimiento de vecinos IPv6: sin ellos se pierde la red local
+	// y con ella la conexión al servidor
+	b.WriteString("\t\tudp sport 68 udp dport 67 accept\n")
+	b.WriteString("\t\tudp sport 546 udp dport 547 accept\n")
+	b.WriteString("\t\ticmpv6 type { nd-router-solicit, nd-neighbor-solicit, nd-neighbor-advert } accept\n")
+	if config.AllowLAN {
+		fmt.Fprintf(&b, "\t\tip daddr { %s } accept\n", strings.Join(lanIPv4, ", "))
+		fmt.Fprintf(&b, "\t\tip6 daddr { %s } accept\n", strings.Join(lanIPv6, ", "))
+	}
+	b.WriteString("\t}\n")
+
+	// Entrada: respuestas a lo permitido y lo que llega por el túnel
+	b.WriteString("\tchain input {\n")
+	b.WriteString("\t\ttype filter hook input priority 0; policy drop;\n")
+	b.WriteString("\t\tiifname \"lo\" accept\n")
+	fmt.Fprintf(&b, "\t\tiifname %q accept\n", config.Device)
+	b.WriteString("\t\tct state established,related accept\n")
+	b.WriteString("\t\tudp sport 67 udp dport 68 accept\n")
+	b.WriteString("\t\tudp sport 547 udp dport 546 accept\n")
+	b.WriteString("\t\ticmpv6 type { nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert } accept\n")
+	if config.AllowLAN {
+		fmt.Fprintf(&b, "\t\tip saddr { %s } accept\n", strings.Join(lanIPv4, ", "))
+		fmt.Fprintf(&b, "\t\tip6 saddr { %s } accept\n", strings.Join(lanIPv6, ", "))
+	}
+	b.WriteString("\t}\n")
+	b.WriteString("}\n")
+
+	return b.String(), nil
+}
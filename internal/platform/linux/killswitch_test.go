@@ -0,0 +1,158 @@
+package linux
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKillSwitchRuleset(t *testing.T) {
+	got, err := killSwitchRuleset(KillSwitchConfig{
+		Device: "tun*",
+		Remotes: []KillSwitchRemote{
+			{IP: "203.0.113.1", Port: 1194, Protocol: "udp"},
+			{IP: "2001:db8::1", Port: 443, Protocol: "tcp"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `table inet navtunnel
+delete table inet navtunnel
+table inet navtunnel {
+	chain output {
+		type filter hook output priority 0; policy drop;
+		oifname "lo" accept
+		oifname "tun*" accept
+		ip daddr 203.0.113.1 udp dport 1194 accept
+		ip6 daddr 2001:db8::1 tcp dport 443 accept
+		udp sport 68 udp dport 67 accept
+		udp sport 546 udp dport 547 accept
+		icmpv6 type { nd-router-solicit, nd-neighbor-solicit, nd-neighbor-advert } accept
+	}
+	chain input {
+		type filter hook input priority 0; policy drop;
+		iifname "lo" accept
+		iifname "tun*" accept
+		ct state established,related accept
+		udp sport 67 udp dport 68 accept
+		udp sport 547 udp dport 546 accept
+		icmpv6 type { nd-router-advert, nd-neighbor-solicit, nd-neighbor-advert } accept
+	}
+}
+`
+	if got != want {
+		t.Errorf("ruleset:\n%s\nse esperaba:\n%s", got, want)
+	}
+}
+
+func TestKillSwitchRulesetLAN(t *testing.T) {
+	got, err := killSwitchRuleset(KillSwitchConfig{
+		Device:   "tun0",
+		Remotes:  []KillSwitchRemote{{IP: "203.0.113.1", Port: 1194, Protocol: "udp"}},
+		AllowLAN: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range []string{
+		`oifname "tun0" accept`,
+		"ip daddr { 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16, 169.254.0.0/16 } accept",
+		"ip6 daddr { fc00::/7, fe80::/10 } accept",
+		"ip saddr { 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16, 169.254.0.0/16 } accept",
+	} {
+		if !strings.Contains(got, rule) {
+			t.Errorf("falta la regla %q en:\n%s", rule, got)
+		}
+	}
+}
+
+func TestKillSwitchRulesetInvalid(t *testing.T) {
+	remote := []KillSwitchRemote{{IP: "203.0.113.1", Port: 1194, Protocol: "udp"}}
+	tests := []struct {
+		name   string
+		config KillSwitchConfig
+	}{
+		{"interfaz con comillas", KillSwitchConfig{Device: `tun0" accept`, Remotes: remote}},
+		{"interfaz vacía", KillSwitchConfig{Device: "", Remotes: remote}},
+		{"sin servidores", KillSwitchConfig{Device: "tun0"}},
+		{"IP inválida", KillSwitchConfig{Device: "tun0", Remotes: []KillSwitchRemote{{IP: "vpn.example.com", Port: 1194, Protocol: "udp"}}}},
+		{"protocolo inválido", KillSwitchConfig{Device: "tun0", Remotes: []KillSwitchRemote{{IP: "203.0.113.1", Port: 1194, Protocol: "icmp"}}}},
+		{"puerto inválido", KillSwitchConfig{Device: "tun0", Remotes: []KillSwitchRemote{{IP: "203.0.113.1", Protocol: "udp"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := killSwitchRuleset(tt.config); err == nil {
+				t.Error("se esperaba un error")
+			}
+		})
+	}
+}
+
+// killSwitchHelperScript retorna bash y la ruta del helper que instala el .deb
+func killSwitchHelperScript(t *testing.T) (string, string) {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash no está disponible")
+	}
+	return bash, filepath.Join("..", "..", "..", "packaging", "debian", "usr", "lib", "navtunnel", "navtunnel-killswitch")
+}
+
+// El helper del .deb debe generar exactamente el mismo ruleset
+func TestKillSwitchHelperMatchesRuleset(t *testing.T) {
+	bash, helper := killSwitchHelperScript(t)
+
+	for _, config := range []KillSwitchConfig{
+		{
+			Device: "tun*",
+			Remotes: []KillSwitchRemote{
+				{IP: "203.0.113.1", Port: 1194, Protocol: "udp"},
+				{IP: "2001:0db8:0::1", Port: 443, Protocol: "tcp"},
+			},
+		},
+		{
+			Device:   "tun0",
+			Remotes:  []KillSwitchRemote{{IP: "203.0.113.1", Port: 1194, Protocol: "udp"}},
+			AllowLAN: true,
+		},
+	} {
+		want, err := killSwitchRuleset(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := append([]string{helper, "--print"}, killSwitchHelperArgs(config)...)
+		got, err := exec.Command(bash, args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v: %v: %s", args, err, got)
+		}
+		if string(got) != want {
+			t.Errorf("helper:\n%s\nse esperaba:\n%s", got, want)
+		}
+	}
+
+	got, err := exec.Command(bash, helper, "--print", "disable").CombinedOutput()
+	if err != nil || string(got) != killSwitchFlush() {
+		t.Errorf("disable = %q (%v), se esperaba %q", got, err, killSwitchFlush())
+	}
+}
+
+func TestKillSwitchHelperRejectsInvalidArgs(t *testing.T) {
+	bash, helper := killSwitchHelperScript(t)
+
+	for _, args := range [][]string{
+		{"flush", "ruleset"},
+		{"enable", `tun0" accept; chain x {`, "udp", "203.0.113.1", "1194"},
+		{"enable", "tun0", "icmp", "203.0.113.1", "1194"},
+		{"enable", "tun0", "udp", "203.0.113.1; flush ruleset", "1194"},
+		{"enable", "tun0", "udp", "203.0.113.1", "70000"},
+		{"enable", "tun0", "udp", "203.0.113.1"},
+	} {
+		cmd := exec.Command(bash, append([]string{helper, "--print"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("%q aceptado:\n%s", args, out)
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package linux
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
-	"time"
 )
 
 // Process representa un proceso en ejecución
@@ -19,7 +24,15 @@ type Process struct {
 // StartConfig contiene la configuración de inicio
 type StartConfig struct {
 	ConfigPath  string
+	OpenVPNPath string // Opcional: si está vacío se busca con FindOpenVPN
 	MgmtPort    int
+	// MgmtSocket es la ruta del socket Unix del management interface.
+	// Si está vacía se usa 127.0.0.1:MgmtPort.
+	MgmtSocket string
+	// MgmtPasswordFile es el archivo con la contraseña del management interface
+	MgmtPasswordFile string
+	// ExtraArgs son opciones de OpenVPN que se agregan al final (reglas de rutas)
+	ExtraArgs   []string
 	LogCallback func(string)
 }
 
@@ -57,26 +70,34 @@ func (p *LinuxPlatform) FindOpenVPN() (string, error) {
 	return path, nil
 }
 
-// StartOpenVPN inicia el proceso de OpenVPN con elevación de privilegios usando pkexec
+// StartOpenVPN inicia el proceso de OpenVPN con elevación de privilegios
 func (p *LinuxPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	// Buscar OpenVPN
-	openvpnPath, err := p.FindOpenVPN()
-	if err != nil {
-		return nil, err
+	openvpnPath := config.OpenVPNPath
+	if openvpnPath == "" {
+		path, err := p.FindOpenVPN()
+		if err != nil {
+			return nil, err
+		}
+		openvpnPath = path
 	}
 
 	// Construir argumentos base de OpenVPN
 	args := []string{
 		"--config", config.ConfigPath,
-		"--management", "127.0.0.1", fmt.Sprintf("%d", config.MgmtPort),
+	}
+	args = append(args, managementArgs(config)...)
+	args = append(args,
 		"--management-query-passwords",
 		"--management-hold",
+		"--setenv", "IV_SSO", "webauth,openurl", // Anunciar soporte de login web (SSO)
 		"--auth-retry", "interact",
 		"--auth-nocache",
 		"--verb", "4", // Más verbosidad para debugging
-	}
+	)
+	args = append(args, config.ExtraArgs...)
 
-	// Elevar comando con pkexec
+	// Elevar comando (sudo -n o pkexec)
 	elevatedCmd, elevatedArgs, err := p.ElevateCommand(openvpnPath, args)
 	if err != nil {
 		return nil, err
@@ -85,38 +106,13 @@ func (p *LinuxPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	// Crear comando
 	cmd := exec.Command(elevatedCmd, elevatedArgs...)
 
-	// Capturar stdout y stderr para debugging
+	// Capturar stdout y stderr línea a línea para el log de la aplicación
 	if config.LogCallback != nil {
-		stdout, err := cmd.StdoutPipe()
-		if err == nil {
-			go func() {
-				buf := make([]byte, 1024)
-				for {
-					n, err := stdout.Read(buf)
-					if n > 0 {
-						config.LogCallback("[OpenVPN stdout] " + string(buf[:n]))
-					}
-					if err != nil {
-						break
-					}
-				}
-			}()
+		if stdout, err := cmd.StdoutPipe(); err == nil {
+			go forwardLines(stdout, config.LogCallback)
 		}
-
-		stderr, err := cmd.StderrPipe()
-		if err == nil {
-			go func() {
-				buf := make([]byte, 1024)
-				for {
-					n, err := stderr.Read(buf)
-					if n > 0 {
-						config.LogCallback("[OpenVPN stderr] " + string(buf[:n]))
-					}
-					if err != nil {
-						break
-					}
-				}
-			}()
+		if stderr, err := cmd.StderrPipe(); err == nil {
+			go forwardLines(stderr, config.LogCallback)
 		}
 	}
 
@@ -139,37 +135,93 @@ func (p *LinuxPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	return proc, nil
 }
 
-// StopOpenVPN detiene el proceso de OpenVPN limpiamente
-func (p *LinuxPlatform) StopOpenVPN(proc *Process) error {
-	if !proc.Running || proc.Cmd.Process == nil {
+// TerminateOpenVPN envía SIGTERM (o SIGKILL si force) al grupo de procesos de
+// OpenVPN, sin esperar a que termine. sudo reenvía SIGTERM al openvpn real;
+// SIGKILL no se reenvía, por eso es el último recurso.
+func (p *LinuxPlatform) TerminateOpenVPN(proc *Process, force bool) error {
+	if proc.Cmd == nil || proc.Cmd.Process == nil {
 		return nil
 	}
 
-	// Intentar SIGTERM primero (cierre limpio)
-	if err := proc.Cmd.Process.Signal(syscall.SIGTERM); err != nil {
-		// Si ya está muerto, ok
-		proc.Running = false
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+
+	// Con Setpgid el PID del proceso lanzado es también el ID del grupo
+	if err := syscall.Kill(-proc.Cmd.Process.Pid, sig); err == nil {
 		return nil
 	}
 
-	// Esperar hasta 5 segundos a que termine
-	done := make(chan error, 1)
-	go func() {
-		done <- proc.Cmd.Wait()
-	}()
+	// Sin permiso sobre el grupo (hijos como root): al menos al proceso lanzado
+	if err := proc.Cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("error al enviar %v a OpenVPN: %w", sig, err)
+	}
+	return nil
+}
 
-	select {
-	case <-done:
-		// Terminó limpiamente
-		proc.Running = false
-		return nil
-	case <-time.After(5 * time.Second):
-		// Timeout, forzar con SIGKILL
-		if err := proc.Cmd.Process.Kill(); err != nil {
-			return fmt.Errorf("error al forzar cierre: %w", err)
+// FindOpenVPNProcesses busca procesos openvpn cuya línea de comandos incluya
+// el argumento match (p.ej. el socket del management interface de una sesión)
+func (p *LinuxPlatform) FindOpenVPNProcesses(match string) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil || len(data) == 0 {
+			continue // El proceso terminó o no es accesible
+		}
+
+		args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+		if filepath.Base(args[0]) != "openvpn" {
+			continue
+		}
+		for _, arg := range args[1:] {
+			if arg == match {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids, nil
+}
+
+// managementArgs construye las opciones --management según el endpoint pedido.
+// Con socket Unix solo el usuario actual puede conectarse (el directorio es
+// privado y además se restringe con --management-client-user).
+func managementArgs(config StartConfig) []string {
+	var args []string
+	if config.MgmtSocket != "" {
+		args = []string{"--management", config.MgmtSocket, "unix"}
+	} else {
+		args = []string{"--management", "127.0.0.1", fmt.Sprintf("%d", config.MgmtPort)}
+	}
+	if config.MgmtPasswordFile != "" {
+		args = append(args, config.MgmtPasswordFile)
+	}
+
+	if config.MgmtSocket != "" {
+		if u, err := user.Current(); err == nil {
+			args = append(args, "--management-client-user", u.Username)
+		}
+	}
+	return args
+}
+
+// forwardLines envía cada línea leída de r al callback de logs
+func forwardLines(r io.Reader, callback func(string)) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			callback(line)
 		}
-		proc.Running = false
-		return nil
 	}
 }
 
@@ -178,8 +230,15 @@ func (p *LinuxPlatform) RequiresElevation() bool {
 	return true
 }
 
-// ElevateCommand prepara un comando para ejecutarse con privilegios elevados usando pkexec
+// ElevateCommand prepara un comando para ejecutarse con privilegios elevados.
+// Prefiere "sudo -n", que usa la regla NOPASSWD instalada por el paquete .deb
+// sin pedir contraseña; si sudo no existe recurre a pkexec.
 func (p *LinuxPlatform) ElevateCommand(path string, args []string) (string, []string, error) {
+	if _, err := exec.LookPath("sudo"); err == nil {
+		elevatedArgs := append([]string{"-n", path}, args...)
+		return "sudo", elevatedArgs, nil
+	}
+
 	// Verificar que pkexec esté disponible
 	if _, err := exec.LookPath("pkexec"); err != nil {
 		return "", nil, fmt.Errorf("pkexec no está disponible. Instala con: sudo apt install policykit-1")
@@ -232,6 +291,71 @@ func (p *LinuxPlatform) GetLogPath() string {
 	return filepath.Join(cacheHome, "PreyVPN", "logs")
 }
 
+// EnsureRuntimeDir crea el directorio privado de ejecución del usuario.
+// Usa $XDG_RUNTIME_DIR/NavTunnel y, si no existe, /tmp/navtunnel-<uid>.
+// Rechaza directorios que no pertenezcan al usuario o que sean enlaces.
+func (p *LinuxPlatform) EnsureRuntimeDir() (string, error) {
+	var dir string
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		dir = filepath.Join(runtimeDir, "NavTunnel")
+	} else {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("navtunnel-%d", os.Getuid()))
+	}
+
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return "", err
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s no es un directorio", dir)
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != os.Getuid() {
+		return "", fmt.Errorf("%s pertenece a otro usuario", dir)
+	}
+	if info.Mode().Perm() != 0o700 {
+		if err := os.Chmod(dir, 0o700); err != nil {
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
+// OpenURL abre una URL en el navegador predeterminado usando xdg-open
+func (p *LinuxPlatform) OpenURL(url string) error {
+	if _, err := exec.LookPath("xdg-open"); err != nil {
+		return fmt.Errorf("xdg-open no está disponible. Instala con: sudo apt install xdg-utils")
+	}
+	return exec.Command("xdg-open", url).Start()
+}
+
+// InterfaceCounters lee los contadores de bytes de una interfaz desde sysfs
+func (p *LinuxPlatform) InterfaceCounters(name string) (uint64, uint64, error) {
+	dir := filepath.Join("/sys/class/net", filepath.Base(name), "statistics")
+
+	read := func(file string) (uint64, error) {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	}
+
+	rx, err := read("rx_bytes")
+	if err != nil {
+		return 0, 0, err
+	}
+	tx, err := read("tx_bytes")
+	if err != nil {
+		return 0, 0, err
+	}
+	return rx, tx, nil
+}
+
 // Name retorna el nombre de la plataforma
 func (p *LinuxPlatform) Name() string {
 	return "linux"
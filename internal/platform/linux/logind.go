@@ -0,0 +1,196 @@
+package linux
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Eventos de la sesión del usuario
+const (
+	SessionSleep  = "sleep"  // El sistema va a suspenderse
+	SessionResume = "resume" // El sistema volvió de la suspensión
+	SessionLock   = "lock"   // Se bloqueó la pantalla
+	SessionUnlock = "unlock" // Se desbloqueó la pantalla
+)
+
+const (
+	logindName    = "org.freedesktop.login1"
+	logindPath    = "/org/freedesktop/login1"
+	logindManager = "org.freedesktop.login1.Manager"
+	logindSession = "org.freedesktop.login1.Session"
+)
+
+// SessionMonitor recibe de systemd-logind las señales de suspensión
+// (PrepareForSleep) y de bloqueo de la sesión del usuario (Lock/Unlock)
+type SessionMonitor struct {
+	conn    *dbus.Conn
+	session dbus.ObjectPath // Sesión del usuario; vacía si no se encontró
+	signals chan *dbus.Signal
+	events  chan string
+
+	mu        sync.Mutex
+	delay     bool     // Se pidió retrasar la suspensión
+	inhibitor *os.File // Bloqueo "delay" de logind mientras se mantiene abierto
+}
+
+// WatchSession se suscribe a las señales de logind por el bus del sistema
+func (p *LinuxPlatform) WatchSession() (*SessionMonitor, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar al bus del sistema: %w", err)
+	}
+	m, err := watchSession(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// watchSession se suscribe a las señales de logind en la conexión indicada,
+// que pasa a ser del monitor
+func watchSession(conn *dbus.Conn) (*SessionMonitor, error) {
+	m := &SessionMonitor{
+		conn:    conn,
+		signals: make(chan *dbus.Signal, 16),
+		events:  make(chan string, 16),
+	}
+
+	err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(logindPath),
+		dbus.WithMatchInterface(logindManager),
+		dbus.WithMatchMember("PrepareForSleep"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo suscribir a logind: %w", err)
+	}
+
+	// Sin sesión (por ejemplo, fuera de un escritorio) solo hay suspensión
+	if session, err := logindSessionPath(conn); err == nil {
+		m.session = session
+		err = conn.AddMatchSignal(
+			dbus.WithMatchObjectPath(session),
+			dbus.WithMatchInterface(logindSession),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("no se pudo suscribir a la sesión de logind: %w", err)
+		}
+	}
+
+	conn.Signal(m.signals)
+	go m.read()
+	return m, nil
+}
+
+// logindSessionPath busca la sesión de logind del proceso
+func logindSessionPath(conn *dbus.Conn) (dbus.ObjectPath, error) {
+	obj := conn.Object(logindName, logindPath)
+
+	var path dbus.ObjectPath
+	err := obj.Call(logindManager+".GetSession", 0, "auto").Store(&path)
+	if err == nil {
+		return path, nil
+	}
+	if err := obj.Call(logindManager+".GetSessionByPID", 0, uint32(os.Getpid())).Store(&path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Events retorna el canal de eventos (SessionSleep, SessionResume, ...); se cierra con Close
+func (m *SessionMonitor) Events() <-chan string {
+	return m.events
+}
+
+// Close deja de recibir señales y libera el retraso de la suspensión
+func (m *SessionMonitor) Close() error {
+	m.mu.Lock()
+	m.delay = false
+	m.releaseLocked()
+	m.mu.Unlock()
+	return m.conn.Close()
+}
+
+// SetSleepDelay pide (o deja de pedir) a logind que espere a NavTunnel antes
+// de suspender, hasta SleepReady o el tope del sistema (InhibitDelayMaxSec)
+func (m *SessionMonitor) SetSleepDelay(enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.delay = enabled
+	if !enabled {
+		m.releaseLocked()
+		return nil
+	}
+	if m.inhibitor != nil {
+		return nil
+	}
+	return m.inhibitLocked()
+}
+
+// SleepReady avisa que NavTunnel terminó de prepararse para la suspensión.
+// El retraso se vuelve a pedir al despertar.
+func (m *SessionMonitor) SleepReady() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.releaseLocked()
+}
+
+// inhibitLocked toma un bloqueo "delay" de suspensión en logind
+func (m *SessionMonitor) inhibitLocked() error {
+	var fd dbus.UnixFD
+	err := m.conn.Object(logindName, logindPath).Call(logindManager+".Inhibit", 0,
+		"sleep", "NavTunnel", "Desconectar la VPN antes de suspender", "delay").Store(&fd)
+	if err != nil {
+		return fmt.Errorf("logind no permitió retrasar la suspensión: %w", err)
+	}
+	m.inhibitor = os.NewFile(uintptr(fd), "logind-inhibit")
+	return nil
+}
+
+// releaseLocked suelta el bloqueo de suspensión, si se tenía
+func (m *SessionMonitor) releaseLocked() {
+	if m.inhibitor != nil {
+		m.inhibitor.Close()
+		m.inhibitor = nil
+	}
+}
+
+// read traduce las señales de logind a eventos hasta que se cierra la conexión
+func (m *SessionMonitor) read() {
+	defer close(m.events)
+
+	for sig := range m.signals {
+		switch sig.Name {
+		case logindManager + ".PrepareForSleep":
+			if len(sig.Body) == 0 {
+				continue
+			}
+			start, _ := sig.Body[0].(bool)
+			if start {
+				m.events <- SessionSleep
+				continue
+			}
+			m.mu.Lock()
+			if m.delay && m.inhibitor == nil {
+				if err := m.inhibitLocked(); err != nil {
+					m.delay = false
+				}
+			}
+			m.mu.Unlock()
+			m.events <- SessionResume
+
+		case logindSession + ".Lock":
+			if sig.Path == m.session {
+				m.events <- SessionLock
+			}
+		case logindSession + ".Unlock":
+			if sig.Path == m.session {
+				m.events <- SessionUnlock
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package linux
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// busConfig es la configuración de un dbus-daemon privado sin restricciones
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*"/>
+    <allow receive_sender="*"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// privateBus inicia un dbus-daemon propio del test y retorna su dirección
+func privateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon no está disponible")
+	}
+
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "bus")
+	configPath := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(configPath, []byte(strings.Replace(busConfig, "%s", socket, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+configPath, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("dbus-daemon no informó su dirección: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// fakeLogind implementa la parte de org.freedesktop.login1.Manager que usa el monitor
+type fakeLogind struct {
+	mu         sync.Mutex
+	inhibits   int
+	inhibitors []*os.File // Se mantienen abiertos hasta enviar la respuesta
+}
+
+const fakeSessionPath = dbus.ObjectPath("/org/freedesktop/login1/session/_31")
+
+func (f *fakeLogind) GetSession(id string) (dbus.ObjectPath, *dbus.Error) {
+	return fakeSessionPath, nil
+}
+
+func (f *fakeLogind) GetSessionByPID(pid uint32) (dbus.ObjectPath, *dbus.Error) {
+	return fakeSessionPath, nil
+}
+
+func (f *fakeLogind) Inhibit(what, who, why, mode string) (dbus.UnixFD, *dbus.Error) {
+	f.mu.Lock()
+	f.inhibits++
+	f.mu.Unlock()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+	r.Close()
+	f.mu.Lock()
+	f.inhibitors = append(f.inhibitors, w)
+	f.mu.Unlock()
+	return dbus.UnixFD(w.Fd()), nil
+}
+
+func (f *fakeLogind) inhibitCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.inhibits
+}
+
+func TestSessionMonitor(t *testing.T) {
+	address := privateBus(t)
+
+	logind, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logind.Close()
+	fake := &fakeLogind{}
+	if err := logind.Export(fake, logindPath, logindManager); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := logind.RequestName(logindName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("RequestName: %v (%v)", reply, err)
+	}
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := watchSession(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.SetSleepDelay(true); err != nil {
+		t.Fatalf("SetSleepDelay: %v", err)
+	}
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-m.Events():
+			if got != want {
+				t.Errorf("evento = %q, se esperaba %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no llegó el evento %q", want)
+		}
+	}
+
+	emit := func(path dbus.ObjectPath, name string, values ...interface{}) {
+		t.Helper()
+		if err := logind.Emit(path, name, values...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	emit(logindPath, logindManager+".PrepareForSleep", true)
+	expect(SessionSleep)
+	m.SleepReady()
+
+	emit(logindPath, logindManager+".PrepareForSleep", false)
+	expect(SessionResume)
+	if n := fake.inhibitCount(); n != 2 {
+		t.Errorf("Inhibit se llamó %d veces, se esperaban 2 (al pedirlo y al despertar)", n)
+	}
+
+	// Las señales de otra sesión se ignoran
+	emit("/org/freedesktop/login1/session/_99", logindSession+".Lock")
+	emit(fakeSessionPath, logindSession+".Lock")
+	expect(SessionLock)
+	emit(fakeSessionPath, logindSession+".Unlock")
+	expect(SessionUnlock)
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range m.Events() {
+	}
+}
@@ -0,0 +1,163 @@
+package linux
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// Tipos de cambio de red
+const (
+	NetworkLink    = "link"    // Interfaz creada, borrada, levantada o caída
+	NetworkAddress = "address" // Dirección agregada o quitada
+	NetworkRoute   = "route"   // Ruta agregada o quitada
+)
+
+// NetworkChange es un aviso de cambio en la red del sistema
+type NetworkChange struct {
+	Kind   string // NetworkLink, NetworkAddress, NetworkRoute o vacío si se perdieron avisos
+	Device string // Interfaz afectada; vacío si ya no existe o no se sabe
+}
+
+// NetlinkMonitor recibe los avisos de rtnetlink de interfaces, direcciones y rutas
+type NetlinkMonitor struct {
+	file    *os.File
+	changes chan NetworkChange
+}
+
+// Grupos de multicast de rtnetlink (linux/rtnetlink.h)
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4Ifaddr = 0x10
+	rtmgrpIPv4Route  = 0x40
+	rtmgrpIPv6Ifaddr = 0x100
+	rtmgrpIPv6Route  = 0x400
+
+	// netlinkGroups son los grupos a los que se suscribe el monitor
+	netlinkGroups = rtmgrpLink | rtmgrpIPv4Ifaddr | rtmgrpIPv4Route | rtmgrpIPv6Ifaddr | rtmgrpIPv6Route
+)
+
+// WatchNetwork abre un socket rtnetlink suscrito a los cambios de red.
+// No requiere privilegios.
+func (p *LinuxPlatform) WatchNetwork() (*NetlinkMonitor, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el socket netlink: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: netlinkGroups}); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("no se pudo suscribir a rtnetlink: %w", err)
+	}
+
+	// Con el descriptor no bloqueante, os.File usa el poller de Go y Close
+	// desbloquea la lectura en curso
+	m := &NetlinkMonitor{
+		file:    os.NewFile(uintptr(fd), "rtnetlink"),
+		changes: make(chan NetworkChange, 16),
+	}
+	go m.read()
+	return m, nil
+}
+
+// Changes retorna el canal de avisos; se cierra con Close
+func (m *NetlinkMonitor) Changes() <-chan NetworkChange {
+	return m.changes
+}
+
+// Close deja de recibir avisos
+func (m *NetlinkMonitor) Close() error {
+	return m.file.Close()
+}
+
+// read recibe los mensajes de rtnetlink hasta que se cierra el socket
+func (m *NetlinkMonitor) read() {
+	defer close(m.changes)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := m.file.Read(buf)
+		if errors.Is(err, syscall.ENOBUFS) {
+			// El kernel descartó mensajes: no se sabe qué cambió
+			m.send(NetworkChange{})
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		changes, err := parseNetlinkChanges(buf[:n])
+		if err != nil {
+			m.send(NetworkChange{})
+			continue
+		}
+		for _, c := range changes {
+			change := NetworkChange{Kind: c.kind}
+			if iface, err := net.InterfaceByIndex(c.index); err == nil {
+				change.Device = iface.Name
+			}
+			m.send(change)
+		}
+	}
+}
+
+// send entrega un aviso sin bloquear: si el canal está lleno ya hay avisos
+// pendientes que harán revisar la red igual
+func (m *NetlinkMonitor) send(change NetworkChange) {
+	select {
+	case m.changes <- change:
+	default:
+	}
+}
+
+// netlinkChange es un aviso de rtnetlink con el índice de la interfaz
+type netlinkChange struct {
+	kind  string
+	index int
+}
+
+// parseNetlinkChanges interpreta los mensajes RTM_* de un datagrama de rtnetlink
+func parseNetlinkChanges(buf []byte) ([]netlinkChange, error) {
+	msgs, err := syscall.ParseNetlinkMessage(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []netlinkChange
+	for i := range msgs {
+		msg := &msgs[i]
+		switch msg.Header.Type {
+		case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+			// ifinfomsg: family, pad, type (u16), index (i32), ...
+			if len(msg.Data) < syscall.SizeofIfInfomsg {
+				continue
+			}
+			index := int32(binary.NativeEndian.Uint32(msg.Data[4:8]))
+			changes = append(changes, netlinkChange{NetworkLink, int(index)})
+
+		case syscall.RTM_NEWADDR, syscall.RTM_DELADDR:
+			// ifaddrmsg: family, prefixlen, flags, scope (u8), index (u32)
+			if len(msg.Data) < syscall.SizeofIfAddrmsg {
+				continue
+			}
+			index := binary.NativeEndian.Uint32(msg.Data[4:8])
+			changes = append(changes, netlinkChange{NetworkAddress, int(index)})
+
+		case syscall.RTM_NEWROUTE, syscall.RTM_DELROUTE:
+			change := netlinkChange{kind: NetworkRoute}
+			attrs, err := syscall.ParseNetlinkRouteAttr(msg)
+			if err != nil {
+				continue
+			}
+			for _, attr := range attrs {
+				if attr.Attr.Type == syscall.RTA_OIF && len(attr.Value) >= 4 {
+					change.index = int(binary.NativeEndian.Uint32(attr.Value))
+				}
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
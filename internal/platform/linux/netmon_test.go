@@ -0,0 +1,67 @@
+package linux
+
+import (
+	"encoding/binary"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+// netlinkMessage arma un mensaje de rtnetlink con el orden de bytes del host
+func netlinkMessage(msgType uint16, data []byte) []byte {
+	for len(data)%4 != 0 {
+		data = append(data, 0)
+	}
+	msg := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(data))
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(syscall.NLMSG_HDRLEN+len(data)))
+	binary.NativeEndian.PutUint16(msg[4:6], msgType)
+	return append(msg, data...)
+}
+
+func TestParseNetlinkChanges(t *testing.T) {
+	link := make([]byte, syscall.SizeofIfInfomsg)
+	binary.NativeEndian.PutUint32(link[4:8], 2)
+
+	addr := make([]byte, syscall.SizeofIfAddrmsg)
+	binary.NativeEndian.PutUint32(addr[4:8], 3)
+
+	// rtmsg seguido de RTA_OIF
+	route := make([]byte, syscall.SizeofRtMsg+8)
+	binary.NativeEndian.PutUint16(route[syscall.SizeofRtMsg:], 8)
+	binary.NativeEndian.PutUint16(route[syscall.SizeofRtMsg+2:], syscall.RTA_OIF)
+	binary.NativeEndian.PutUint32(route[syscall.SizeofRtMsg+4:], 4)
+
+	var buf []byte
+	buf = append(buf, netlinkMessage(syscall.RTM_NEWLINK, link)...)
+	buf = append(buf, netlinkMessage(syscall.RTM_DELADDR, addr)...)
+	buf = append(buf, netlinkMessage(syscall.RTM_NEWROUTE, route)...)
+	buf = append(buf, netlinkMessage(syscall.RTM_DELROUTE, make([]byte, syscall.SizeofRtMsg))...)
+	buf = append(buf, netlinkMessage(syscall.RTM_NEWNEIGH, make([]byte, 12))...)
+	buf = append(buf, netlinkMessage(syscall.RTM_NEWADDR, make([]byte, 2))...) // Truncado
+
+	got, err := parseNetlinkChanges(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []netlinkChange{
+		{NetworkLink, 2},
+		{NetworkAddress, 3},
+		{NetworkRoute, 4},
+		{NetworkRoute, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNetlinkChanges:\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestWatchNetworkClose(t *testing.T) {
+	m, err := (&LinuxPlatform{}).WatchNetwork()
+	if err != nil {
+		t.Skipf("rtnetlink no disponible: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for range m.Changes() {
+	}
+}
@@ -0,0 +1,49 @@
+package linux
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pingRTTRe reconoce el tiempo de respuesta en la salida de ping ("time=12.3 ms")
+var pingRTTRe = regexp.MustCompile(`time[=<]([0-9.]+) ?ms`)
+
+// Ping envía un eco ICMP con el ping del sistema, que tiene los permisos
+// necesarios (setuid o capabilities) para abrir el socket ICMP
+func (p *LinuxPlatform) Ping(host string, timeout time.Duration) (time.Duration, error) {
+	if strings.HasPrefix(host, "-") {
+		return 0, fmt.Errorf("host inválido: %q", host)
+	}
+	path, err := exec.LookPath("ping")
+	if err != nil {
+		return 0, fmt.Errorf("ping no está disponible. Instala con: sudo apt install iputils-ping")
+	}
+
+	wait := int(math.Ceil(timeout.Seconds()))
+	if wait < 1 {
+		wait = 1
+	}
+	out, err := exec.Command(path, "-n", "-c", "1", "-W", strconv.Itoa(wait), host).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("sin respuesta de %s", host)
+	}
+	return parsePingRTT(string(out))
+}
+
+// parsePingRTT extrae el tiempo de respuesta de la salida de ping
+func parsePingRTT(output string) (time.Duration, error) {
+	match := pingRTTRe.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("respuesta de ping no reconocida")
+	}
+	ms, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms * float64(time.Millisecond)), nil
+}
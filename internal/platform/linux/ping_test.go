@@ -0,0 +1,31 @@
+package linux
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePingRTT(t *testing.T) {
+	tests := []struct {
+		output string
+		want   time.Duration
+	}{
+		{"64 bytes from 10.10.0.1: icmp_seq=1 ttl=63 time=12.3 ms\n", 12300 * time.Microsecond},
+		{"64 bytes from ::1: icmp_seq=1 ttl=64 time=0.045 ms\n", 45 * time.Microsecond},
+		{"64 bytes from 10.10.0.1: icmp_seq=1 ttl=63 time<1ms\n", time.Millisecond},
+	}
+	for _, tt := range tests {
+		got, err := parsePingRTT(tt.output)
+		if err != nil {
+			t.Errorf("parsePingRTT(%q): %v", tt.output, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePingRTT(%q) = %s, se esperaba %s", tt.output, got, tt.want)
+		}
+	}
+
+	if _, err := parsePingRTT("1 packets transmitted, 0 received, 100% packet loss"); err == nil {
+		t.Error("se esperaba un error sin tiempo de respuesta")
+	}
+}
@@ -0,0 +1,178 @@
+package linux
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Route es una entrada de la tabla de rutas del sistema
+type Route struct {
+	Destination string // Prefijo en notación CIDR ("0.0.0.0/0", "fd00::/64")
+	Gateway     string // Vacío si la ruta es directa por la interfaz
+	Device      string
+	Metric      int
+}
+
+// Flags de rtentry (linux/route.h, linux/ipv6_route.h)
+const (
+	rtfUp      = 0x0001
+	rtfGateway = 0x0002
+	rtfLocal   = 0x80000000
+)
+
+// ifaFTemporary marca una dirección IPv6 temporal (linux/if_addr.h)
+const ifaFTemporary = 0x01
+
+// RoutingTable lee la tabla de rutas principal desde /proc/net
+func (p *LinuxPlatform) RoutingTable() ([]Route, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer la tabla de rutas: %w", err)
+	}
+	defer f.Close()
+
+	routes, err := parseRoutes(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// IPv6 puede estar desactivado: sin el archivo solo hay rutas IPv4
+	if f6, err := os.Open("/proc/net/ipv6_route"); err == nil {
+		defer f6.Close()
+		routes6, err := parseIPv6Routes(f6)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, routes6...)
+	}
+	return routes, nil
+}
+
+// parseRoutes interpreta /proc/net/route: direcciones en hexadecimal con el
+// orden de bytes del host
+func parseRoutes(r io.Reader) ([]Route, error) {
+	var routes []Route
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Encabezado
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&rtfUp == 0 {
+			continue
+		}
+		dest, err1 := hexIPv4(fields[1])
+		gateway, err2 := hexIPv4(fields[2])
+		mask, err3 := hexIPv4(fields[7])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("línea inválida en la tabla de rutas: %q", scanner.Text())
+		}
+		ones, _ := net.IPMask(mask).Size()
+		metric, _ := strconv.Atoi(fields[6])
+
+		route := Route{
+			Destination: fmt.Sprintf("%s/%d", dest, ones),
+			Device:      fields[0],
+			Metric:      metric,
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = gateway.String()
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
+
+// parseIPv6Routes interpreta /proc/net/ipv6_route, omitiendo las rutas
+// locales y de multicast que el kernel agrega a cada interfaz
+func parseIPv6Routes(r io.Reader) ([]Route, error) {
+	var routes []Route
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[8], 16, 32)
+		if err != nil || flags&rtfUp == 0 || flags&rtfLocal != 0 || fields[9] == "lo" {
+			continue
+		}
+		dest, err1 := hex.DecodeString(fields[0])
+		prefix, err2 := strconv.ParseUint(fields[1], 16, 8)
+		gateway, err3 := hex.DecodeString(fields[4])
+		metric, err4 := strconv.ParseUint(fields[5], 16, 32)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || len(dest) != net.IPv6len || len(gateway) != net.IPv6len {
+			return nil, fmt.Errorf("línea inválida en la tabla de rutas IPv6: %q", scanner.Text())
+		}
+		if net.IP(dest).IsMulticast() {
+			continue
+		}
+
+		route := Route{
+			Destination: fmt.Sprintf("%s/%d", net.IP(dest), prefix),
+			Device:      fields[9],
+			Metric:      int(metric),
+		}
+		if flags&rtfGateway != 0 {
+			route.Gateway = net.IP(gateway).String()
+		}
+		routes = append(routes, route)
+	}
+	return routes, scanner.Err()
+}
+
+// TemporaryAddresses lee de /proc/net/if_inet6 las direcciones IPv6
+// temporales. Sin IPv6 no hay ninguna.
+func (p *LinuxPlatform) TemporaryAddresses() ([]string, error) {
+	f, err := os.Open("/proc/net/if_inet6")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseTemporaryAddresses(f)
+}
+
+// parseTemporaryAddresses interpreta /proc/net/if_inet6:
+// dirección, índice, prefijo, alcance, flags e interfaz, en hexadecimal
+func parseTemporaryAddresses(r io.Reader) ([]string, error) {
+	var addresses []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		addr, err1 := hex.DecodeString(fields[0])
+		flags, err2 := strconv.ParseUint(fields[4], 16, 32)
+		if err1 != nil || err2 != nil || len(addr) != net.IPv6len {
+			return nil, fmt.Errorf("línea inválida en if_inet6: %q", scanner.Text())
+		}
+		if flags&ifaFTemporary != 0 {
+			addresses = append(addresses, net.IP(addr).String())
+		}
+	}
+	return addresses, scanner.Err()
+}
+
+// hexIPv4 convierte "0102A8C0" (orden del host) en 192.168.2.1
+func hexIPv4(s string) (net.IP, error) {
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, net.IPv4len)
+	binary.NativeEndian.PutUint32(ip, uint32(v))
+	return ip, nil
+}
@@ -0,0 +1,67 @@
+package linux
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRoutes(t *testing.T) {
+	// Formato de un host little-endian
+	table := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"eth0\t00000000\t0102A8C0\t0003\t0\t0\t100\t00000000\t0\t0\t0\n" +
+		"eth0\t0002A8C0\t00000000\t0001\t0\t0\t100\t00FFFFFF\t0\t0\t0\n" +
+		"tun0\t00000A0A\t00000000\t0001\t0\t0\t0\t0000FFFF\t0\t0\t0\n" +
+		"tun1\t0000000A\t00000000\t0000\t0\t0\t0\t000000FF\t0\t0\t0\n"
+
+	got, err := parseRoutes(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Route{
+		{Destination: "0.0.0.0/0", Gateway: "192.168.2.1", Device: "eth0", Metric: 100},
+		{Destination: "192.168.2.0/24", Device: "eth0", Metric: 100},
+		{Destination: "10.10.0.0/16", Device: "tun0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRoutes:\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestParseIPv6Routes(t *testing.T) {
+	table := "fd000000000000000000000000000000 40 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000001 00000000 00000001     eth0\n" +
+		"00000000000000000000000000000000 00 00000000000000000000000000000000 00 fd000000000000000000000000000001 00000400 00000001 00000000 00000003     eth0\n" +
+		"00000000000000000000000000000001 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001       lo\n" +
+		"fd000000000000000000000000000002 80 00000000000000000000000000000000 00 00000000000000000000000000000000 00000000 00000002 00000000 80200001     eth0\n" +
+		"ff000000000000000000000000000000 08 00000000000000000000000000000000 00 00000000000000000000000000000000 00000100 00000003 00000000 00000001     eth0\n"
+
+	got, err := parseIPv6Routes(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Route{
+		{Destination: "fd00::/64", Device: "eth0", Metric: 256},
+		{Destination: "::/0", Gateway: "fd00::1", Device: "eth0", Metric: 1024},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseIPv6Routes:\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestParseTemporaryAddresses(t *testing.T) {
+	table := "20010db8000000001a2b3c4d5e6f7081 02 40 00 01    wlan0\n" +
+		"20010db800000000021122fffe334455 02 40 00 00    wlan0\n" +
+		"20010db800000000aabbccddeeff0011 02 40 00 21    wlan0\n" +
+		"fe80000000000000021122fffe334455 02 40 20 80    wlan0\n" +
+		"00000000000000000000000000000001 01 80 10 80       lo\n"
+
+	got, err := parseTemporaryAddresses(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La segunda temporal ya está en desuso (0x20) pero sigue siendo temporal
+	want := []string{"2001:db8::1a2b:3c4d:5e6f:7081", "2001:db8::aabb:ccdd:eeff:11"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTemporaryAddresses = %q, se esperaba %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package platform
 import (
 	"os/exec"
 	"runtime"
+	"time"
 )
 
 // Process representa un proceso de OpenVPN en ejecución
@@ -15,16 +16,96 @@ type Process struct {
 // StartConfig contiene la configuración para iniciar OpenVPN
 type StartConfig struct {
 	ConfigPath  string
+	OpenVPNPath string // Opcional: si está vacío se busca con FindOpenVPN
 	MgmtPort    int
+	// MgmtSocket es la ruta del socket Unix del management interface.
+	// Si está vacía se usa 127.0.0.1:MgmtPort.
+	MgmtSocket string
+	// MgmtPasswordFile es el archivo con la contraseña del management interface
+	MgmtPasswordFile string
+	// ExtraArgs son opciones de OpenVPN que se agregan al final (reglas de rutas)
+	ExtraArgs   []string
 	LogCallback func(string)
 }
 
+// DNSConfig describe los DNS que se aplican a la interfaz del túnel
+type DNSConfig struct {
+	Device  string
+	Servers []string
+	Domains []string
+	// RoutingOnly hace que los dominios solo enruten sus consultas al túnel
+	// (split DNS). Si es false el túnel recibe todas las consultas.
+	RoutingOnly bool
+}
+
+// Route es una entrada de la tabla de rutas del sistema
+type Route struct {
+	Destination string // Prefijo en notación CIDR ("0.0.0.0/0", "fd00::/64")
+	Gateway     string // Vacío si la ruta es directa por la interfaz
+	Device      string
+	Metric      int
+}
+
+// KillSwitchConfig describe el tráfico que se permite con el kill switch activo
+type KillSwitchConfig struct {
+	Device   string // Interfaz del túnel; admite comodín final ("tun*")
+	Remotes  []KillSwitchRemote
+	AllowLAN bool // Permitir redes privadas y de enlace local
+}
+
+// KillSwitchRemote es un servidor VPN al que se permite conectar
+type KillSwitchRemote struct {
+	IP       string
+	Port     int
+	Protocol string // "udp" o "tcp"
+}
+
+// NetworkChange es un aviso de cambio en las interfaces, direcciones o rutas del sistema
+type NetworkChange struct {
+	Kind   string // "link", "address", "route" o vacío si se perdieron avisos
+	Device string // Interfaz afectada; vacío si ya no existe o no se sabe
+}
+
+// NetworkMonitor entrega los cambios de red del sistema hasta que se cierra
+type NetworkMonitor interface {
+	// Changes retorna el canal de avisos; se cierra después de Close
+	Changes() <-chan NetworkChange
+	Close() error
+}
+
+// SessionEvent es un evento de la sesión del usuario (suspensión o bloqueo)
+type SessionEvent string
+
+const (
+	SessionSleep  SessionEvent = "sleep"  // El sistema va a suspenderse
+	SessionResume SessionEvent = "resume" // El sistema volvió de la suspensión
+	SessionLock   SessionEvent = "lock"   // Se bloqueó la pantalla
+	SessionUnlock SessionEvent = "unlock" // Se desbloqueó la pantalla
+)
+
+// SessionMonitor entrega los eventos de suspensión y bloqueo de la sesión
+type SessionMonitor interface {
+	// Events retorna el canal de eventos; se cierra después de Close
+	Events() <-chan SessionEvent
+	// SetSleepDelay pide (o deja de pedir) que el sistema espere antes de
+	// suspender, hasta SleepReady
+	SetSleepDelay(enabled bool) error
+	// SleepReady avisa que la aplicación terminó de prepararse para suspender
+	SleepReady()
+	Close() error
+}
+
 // Platform define la interfaz para operaciones específicas de cada plataforma
 type Platform interface {
 	// Process management
 	FindOpenVPN() (string, error)
 	StartOpenVPN(config StartConfig) (*Process, error)
-	StopOpenVPN(proc *Process) error
+	// TerminateOpenVPN pide a OpenVPN y a sus procesos hijos que terminen
+	// (force: terminación forzada). No espera a que el proceso salga.
+	TerminateOpenVPN(proc *Process, force bool) error
+	// FindOpenVPNProcesses busca procesos openvpn cuya línea de comandos
+	// incluya el argumento match
+	FindOpenVPNProcesses(match string) ([]int, error)
 
 	// Privilege elevation
 	RequiresElevation() bool
@@ -34,6 +115,39 @@ type Platform interface {
 	GetConfigDir() string
 	GetDefaultConfigPath() string
 	GetLogPath() string
+	// EnsureRuntimeDir crea (si hace falta) y retorna el directorio privado
+	// del usuario para sockets y archivos efímeros de la sesión
+	EnsureRuntimeDir() (string, error)
+
+	// Network
+	// InterfaceCounters retorna los bytes recibidos y enviados por una interfaz
+	InterfaceCounters(name string) (rx uint64, tx uint64, err error)
+	// ConfigureDNS aplica los DNS empujados por el servidor a la interfaz del túnel
+	ConfigureDNS(config DNSConfig) error
+	// RevertDNS deshace lo aplicado por ConfigureDNS en la interfaz
+	RevertDNS(device string) error
+	// RoutingTable retorna la tabla de rutas del sistema (IPv4 e IPv6)
+	RoutingTable() ([]Route, error)
+	// TemporaryAddresses retorna las direcciones IPv6 temporales (extensiones
+	// de privacidad) del sistema, que se renuevan solas cada cierto tiempo
+	TemporaryAddresses() ([]string, error)
+	// EnableKillSwitch bloquea todo el tráfico que no vaya por el túnel o al
+	// servidor VPN. Llamarlo de nuevo reemplaza las reglas sin dejar huecos.
+	EnableKillSwitch(config KillSwitchConfig) error
+	// DisableKillSwitch quita el bloqueo (no falla si no estaba activo)
+	DisableKillSwitch() error
+	// KillSwitchActive indica si quedó un bloqueo aplicado, por ejemplo
+	// después de que la aplicación terminara sin quitarlo
+	KillSwitchActive() (bool, error)
+	// Ping envía un eco ICMP y retorna el tiempo de respuesta
+	Ping(host string, timeout time.Duration) (time.Duration, error)
+	// WatchNetwork se suscribe a los cambios de red del sistema
+	WatchNetwork() (NetworkMonitor, error)
+	// WatchSession se suscribe a la suspensión y al bloqueo de la sesión del usuario
+	WatchSession() (SessionMonitor, error)
+
+	// Desktop integration
+	OpenURL(url string) error
 
 	// Platform info
 	Name() string
@@ -2,7 +2,11 @@
 
 package platform
 
-import "github.com/lavp2393/navtunnel/internal/platform/darwin"
+import (
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform/darwin"
+)
 
 // darwinAdapter adapta darwin.DarwinPlatform a la interfaz Platform
 type darwinAdapter struct {
@@ -22,9 +26,13 @@ func (a *darwinAdapter) FindOpenVPN() (string, error) {
 
 func (a *darwinAdapter) StartOpenVPN(config StartConfig) (*Process, error) {
 	darwinConfig := darwin.StartConfig{
-		ConfigPath:  config.ConfigPath,
-		MgmtPort:    config.MgmtPort,
-		LogCallback: config.LogCallback,
+		ConfigPath:       config.ConfigPath,
+		OpenVPNPath:      config.OpenVPNPath,
+		MgmtPort:         config.MgmtPort,
+		MgmtSocket:       config.MgmtSocket,
+		MgmtPasswordFile: config.MgmtPasswordFile,
+		ExtraArgs:        config.ExtraArgs,
+		LogCallback:      config.LogCallback,
 	}
 
 	darwinProc, err := a.impl.StartOpenVPN(darwinConfig)
@@ -39,16 +47,15 @@ func (a *darwinAdapter) StartOpenVPN(config StartConfig) (*Process, error) {
 	}, nil
 }
 
-func (a *darwinAdapter) StopOpenVPN(proc *Process) error {
-	darwinProc := &darwin.Process{
-		Cmd:     proc.Cmd,
-		Running: proc.Running,
-		PID:     proc.PID,
-	}
+func (a *darwinAdapter) TerminateOpenVPN(proc *Process, force bool) error {
+	return a.impl.TerminateOpenVPN(&darwin.Process{
+		Cmd: proc.Cmd,
+		PID: proc.PID,
+	}, force)
+}
 
-	err := a.impl.StopOpenVPN(darwinProc)
-	proc.Running = darwinProc.Running
-	return err
+func (a *darwinAdapter) FindOpenVPNProcesses(match string) ([]int, error) {
+	return a.impl.FindOpenVPNProcesses(match)
 }
 
 func (a *darwinAdapter) RequiresElevation() bool {
@@ -71,6 +78,79 @@ func (a *darwinAdapter) GetLogPath() string {
 	return a.impl.GetLogPath()
 }
 
+func (a *darwinAdapter) EnsureRuntimeDir() (string, error) {
+	return a.impl.EnsureRuntimeDir()
+}
+
+func (a *darwinAdapter) OpenURL(url string) error {
+	return a.impl.OpenURL(url)
+}
+
+func (a *darwinAdapter) InterfaceCounters(name string) (uint64, uint64, error) {
+	return a.impl.InterfaceCounters(name)
+}
+
+func (a *darwinAdapter) ConfigureDNS(config DNSConfig) error {
+	return a.impl.ConfigureDNS(darwin.DNSConfig{
+		Device:      config.Device,
+		Servers:     config.Servers,
+		Domains:     config.Domains,
+		RoutingOnly: config.RoutingOnly,
+	})
+}
+
+func (a *darwinAdapter) RevertDNS(device string) error {
+	return a.impl.RevertDNS(device)
+}
+
+func (a *darwinAdapter) EnableKillSwitch(config KillSwitchConfig) error {
+	remotes := make([]darwin.KillSwitchRemote, len(config.Remotes))
+	for i, r := range config.Remotes {
+		remotes[i] = darwin.KillSwitchRemote(r)
+	}
+	return a.impl.EnableKillSwitch(darwin.KillSwitchConfig{
+		Device:   config.Device,
+		Remotes:  remotes,
+		AllowLAN: config.AllowLAN,
+	})
+}
+
+func (a *darwinAdapter) DisableKillSwitch() error {
+	return a.impl.DisableKillSwitch()
+}
+
+func (a *darwinAdapter) KillSwitchActive() (bool, error) {
+	return a.impl.KillSwitchActive()
+}
+
+func (a *darwinAdapter) WatchNetwork() (NetworkMonitor, error) {
+	return nil, a.impl.WatchNetwork()
+}
+
+func (a *darwinAdapter) WatchSession() (SessionMonitor, error) {
+	return nil, a.impl.WatchSession()
+}
+
+func (a *darwinAdapter) Ping(host string, timeout time.Duration) (time.Duration, error) {
+	return a.impl.Ping(host, timeout)
+}
+
+func (a *darwinAdapter) TemporaryAddresses() ([]string, error) {
+	return a.impl.TemporaryAddresses()
+}
+
+func (a *darwinAdapter) RoutingTable() ([]Route, error) {
+	routes, err := a.impl.RoutingTable()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Route, len(routes))
+	for i, r := range routes {
+		result[i] = Route(r)
+	}
+	return result, nil
+}
+
 func (a *darwinAdapter) Name() string {
 	return a.impl.Name()
 }
@@ -2,7 +2,11 @@
 
 package platform
 
-import "github.com/lavp2393/navtunnel/internal/platform/linux"
+import (
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform/linux"
+)
 
 // linuxAdapter adapta linux.LinuxPlatform a la interfaz Platform
 type linuxAdapter struct {
@@ -23,9 +27,13 @@ func (a *linuxAdapter) FindOpenVPN() (string, error) {
 func (a *linuxAdapter) StartOpenVPN(config StartConfig) (*Process, error) {
 	// Convertir StartConfig de platform a linux
 	linuxConfig := linux.StartConfig{
-		ConfigPath:  config.ConfigPath,
-		MgmtPort:    config.MgmtPort,
-		LogCallback: config.LogCallback,
+		ConfigPath:       config.ConfigPath,
+		OpenVPNPath:      config.OpenVPNPath,
+		MgmtPort:         config.MgmtPort,
+		MgmtSocket:       config.MgmtSocket,
+		MgmtPasswordFile: config.MgmtPasswordFile,
+		ExtraArgs:        config.ExtraArgs,
+		LogCallback:      config.LogCallback,
 	}
 
 	// Llamar a la implementación de linux
@@ -42,20 +50,15 @@ func (a *linuxAdapter) StartOpenVPN(config StartConfig) (*Process, error) {
 	}, nil
 }
 
-func (a *linuxAdapter) StopOpenVPN(proc *Process) error {
-	// Convertir platform.Process a linux.Process
-	linuxProc := &linux.Process{
-		Cmd:     proc.Cmd,
-		Running: proc.Running,
-		PID:     proc.PID,
-	}
-
-	err := a.impl.StopOpenVPN(linuxProc)
-
-	// Actualizar el estado de vuelta
-	proc.Running = linuxProc.Running
+func (a *linuxAdapter) TerminateOpenVPN(proc *Process, force bool) error {
+	return a.impl.TerminateOpenVPN(&linux.Process{
+		Cmd: proc.Cmd,
+		PID: proc.PID,
+	}, force)
+}
 
-	return err
+func (a *linuxAdapter) FindOpenVPNProcesses(match string) ([]int, error) {
+	return a.impl.FindOpenVPNProcesses(match)
 }
 
 func (a *linuxAdapter) RequiresElevation() bool {
@@ -78,6 +81,128 @@ func (a *linuxAdapter) GetLogPath() string {
 	return a.impl.GetLogPath()
 }
 
+func (a *linuxAdapter) EnsureRuntimeDir() (string, error) {
+	return a.impl.EnsureRuntimeDir()
+}
+
+func (a *linuxAdapter) OpenURL(url string) error {
+	return a.impl.OpenURL(url)
+}
+
+func (a *linuxAdapter) InterfaceCounters(name string) (uint64, uint64, error) {
+	return a.impl.InterfaceCounters(name)
+}
+
+func (a *linuxAdapter) ConfigureDNS(config DNSConfig) error {
+	return a.impl.ConfigureDNS(linux.DNSConfig{
+		Device:      config.Device,
+		Servers:     config.Servers,
+		Domains:     config.Domains,
+		RoutingOnly: config.RoutingOnly,
+	})
+}
+
+func (a *linuxAdapter) RevertDNS(device string) error {
+	return a.impl.RevertDNS(device)
+}
+
+func (a *linuxAdapter) EnableKillSwitch(config KillSwitchConfig) error {
+	remotes := make([]linux.KillSwitchRemote, len(config.Remotes))
+	for i, r := range config.Remotes {
+		remotes[i] = linux.KillSwitchRemote(r)
+	}
+	return a.impl.EnableKillSwitch(linux.KillSwitchConfig{
+		Device:   config.Device,
+		Remotes:  remotes,
+		AllowLAN: config.AllowLAN,
+	})
+}
+
+func (a *linuxAdapter) DisableKillSwitch() error {
+	return a.impl.DisableKillSwitch()
+}
+
+func (a *linuxAdapter) KillSwitchActive() (bool, error) {
+	return a.impl.KillSwitchActive()
+}
+
+func (a *linuxAdapter) Ping(host string, timeout time.Duration) (time.Duration, error) {
+	return a.impl.Ping(host, timeout)
+}
+
+func (a *linuxAdapter) WatchNetwork() (NetworkMonitor, error) {
+	impl, err := a.impl.WatchNetwork()
+	if err != nil {
+		return nil, err
+	}
+	m := &linuxNetworkMonitor{impl: impl, changes: make(chan NetworkChange, 16)}
+	go func() {
+		defer close(m.changes)
+		for c := range impl.Changes() {
+			select {
+			case m.changes <- NetworkChange(c):
+			default:
+			}
+		}
+	}()
+	return m, nil
+}
+
+// linuxNetworkMonitor adapta linux.NetlinkMonitor a NetworkMonitor
+type linuxNetworkMonitor struct {
+	impl    *linux.NetlinkMonitor
+	changes chan NetworkChange
+}
+
+func (m *linuxNetworkMonitor) Changes() <-chan NetworkChange {
+	return m.changes
+}
+
+func (m *linuxNetworkMonitor) Close() error {
+	return m.impl.Close()
+}
+
+func (a *linuxAdapter) WatchSession() (SessionMonitor, error) {
+	impl, err := a.impl.WatchSession()
+	if err != nil {
+		return nil, err
+	}
+	m := &linuxSessionMonitor{SessionMonitor: impl, events: make(chan SessionEvent, 16)}
+	go func() {
+		defer close(m.events)
+		for e := range impl.Events() {
+			m.events <- SessionEvent(e)
+		}
+	}()
+	return m, nil
+}
+
+// linuxSessionMonitor adapta linux.SessionMonitor a SessionMonitor
+type linuxSessionMonitor struct {
+	*linux.SessionMonitor
+	events chan SessionEvent
+}
+
+func (m *linuxSessionMonitor) Events() <-chan SessionEvent {
+	return m.events
+}
+
+func (a *linuxAdapter) TemporaryAddresses() ([]string, error) {
+	return a.impl.TemporaryAddresses()
+}
+
+func (a *linuxAdapter) RoutingTable() ([]Route, error) {
+	routes, err := a.impl.RoutingTable()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Route, len(routes))
+	for i, r := range routes {
+		result[i] = Route(r)
+	}
+	return result, nil
+}
+
 func (a *linuxAdapter) Name() string {
 	return a.impl.Name()
 }
@@ -2,7 +2,11 @@
 
 package platform
 
-import "github.com/lavp2393/navtunnel/internal/platform/windows"
+import (
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/platform/windows"
+)
 
 // windowsAdapter adapta windows.WindowsPlatform a la interfaz Platform
 type windowsAdapter struct {
@@ -22,9 +26,13 @@ func (a *windowsAdapter) FindOpenVPN() (string, error) {
 
 func (a *windowsAdapter) StartOpenVPN(config StartConfig) (*Process, error) {
 	winConfig := windows.StartConfig{
-		ConfigPath:  config.ConfigPath,
-		MgmtPort:    config.MgmtPort,
-		LogCallback: config.LogCallback,
+		ConfigPath:       config.ConfigPath,
+		OpenVPNPath:      config.OpenVPNPath,
+		MgmtPort:         config.MgmtPort,
+		MgmtSocket:       config.MgmtSocket,
+		MgmtPasswordFile: config.MgmtPasswordFile,
+		ExtraArgs:        config.ExtraArgs,
+		LogCallback:      config.LogCallback,
 	}
 
 	winProc, err := a.impl.StartOpenVPN(winConfig)
@@ -39,16 +47,15 @@ func (a *windowsAdapter) StartOpenVPN(config StartConfig) (*Process, error) {
 	}, nil
 }
 
-func (a *windowsAdapter) StopOpenVPN(proc *Process) error {
-	winProc := &windows.Process{
-		Cmd:     proc.Cmd,
-		Running: proc.Running,
-		PID:     proc.PID,
-	}
+func (a *windowsAdapter) TerminateOpenVPN(proc *Process, force bool) error {
+	return a.impl.TerminateOpenVPN(&windows.Process{
+		Cmd: proc.Cmd,
+		PID: proc.PID,
+	}, force)
+}
 
-	err := a.impl.StopOpenVPN(winProc)
-	proc.Running = winProc.Running
-	return err
+func (a *windowsAdapter) FindOpenVPNProcesses(match string) ([]int, error) {
+	return a.impl.FindOpenVPNProcesses(match)
 }
 
 func (a *windowsAdapter) RequiresElevation() bool {
@@ -71,6 +78,79 @@ func (a *windowsAdapter) GetLogPath() string {
 	return a.impl.GetLogPath()
 }
 
+func (a *windowsAdapter) EnsureRuntimeDir() (string, error) {
+	return a.impl.EnsureRuntimeDir()
+}
+
+func (a *windowsAdapter) OpenURL(url string) error {
+	return a.impl.OpenURL(url)
+}
+
+func (a *windowsAdapter) InterfaceCounters(name string) (uint64, uint64, error) {
+	return a.impl.InterfaceCounters(name)
+}
+
+func (a *windowsAdapter) ConfigureDNS(config DNSConfig) error {
+	return a.impl.ConfigureDNS(windows.DNSConfig{
+		Device:      config.Device,
+		Servers:     config.Servers,
+		Domains:     config.Domains,
+		RoutingOnly: config.RoutingOnly,
+	})
+}
+
+func (a *windowsAdapter) RevertDNS(device string) error {
+	return a.impl.RevertDNS(device)
+}
+
+func (a *windowsAdapter) EnableKillSwitch(config KillSwitchConfig) error {
+	remotes := make([]windows.KillSwitchRemote, len(config.Remotes))
+	for i, r := range config.Remotes {
+		remotes[i] = windows.KillSwitchRemote(r)
+	}
+	return a.impl.EnableKillSwitch(windows.KillSwitchConfig{
+		Device:   config.Device,
+		Remotes:  remotes,
+		AllowLAN: config.AllowLAN,
+	})
+}
+
+func (a *windowsAdapter) DisableKillSwitch() error {
+	return a.impl.DisableKillSwitch()
+}
+
+func (a *windowsAdapter) KillSwitchActive() (bool, error) {
+	return a.impl.KillSwitchActive()
+}
+
+func (a *windowsAdapter) Ping(host string, timeout time.Duration) (time.Duration, error) {
+	return a.impl.Ping(host, timeout)
+}
+
+func (a *windowsAdapter) WatchNetwork() (NetworkMonitor, error) {
+	return nil, a.impl.WatchNetwork()
+}
+
+func (a *windowsAdapter) WatchSession() (SessionMonitor, error) {
+	return nil, a.impl.WatchSession()
+}
+
+func (a *windowsAdapter) TemporaryAddresses() ([]string, error) {
+	return a.impl.TemporaryAddresses()
+}
+
+func (a *windowsAdapter) RoutingTable() ([]Route, error) {
+	routes, err := a.impl.RoutingTable()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Route, len(routes))
+	for i, r := range routes {
+		result[i] = Route(r)
+	}
+	return result, nil
+}
+
 func (a *windowsAdapter) Name() string {
 	return a.impl.Name()
 }
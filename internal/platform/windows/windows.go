@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -18,7 +19,15 @@ type Process struct {
 // StartConfig contiene la configuración de inicio
 type StartConfig struct {
 	ConfigPath  string
+	OpenVPNPath string // Opcional: si está vacío se busca con FindOpenVPN
 	MgmtPort    int
+	// MgmtSocket es la ruta del socket Unix del management interface.
+	// Si está vacía se usa 127.0.0.1:MgmtPort.
+	MgmtSocket string
+	// MgmtPasswordFile es el archivo con la contraseña del management interface
+	MgmtPasswordFile string
+	// ExtraArgs son opciones de OpenVPN que se agregan al final (reglas de rutas)
+	ExtraArgs   []string
 	LogCallback func(string)
 }
 
@@ -64,21 +73,29 @@ func (p *WindowsPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	// - O usar OpenVPN GUI service si está instalado
 	// - Manejar User Account Control (UAC) prompt
 
-	openvpnPath, err := p.FindOpenVPN()
-	if err != nil {
-		return nil, err
+	openvpnPath := config.OpenVPNPath
+	if openvpnPath == "" {
+		path, err := p.FindOpenVPN()
+		if err != nil {
+			return nil, err
+		}
+		openvpnPath = path
 	}
 
 	// Construir argumentos base de OpenVPN
 	args := []string{
 		"--config", config.ConfigPath,
-		"--management", "127.0.0.1", fmt.Sprintf("%d", config.MgmtPort),
+	}
+	args = append(args, managementArgs(config)...)
+	args = append(args,
 		"--management-query-passwords",
 		"--management-hold",
+		"--setenv", "IV_SSO", "webauth,openurl", // Anunciar soporte de login web (SSO)
 		"--auth-retry", "interact",
 		"--auth-nocache",
 		"--verb", "4",
-	}
+	)
+	args = append(args, config.ExtraArgs...)
 
 	// TODO: Implementar elevación en Windows
 	_ = openvpnPath
@@ -87,33 +104,37 @@ func (p *WindowsPlatform) StartOpenVPN(config StartConfig) (*Process, error) {
 	return nil, fmt.Errorf("Windows support not yet implemented")
 }
 
-// StopOpenVPN detiene el proceso de OpenVPN limpiamente
-func (p *WindowsPlatform) StopOpenVPN(proc *Process) error {
-	if !proc.Running || proc.Cmd.Process == nil {
+// managementArgs construye las opciones --management.
+// En Windows solo se soporta TCP en loopback; MgmtSocket se ignora.
+func managementArgs(config StartConfig) []string {
+	args := []string{"--management", "127.0.0.1", fmt.Sprintf("%d", config.MgmtPort)}
+	if config.MgmtPasswordFile != "" {
+		args = append(args, config.MgmtPasswordFile)
+	}
+	return args
+}
+
+// TerminateOpenVPN termina OpenVPN y sus procesos hijos, sin esperar
+func (p *WindowsPlatform) TerminateOpenVPN(proc *Process, force bool) error {
+	if proc.Cmd == nil || proc.Cmd.Process == nil {
 		return nil
 	}
 
-	// En Windows, usar Process.Kill() directamente
-	// TODO: Investigar si hay una forma más "limpia" de terminar OpenVPN en Windows
-	if err := proc.Cmd.Process.Kill(); err != nil {
+	// TODO: Usar la señal de salida del servicio interactivo de OpenVPN
+	args := []string{"/PID", strconv.Itoa(proc.Cmd.Process.Pid), "/T"}
+	if force {
+		args = append(args, "/F")
+	}
+	if err := exec.Command("taskkill", args...).Run(); err != nil {
 		return fmt.Errorf("error al cerrar OpenVPN: %w", err)
 	}
+	return nil
+}
 
-	// Esperar a que termine
-	done := make(chan error, 1)
-	go func() {
-		done <- proc.Cmd.Wait()
-	}()
-
-	select {
-	case <-done:
-		proc.Running = false
-		return nil
-	case <-time.After(5 * time.Second):
-		// Ya debería estar muerto, pero marcar como no corriendo
-		proc.Running = false
-		return nil
-	}
+// FindOpenVPNProcesses busca procesos openvpn cuya línea de comandos incluya match
+func (p *WindowsPlatform) FindOpenVPNProcesses(match string) ([]int, error) {
+	// TODO: Implementar con WMI (Win32_Process.CommandLine)
+	return nil, fmt.Errorf("Windows process lookup not yet implemented")
 }
 
 // RequiresElevation indica si la plataforma requiere elevación de privilegios
@@ -159,6 +180,118 @@ func (p *WindowsPlatform) GetLogPath() string {
 	return filepath.Join(localAppData, "PreyVPN", "logs")
 }
 
+// EnsureRuntimeDir crea el directorio privado de ejecución del usuario
+func (p *WindowsPlatform) EnsureRuntimeDir() (string, error) {
+	localAppData := os.Getenv("LOCALAPPDATA")
+	if localAppData == "" {
+		localAppData = os.TempDir()
+	}
+	dir := filepath.Join(localAppData, "PreyVPN", "run")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// OpenURL abre una URL en el navegador predeterminado
+func (p *WindowsPlatform) OpenURL(url string) error {
+	return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+}
+
+// InterfaceCounters lee los contadores de bytes de una interfaz
+func (p *WindowsPlatform) InterfaceCounters(name string) (uint64, uint64, error) {
+	// TODO: Implementar con GetIfEntry2
+	return 0, 0, fmt.Errorf("Windows interface counters not yet implemented")
+}
+
+// DNSConfig describe los DNS que se aplican a la interfaz del túnel
+type DNSConfig struct {
+	Device      string
+	Servers     []string
+	Domains     []string
+	RoutingOnly bool
+}
+
+// ConfigureDNS aplica los DNS del túnel a su interfaz
+func (p *WindowsPlatform) ConfigureDNS(config DNSConfig) error {
+	// TODO: OpenVPN ya aplica dhcp-option DNS en el adaptador TAP/wintun;
+	// el split DNS requiere reglas NRPT (Add-DnsClientNrptRule)
+	return fmt.Errorf("Windows DNS configuration not yet implemented")
+}
+
+// RevertDNS deshace la configuración DNS de la interfaz
+func (p *WindowsPlatform) RevertDNS(device string) error {
+	return nil
+}
+
+// Route es una entrada de la tabla de rutas del sistema
+type Route struct {
+	Destination string // Prefijo en notación CIDR ("0.0.0.0/0", "fd00::/64")
+	Gateway     string // Vacío si la ruta es directa por la interfaz
+	Device      string
+	Metric      int
+}
+
+// KillSwitchConfig describe el tráfico que se permite con el kill switch activo
+type KillSwitchConfig struct {
+	Device   string // Interfaz del túnel; admite comodín final ("tun*")
+	Remotes  []KillSwitchRemote
+	AllowLAN bool // Permitir redes privadas y de enlace local
+}
+
+// KillSwitchRemote es un servidor VPN al que se permite conectar
+type KillSwitchRemote struct {
+	IP       string
+	Port     int
+	Protocol string // "udp" o "tcp"
+}
+
+// EnableKillSwitch bloquea el tráfico fuera del túnel
+func (p *WindowsPlatform) EnableKillSwitch(config KillSwitchConfig) error {
+	// TODO: Implementar con Windows Filtering Platform (WFP)
+	return fmt.Errorf("Windows kill switch not yet implemented")
+}
+
+// DisableKillSwitch quita el bloqueo del kill switch
+func (p *WindowsPlatform) DisableKillSwitch() error {
+	return nil
+}
+
+// KillSwitchActive indica si el kill switch quedó activo
+func (p *WindowsPlatform) KillSwitchActive() (bool, error) {
+	return false, nil
+}
+
+// Ping envía un eco ICMP y retorna el tiempo de respuesta
+func (p *WindowsPlatform) Ping(host string, timeout time.Duration) (time.Duration, error) {
+	// TODO: Implementar con IcmpSendEcho2 (no requiere privilegios)
+	return 0, fmt.Errorf("Windows ping not yet implemented")
+}
+
+// WatchNetwork se suscribe a los cambios de red del sistema
+func (p *WindowsPlatform) WatchNetwork() error {
+	// TODO: Implementar con NotifyIpInterfaceChange y NotifyRouteChange2
+	return fmt.Errorf("Windows network monitor not yet implemented")
+}
+
+// WatchSession se suscribe a la suspensión y al bloqueo de la sesión
+func (p *WindowsPlatform) WatchSession() error {
+	// TODO: Implementar con WM_POWERBROADCAST y WTSRegisterSessionNotification
+	return fmt.Errorf("Windows session monitor not yet implemented")
+}
+
+// RoutingTable retorna la tabla de rutas del sistema
+func (p *WindowsPlatform) RoutingTable() ([]Route, error) {
+	// TODO: Implementar con GetIpForwardTable2
+	return nil, fmt.Errorf("Windows routing table not yet implemented")
+}
+
+// TemporaryAddresses retorna las direcciones IPv6 temporales del sistema
+func (p *WindowsPlatform) TemporaryAddresses() ([]string, error) {
+	// TODO: Implementar con GetUnicastIpAddressTable (SuffixOrigin == IpSuffixOriginRandom)
+	return nil, nil
+}
+
 // Name retorna el nombre de la plataforma
 func (p *WindowsPlatform) Name() string {
 	return "windows"
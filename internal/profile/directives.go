@@ -0,0 +1,76 @@
+package profile
+
+// knownDirectives son las opciones de OpenVPN 2.6 (cliente y servidor) y las
+// que se eliminaron hace poco, que el linter informa como obsoletas
+var knownDirectives = map[string]bool{
+	"allow-compression": true, "allow-nonadmin": true, "allow-pull-fqdn": true,
+	"allow-recursive-routing": true, "askpass": true, "auth": true, "auth-gen-token": true,
+	"auth-gen-token-secret": true, "auth-nocache": true, "auth-retry": true, "auth-token": true,
+	"auth-token-user": true, "auth-user-pass": true, "auth-user-pass-optional": true,
+	"auth-user-pass-verify": true, "bcast-buffers": true, "bind": true, "bind-dev": true,
+	"block-ipv6": true, "block-outside-dns": true, "ca": true, "capath": true,
+	"ccd-exclusive": true, "cd": true, "cert": true, "chroot": true, "cipher": true,
+	"client": true, "client-config-dir": true, "client-connect": true, "client-disconnect": true,
+	"client-nat": true, "client-to-client": true, "comp-lzo": true, "comp-noadapt": true,
+	"compat-mode": true, "compress": true, "config": true, "connect-freq": true,
+	"connect-retry": true, "connect-retry-max": true, "connect-timeout": true, "crl-verify": true,
+	"cryptoapicert": true, "daemon": true, "data-ciphers": true, "data-ciphers-fallback": true,
+	"dev": true, "dev-node": true, "dev-type": true, "dh": true, "dhcp-option": true,
+	"dhcp-pre-release": true, "dhcp-release": true, "dhcp-renew": true, "disable-dco": true,
+	"disable-occ": true, "dns": true, "down": true, "down-pre": true, "duplicate-cn": true,
+	"ecdh-curve": true, "echo": true, "engine": true, "explicit-exit-notify": true,
+	"extra-certs": true, "fast-io": true, "float": true, "fragment": true, "group": true,
+	"hand-window": true, "help": true, "http-proxy": true, "http-proxy-option": true,
+	"http-proxy-user-pass": true, "ifconfig": true, "ifconfig-ipv6": true,
+	"ifconfig-ipv6-pool": true, "ifconfig-ipv6-push": true, "ifconfig-noexec": true,
+	"ifconfig-nowarn": true, "ifconfig-pool": true, "ifconfig-pool-persist": true,
+	"ifconfig-push": true, "ignore-unknown-option": true, "inactive": true, "inetd": true,
+	"ip-win32": true, "ipchange": true, "iproute": true, "iroute": true, "iroute-ipv6": true,
+	"keepalive": true, "key": true, "key-direction": true, "key-method": true,
+	"keying-material-exporter": true, "keysize": true, "learn-address": true, "link-mtu": true,
+	"lladdr": true, "local": true, "log": true, "log-append": true, "lport": true,
+	"machine-readable-output": true, "management": true, "management-client": true,
+	"management-client-auth": true, "management-client-group": true,
+	"management-client-user": true, "management-external-cert": true,
+	"management-external-key": true, "management-forget-disconnect": true,
+	"management-hold": true, "management-log-cache": true, "management-query-passwords": true,
+	"management-query-proxy": true, "management-query-remote": true, "management-signal": true,
+	"management-up-down": true, "mark": true, "max-clients": true, "max-routes": true,
+	"max-routes-per-client": true, "mlock": true, "mode": true, "mssfix": true, "mtu-disc": true,
+	"mtu-test": true, "multihome": true, "mute": true, "mute-replay-warnings": true,
+	"ncp-ciphers": true, "ncp-disable": true, "nice": true, "no-iv": true, "no-replay": true,
+	"nobind": true, "ns-cert-type": true, "opt-verify": true, "passtos": true,
+	"peer-fingerprint": true, "persist-key": true, "persist-local-ip": true,
+	"persist-remote-ip": true, "persist-tun": true, "ping": true, "ping-exit": true,
+	"ping-restart": true, "ping-timer-rem": true, "pkcs11-cert-private": true, "pkcs11-id": true,
+	"pkcs11-id-management": true, "pkcs11-pin-cache": true, "pkcs11-private-mode": true,
+	"pkcs11-protected-authentication": true, "pkcs11-providers": true, "pkcs12": true,
+	"plugin": true, "port": true, "port-share": true, "proto": true, "proto-force": true,
+	"providers": true, "pull": true, "pull-filter": true, "push": true, "push-peer-info": true,
+	"push-remove": true, "push-reset": true, "rcvbuf": true, "redirect-gateway": true,
+	"redirect-private": true, "register-dns": true, "remap-usr1": true, "remote": true,
+	"remote-cert-eku": true, "remote-cert-ku": true, "remote-cert-tls": true,
+	"remote-random": true, "remote-random-hostname": true, "reneg-bytes": true,
+	"reneg-pkts": true, "reneg-sec": true, "replay-persist": true, "replay-window": true,
+	"resolv-retry": true, "route": true, "route-delay": true, "route-gateway": true,
+	"route-ipv6": true, "route-ipv6-gateway": true, "route-method": true, "route-metric": true,
+	"route-noexec": true, "route-nopull": true, "route-pre-down": true, "route-up": true,
+	"rport": true, "script-security": true, "secret": true, "server": true, "server-bridge": true,
+	"server-ipv6": true, "server-poll-timeout": true, "service": true, "session-timeout": true,
+	"setcon": true, "setenv": true, "setenv-safe": true, "shaper": true, "show-net-up": true,
+	"single-session": true, "sndbuf": true, "socket-flags": true, "socks-proxy": true,
+	"stale-routes-check": true, "static-challenge": true, "status": true, "status-version": true,
+	"suppress-timestamps": true, "syslog": true, "tap-sleep": true, "tcp-nodelay": true,
+	"tcp-queue-limit": true, "test-crypto": true, "tls-auth": true, "tls-cert-profile": true,
+	"tls-cipher": true, "tls-ciphersuites": true, "tls-client": true, "tls-crypt": true,
+	"tls-crypt-v2": true, "tls-crypt-v2-max-age": true, "tls-crypt-v2-verify": true,
+	"tls-exit": true, "tls-export-cert": true, "tls-groups": true, "tls-remote": true,
+	"tls-server": true, "tls-timeout": true, "tls-verify": true, "tls-version-max": true,
+	"tls-version-min": true, "tmp-dir": true, "topology": true, "tran-window": true,
+	"tun-ipv6": true, "tun-mtu": true, "tun-mtu-extra": true, "tun-mtu-max": true,
+	"txqueuelen": true, "up": true, "up-delay": true, "up-restart": true, "user": true,
+	"username-as-common-name": true, "verb": true, "verify-client-cert": true,
+	"verify-hash": true, "verify-x509-name": true, "version": true, "vlan-accept": true,
+	"vlan-pvid": true, "vlan-tagging": true, "win-sys": true, "windows-driver": true,
+	"writepid": true, "x509-track": true, "x509-username-field": true,
+}
@@ -0,0 +1,349 @@
+package profile
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxImportFile limita lo que se lee de cada archivo de un ZIP
+const maxImportFile = 1 << 20
+
+// Imported es un perfil importado al directorio de perfiles
+type Imported struct {
+	Path      string   // Copia dentro del directorio de perfiles
+	Source    string   // Archivo de origen ("bundle.zip:client.ovpn" si vino en un ZIP)
+	Inlined   []string // Directivas cuyos archivos se incorporaron como bloques inline
+	External  []string // Directivas que siguen apuntando a un archivo fuera del perfil
+	Duplicate bool     // Ya había un perfil con el mismo contenido; Path es ese
+}
+
+// inlinable son las directivas cuyo archivo se incorpora al perfil: el
+// material criptográfico y los archivos de credenciales, que OpenVPN también
+// acepta como bloques <auth-user-pass> y <http-proxy-user-pass>
+var inlinable = map[string]bool{
+	"ca": true, "cert": true, "key": true, "extra-certs": true, "pkcs12": true,
+	"dh": true, "crl-verify": true, "secret": true,
+	"tls-auth": true, "tls-crypt": true, "tls-crypt-v2": true,
+	"auth-user-pass": true, "http-proxy-user-pass": true,
+}
+
+// IsImportable indica si el archivo se puede importar (.ovpn, .conf o .zip)
+func IsImportable(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ovpn", ".conf", ".zip":
+		return true
+	}
+	return false
+}
+
+// Import copia a dir el perfil source (.ovpn o .conf) o los perfiles de un
+// ZIP. Los archivos que referencian ca, cert, key, tls-auth... se incorporan
+// como bloques inline, así la copia no depende de dónde estaba el original.
+// Las copias son 0600; si ya hay un perfil con el mismo contenido, se usa ese.
+func Import(source, dir string) ([]Imported, error) {
+	if strings.EqualFold(filepath.Ext(source), ".zip") {
+		return importZip(source, dir)
+	}
+	if !IsImportable(source) {
+		return nil, fmt.Errorf("%s no es un perfil de OpenVPN (.ovpn, .conf o .zip)", filepath.Base(source))
+	}
+
+	p, err := ParseFile(source)
+	if err != nil {
+		return nil, err
+	}
+	imported, err := importProfile(p, baseName(source), dir, func(ref string) ([]byte, error) {
+		return os.ReadFile(p.Resolve(ref))
+	})
+	if err != nil {
+		return nil, err
+	}
+	imported.Source = source
+	return []Imported{imported}, nil
+}
+
+// Copy guarda en dir una copia del perfil source llamada name, con sus
+// archivos incorporados como en Import. A diferencia de Import siempre crea
+// un archivo nuevo, aunque ya haya otro perfil con el mismo contenido.
+func Copy(source, dir, name string) (string, error) {
+	p, err := ParseFile(source)
+	if err != nil {
+		return "", err
+	}
+	if _, err := inlineFiles(p, func(ref string) ([]byte, error) {
+		return os.ReadFile(p.Resolve(ref))
+	}); err != nil {
+		return "", err
+	}
+	target, _, err := store(dir, baseName(name), p.Bytes(), false)
+	return target, err
+}
+
+// importZip importa los perfiles de un ZIP; los archivos referenciados se
+// buscan dentro del mismo ZIP
+func importZip(source, dir string) ([]Imported, error) {
+	r, err := zip.OpenReader(source)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir %s: %w", filepath.Base(source), err)
+	}
+	defer r.Close()
+
+	entries := make(map[string]*zip.File)
+	var profiles []*zip.File
+	for _, f := range r.File {
+		name := path.Clean(f.Name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+		entries[name] = f
+		if ext := strings.ToLower(path.Ext(name)); ext == ".ovpn" || ext == ".conf" {
+			profiles = append(profiles, f)
+		}
+	}
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("%s no contiene ningún perfil (.ovpn o .conf)", filepath.Base(source))
+	}
+
+	var imported []Imported
+	for _, f := range profiles {
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		p, err := ParseBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		// Con un solo perfil, el nombre del ZIP suele decir más que "client.ovpn"
+		name := baseName(f.Name)
+		if len(profiles) == 1 {
+			name = baseName(source)
+		}
+
+		profileDir := path.Dir(path.Clean(f.Name))
+		result, err := importProfile(p, name, dir, func(ref string) ([]byte, error) {
+			return readZipRef(entries, profileDir, ref)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		result.Source = source + ":" + f.Name
+		imported = append(imported, result)
+	}
+	return imported, nil
+}
+
+// readZipRef busca un archivo referenciado por un perfil del ZIP: junto al
+// perfil o, si la ruta es de otro equipo, por su nombre en todo el ZIP
+func readZipRef(entries map[string]*zip.File, profileDir, ref string) ([]byte, error) {
+	ref = strings.ReplaceAll(ref, "\\", "/") // Rutas de Windows
+	if f, ok := entries[path.Join(profileDir, ref)]; ok && !path.IsAbs(ref) {
+		return readZipFile(f)
+	}
+
+	var found *zip.File
+	for name, f := range entries {
+		if path.Base(name) == path.Base(ref) {
+			if found != nil {
+				return nil, fmt.Errorf("hay más de un %s en el ZIP", path.Base(ref))
+			}
+			found = f
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("el ZIP no contiene %s", ref)
+	}
+	return readZipFile(found)
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxImportFile+1))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo leer %s: %w", f.Name, err)
+	}
+	if len(data) > maxImportFile {
+		return nil, fmt.Errorf("%s es demasiado grande", f.Name)
+	}
+	return data, nil
+}
+
+// importProfile incorpora los archivos del perfil y lo guarda en dir
+func importProfile(p *Profile, name, dir string, read func(ref string) ([]byte, error)) (Imported, error) {
+	inlined, err := inlineFiles(p, read)
+	if err != nil {
+		return Imported{}, err
+	}
+
+	target, duplicate, err := store(dir, name, p.Bytes(), true)
+	if err != nil {
+		return Imported{}, err
+	}
+	return Imported{Path: target, Inlined: inlined, External: externalFiles(p), Duplicate: duplicate}, nil
+}
+
+// externalFiles retorna las directivas que referencian un archivo que OpenVPN
+// no acepta inline: la copia depende de que siga en la misma ruta
+func externalFiles(p *Profile) []string {
+	var external []string
+	for _, n := range p.Directives() {
+		var ref string
+		switch n.Name {
+		case "askpass":
+			ref = n.Arg(0)
+		case "http-proxy":
+			// El tercer argumento es el archivo de credenciales del proxy
+			if auth := n.Arg(2); auth != "auto" && auth != "auto-nct" {
+				ref = auth
+			}
+		}
+		if ref != "" && ref != "[inline]" && ref != "stdin" {
+			external = append(external, n.Name)
+		}
+	}
+	return external
+}
+
+// inlineFiles reemplaza las directivas que apuntan a material criptográfico
+// o a credenciales por bloques inline con el contenido del archivo
+func inlineFiles(p *Profile, read func(ref string) ([]byte, error)) ([]string, error) {
+	var inlined []string
+	for _, ref := range p.Files() {
+		n := ref.Node
+		if !inlinable[n.Name] || n.Name == "crl-verify" && n.Arg(1) == "dir" {
+			continue
+		}
+
+		data, err := read(ref.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: no se pudo leer %s: %w", n.Name, ref.Path, err)
+		}
+		content := string(data)
+		if n.Name == "pkcs12" {
+			// OpenVPN espera el PKCS#12 inline en base64
+			content = wrapBase64(data)
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+
+		nodes := []*Node{NewBlock(n.Name, content)}
+		// La dirección de la clave pasa a key-direction: inline no lleva argumentos
+		if (n.Name == "tls-auth" || n.Name == "secret") && n.Arg(1) != "" && p.Lookup("key-direction") == nil {
+			nodes = append(nodes, NewDirective("key-direction", n.Arg(1)))
+		}
+		p.Replace(n, nodes...)
+		inlined = append(inlined, n.Name)
+	}
+	return inlined, nil
+}
+
+// wrapBase64 codifica data en base64 en líneas de 64 caracteres
+func wrapBase64(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(encoded) > 64 {
+		b.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	b.WriteString(encoded + "\n")
+	return b.String()
+}
+
+// store guarda data en dir con permisos 0600. Con reuse, si ya hay un perfil
+// con el mismo contenido retorna ese; si el nombre está ocupado agrega un sufijo.
+func store(dir, name string, data []byte, reuse bool) (string, bool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", false, fmt.Errorf("no se pudo crear el directorio de perfiles: %w", err)
+	}
+
+	if reuse {
+		existing, err := findByHash(dir, sha256.Sum256(data))
+		if err != nil {
+			return "", false, err
+		}
+		if existing != "" {
+			return existing, true, nil
+		}
+	}
+
+	target := filepath.Join(dir, name+".ovpn")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(target); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		target = filepath.Join(dir, fmt.Sprintf("%s-%d.ovpn", name, i))
+	}
+
+	tmp, err := os.CreateTemp(dir, ".import-*")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return "", false, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", false, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", false, err
+	}
+	return target, false, nil
+}
+
+// findByHash busca en dir un perfil con ese contenido
+func findByHash(dir string, sum [sha256.Size]byte) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".ovpn" {
+			continue
+		}
+		existing := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(existing)
+		if err != nil {
+			continue
+		}
+		if sha256.Sum256(data) == sum {
+			return existing, nil
+		}
+	}
+	return "", nil
+}
+
+// baseName arma un nombre de archivo seguro a partir del nombre del origen
+func baseName(source string) string {
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(source)), path.Ext(source))
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r < ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimLeft(name, "."))
+	if name == "" {
+		return "perfil"
+	}
+	return name
+}
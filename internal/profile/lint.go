@@ -0,0 +1,223 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity es la gravedad de un hallazgo del linter, de mayor a menor
+type Severity int
+
+const (
+	// SeverityError impide que OpenVPN arranque (archivo que falta, directiva desconocida)
+	SeverityError Severity = iota
+	// SeverityDanger ejecuta código o escribe archivos como root
+	SeverityDanger
+	// SeveritySecurity debilita la seguridad de la conexión
+	SeveritySecurity
+	// SeverityDeprecated está obsoleto o eliminado en OpenVPN 2.6
+	SeverityDeprecated
+)
+
+// Finding es un problema encontrado en el perfil
+type Finding struct {
+	Severity  Severity
+	Line      int    // 0 si no corresponde a una línea
+	Directive string // Directiva o bloque; vacío si es del perfil completo
+	Message   string
+}
+
+func (f Finding) String() string {
+	if f.Line == 0 {
+		return f.Message
+	}
+	return fmt.Sprintf("línea %d: %s", f.Line, f.Message)
+}
+
+// HasSeverity indica si algún hallazgo tiene esa gravedad
+func HasSeverity(findings []Finding, severity Severity) bool {
+	for _, f := range findings {
+		if f.Severity == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// dangerousDirectives ejecutan comandos o cargan código. NavTunnel lanza
+// OpenVPN como root, así que un perfil con estas directivas corre lo que
+// quiera con privilegios de administrador.
+var dangerousDirectives = map[string]string{
+	"up":                    "ejecuta un comando como root al levantar el túnel",
+	"down":                  "ejecuta un comando como root al cerrar el túnel",
+	"route-up":              "ejecuta un comando como root al agregar las rutas",
+	"route-pre-down":        "ejecuta un comando como root antes de quitar las rutas",
+	"ipchange":              "ejecuta un comando como root cuando cambia la IP del servidor",
+	"tls-verify":            "ejecuta un comando como root para verificar el certificado",
+	"client-connect":        "ejecuta un comando como root",
+	"client-disconnect":     "ejecuta un comando como root",
+	"learn-address":         "ejecuta un comando como root",
+	"auth-user-pass-verify": "ejecuta un comando como root",
+	"iproute":               "reemplaza el comando ip por otro programa, que corre como root",
+	"plugin":                "carga una biblioteca dentro de OpenVPN, que corre como root",
+	"config":                "incluye otro archivo de configuración, que no se revisa",
+	"log":                   "escribe un archivo como root (puede sobrescribir cualquier archivo)",
+	"log-append":            "escribe un archivo como root (puede sobrescribir cualquier archivo)",
+	"writepid":              "escribe un archivo como root (puede sobrescribir cualquier archivo)",
+	"status":                "escribe un archivo como root (puede sobrescribir cualquier archivo)",
+}
+
+// deprecatedDirectives están obsoletas o ya no existen en OpenVPN 2.6
+var deprecatedDirectives = map[string]string{
+	"comp-lzo":     "comp-lzo está obsoleta; la compresión permite ataques como VORACLE",
+	"compress":     "la compresión está obsoleta; permite ataques como VORACLE",
+	"ns-cert-type": "ns-cert-type está obsoleta; usar remote-cert-tls server",
+	"ncp-ciphers":  "ncp-ciphers se renombró a data-ciphers",
+	"ncp-disable":  "ncp-disable ya no tiene efecto; usar data-ciphers",
+	"keysize":      "keysize se eliminó en OpenVPN 2.6",
+	"tls-remote":   "tls-remote se eliminó; usar verify-x509-name",
+	"key-method":   "key-method se eliminó en OpenVPN 2.5",
+	"no-iv":        "no-iv se eliminó en OpenVPN 2.5",
+	"max-routes":   "max-routes se eliminó en OpenVPN 2.4",
+	"tun-ipv6":     "tun-ipv6 ya no tiene efecto",
+	"no-replay":    "no-replay está obsoleta y desactiva la protección contra repetición",
+	"secret":       "el modo de clave estática (secret) está obsoleto; usar TLS",
+}
+
+// inlineBlocks son los bloques que acepta OpenVPN
+var inlineBlocks = map[string]bool{
+	"connection": true, "ca": true, "cert": true, "key": true, "extra-certs": true,
+	"pkcs12": true, "dh": true, "crl-verify": true, "secret": true, "tls-auth": true,
+	"tls-crypt": true, "tls-crypt-v2": true, "peer-fingerprint": true,
+	"auth-user-pass": true, "http-proxy-user-pass": true,
+}
+
+// weakCiphers son prefijos de cifrados con bloques de 64 bits (SWEET32)
+var weakCiphers = []string{"BF-", "DES-", "CAST5-", "RC2-", "DESX-"}
+
+// Lint revisa el perfil y retorna los hallazgos ordenados por gravedad y línea
+func Lint(p *Profile) []Finding {
+	var findings []Finding
+	add := func(severity Severity, n *Node, msg string) {
+		f := Finding{Severity: severity, Message: msg}
+		if n != nil {
+			f.Line = n.Line
+			f.Directive = n.Name
+		}
+		findings = append(findings, f)
+	}
+
+	// "setenv opt <directiva>" aplica la directiva igual que si estuviera
+	// sola; solo se ignora si OpenVPN no la conoce
+	var directives []*Node
+	optional := make(map[*Node]bool)
+	for _, n := range p.Directives() {
+		inner := unwrapSetenvOpt(n)
+		if inner != n {
+			optional[inner] = true
+		}
+		directives = append(directives, inner)
+	}
+
+	ignored := make(map[string]bool)
+	for _, n := range directives {
+		if n.Name == "ignore-unknown-option" {
+			for _, name := range n.Args {
+				ignored[name] = true
+			}
+		}
+	}
+
+	for _, n := range p.Nodes {
+		if n.Kind == Block && !inlineBlocks[n.Name] {
+			add(SeverityError, n, fmt.Sprintf("bloque <%s> desconocido", n.Name))
+		}
+	}
+
+	for _, n := range directives {
+		switch {
+		case dangerousDirectives[n.Name] != "":
+			add(SeverityDanger, n, n.Name+": "+dangerousDirectives[n.Name])
+		case deprecatedDirectives[n.Name] != "":
+			add(SeverityDeprecated, n, deprecatedDirectives[n.Name])
+		case !knownDirectives[n.Name] && !ignored[n.Name] && !optional[n]:
+			add(SeverityError, n, fmt.Sprintf("directiva desconocida: %s", n.Name))
+		}
+
+		switch n.Name {
+		case "script-security":
+			if level, err := strconv.Atoi(n.Arg(0)); err == nil && level >= 2 {
+				add(SeverityDanger, n, fmt.Sprintf("script-security %d permite que el perfil ejecute comandos como root", level))
+			}
+		case "cipher", "data-ciphers", "data-ciphers-fallback":
+			for _, cipher := range strings.Split(n.Arg(0), ":") {
+				if strings.EqualFold(cipher, "none") {
+					add(SeveritySecurity, n, n.Name+" none: el tráfico viaja sin cifrar")
+				} else if isWeakCipher(cipher) {
+					add(SeveritySecurity, n, fmt.Sprintf("%s usa %s, un cifrado débil (bloques de 64 bits)", n.Name, cipher))
+				}
+			}
+		case "auth":
+			if strings.EqualFold(n.Arg(0), "none") {
+				add(SeveritySecurity, n, "auth none: los paquetes no se autentican")
+			}
+		}
+	}
+
+	// Archivos referenciados que no existen
+	for _, ref := range p.Files() {
+		if p.Path == "" && !filepath.IsAbs(ref.Path) {
+			continue // Sin archivo no hay contra qué resolver la ruta
+		}
+		if _, err := os.Stat(p.Resolve(ref.Path)); err != nil {
+			add(SeverityError, ref.Node, fmt.Sprintf("%s: no se encontró el archivo %s", ref.Node.Name, ref.Path))
+		}
+	}
+
+	if len(p.Remotes()) == 0 {
+		add(SeverityError, nil, "el perfil no declara ningún servidor (remote)")
+	}
+
+	// Sin verificar el certificado del servidor, cualquier cliente de la
+	// misma CA puede hacerse pasar por él
+	tls := p.Lookup("client") != nil || p.Lookup("tls-client") != nil
+	if tls && !p.Has("secret") && p.Lookup("remote-cert-tls") == nil && p.Lookup("verify-x509-name") == nil &&
+		!p.Has("peer-fingerprint") {
+		add(SeveritySecurity, nil, "no se verifica el certificado del servidor: agregar remote-cert-tls server o verify-x509-name")
+	}
+
+	if cipher := p.Lookup("cipher"); cipher != nil && p.Lookup("data-ciphers") == nil && p.Lookup("ncp-ciphers") == nil {
+		add(SeverityDeprecated, cipher, "cipher sin data-ciphers: OpenVPN 2.6 ya no usa cipher para negociar el cifrado")
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity < findings[j].Severity
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// unwrapSetenvOpt retorna la directiva que envuelve "setenv opt", con la
+// línea del original; si no es un "setenv opt", retorna n
+func unwrapSetenvOpt(n *Node) *Node {
+	for n.Name == "setenv" && n.Arg(0) == "opt" && len(n.Args) > 1 {
+		n = &Node{Kind: Directive, Line: n.Line, Name: n.Args[1], Args: n.Args[2:]}
+	}
+	return n
+}
+
+func isWeakCipher(cipher string) bool {
+	upper := strings.ToUpper(cipher)
+	for _, prefix := range weakCiphers {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
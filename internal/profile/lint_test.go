@@ -0,0 +1,155 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lintText escribe el perfil en un directorio temporal (con ca.crt) y lo revisa
+func lintText(t *testing.T, text string) []Finding {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("MIIB\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "client.ovpn")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Lint(p)
+}
+
+// summary resume los hallazgos como "gravedad:línea:directiva"
+func summary(findings []Finding) string {
+	names := map[Severity]string{
+		SeverityError:      "error",
+		SeverityDanger:     "danger",
+		SeveritySecurity:   "security",
+		SeverityDeprecated: "deprecated",
+	}
+	var parts []string
+	for _, f := range findings {
+		parts = append(parts, names[f.Severity]+":"+strconv.Itoa(f.Line)+":"+f.Directive)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestLintCleanProfile(t *testing.T) {
+	findings := lintText(t, `client
+dev tun
+proto udp
+remote vpn.example.com 1194
+remote-cert-tls server
+data-ciphers AES-256-GCM:AES-128-GCM
+ca ca.crt
+<tls-crypt>
+MIIB
+</tls-crypt>
+auth-user-pass
+static-challenge "Enter OTP" 1
+`)
+	if len(findings) != 0 {
+		t.Errorf("perfil correcto con hallazgos: %v", findings)
+	}
+}
+
+func TestLint(t *testing.T) {
+	findings := lintText(t, `client
+dev tun
+remote vpn.example.com
+script-security 2
+up /etc/openvpn/update-resolv-conf
+plugin /usr/lib/openvpn/plugin.so
+comp-lzo
+cipher BF-CBC
+ns-cert-type server
+cert missing.crt
+foo-bar 1
+<bogus>
+x
+</bogus>
+`)
+	want := "error:10:cert error:11:foo-bar error:12:bogus " +
+		"danger:4:script-security danger:5:up danger:6:plugin " +
+		"security:0: security:8:cipher " +
+		"deprecated:7:comp-lzo deprecated:8:cipher deprecated:9:ns-cert-type"
+	if got := summary(findings); got != want {
+		t.Errorf("hallazgos:\n got: %s\nwant: %s", got, want)
+	}
+	if !HasSeverity(findings, SeverityDanger) {
+		t.Error("HasSeverity(SeverityDanger) = false")
+	}
+}
+
+func TestLintIgnoreUnknownOption(t *testing.T) {
+	findings := lintText(t, `client
+remote a
+remote-cert-tls server
+ignore-unknown-option block-outside-dns foo-bar
+foo-bar 1
+setenv opt baz
+setenv opt block-outside-dns
+`)
+	if len(findings) != 0 {
+		t.Errorf("opciones ignoradas con hallazgos: %v", findings)
+	}
+}
+
+func TestLintSetenvOpt(t *testing.T) {
+	// OpenVPN aplica las directivas que conoce aunque vengan tras "setenv opt"
+	findings := lintText(t, `client
+remote a
+remote-cert-tls server
+setenv opt script-security 2
+setenv opt up /tmp/x.sh
+setenv opt comp-lzo
+setenv FOO bar
+`)
+	if got, want := summary(findings), "danger:4:script-security danger:5:up deprecated:6:comp-lzo"; got != want {
+		t.Errorf("hallazgos = %s, se esperaba %s", got, want)
+	}
+}
+
+func TestLintProfileWithoutRemote(t *testing.T) {
+	findings := lintText(t, "client\nremote-cert-tls server\n")
+	if summary(findings) != "error:0:" {
+		t.Errorf("hallazgos = %v, se esperaba que faltara remote", findings)
+	}
+}
+
+func TestLintNoEncryption(t *testing.T) {
+	findings := lintText(t, "remote a\nverify-x509-name vpn.example.com name\ncipher none\nauth none\ndata-ciphers AES-256-GCM\n")
+	if got, want := summary(findings), "security:3:cipher security:4:auth"; got != want {
+		t.Errorf("hallazgos = %s, se esperaba %s", got, want)
+	}
+}
+
+func TestLintServerVerification(t *testing.T) {
+	tests := []struct {
+		name   string
+		verify string
+		want   string
+	}{
+		{"sin verificación", "", "security:0:"},
+		{"remote-cert-tls", "remote-cert-tls server\n", ""},
+		{"verify-x509-name", "verify-x509-name vpn.example.com name\n", ""},
+		{"peer-fingerprint en una línea", "peer-fingerprint AB:CD:EF\n", ""},
+		{"bloque peer-fingerprint", "<peer-fingerprint>\nAB:CD:EF\n12:34:56\n</peer-fingerprint>\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := lintText(t, "client\nremote vpn.example.com\n"+tt.verify)
+			if got := summary(findings); got != tt.want {
+				t.Errorf("hallazgos = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package profile
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Valores por defecto de OpenVPN
+const (
+	DefaultPort  = 1194
+	DefaultProto = "udp"
+)
+
+// Remote es un servidor VPN declarado con remote
+type Remote struct {
+	Host  string
+	Port  int    // El del remote, o el de port (1194 por defecto)
+	Proto string // Como en el perfil: "udp", "tcp-client", "udp6"...
+	Node  *Node  // Directiva que lo declara
+}
+
+// Transport retorna "udp" o "tcp"
+func (r Remote) Transport() string {
+	return Transport(r.Proto)
+}
+
+// Transport reduce "udp4", "tcp-client", "tcp6-client"... a "udp" o "tcp"
+func Transport(proto string) string {
+	if strings.HasPrefix(strings.ToLower(proto), "tcp") {
+		return "tcp"
+	}
+	return "udp"
+}
+
+// StaticChallenge es la directiva static-challenge
+//
+//	static-challenge "Enter OTP" 1
+type StaticChallenge struct {
+	Text string
+	Echo bool
+}
+
+// Auth describe cómo se autentica el perfil
+type Auth struct {
+	// UserPass indica que OpenVPN pide usuario y contraseña (auth-user-pass)
+	UserPass bool
+
+	// UserPassFile es el archivo de auth-user-pass, si se indicó;
+	// "[inline]" si las credenciales están en un bloque <auth-user-pass>
+	UserPassFile string
+
+	// Challenge es el static-challenge (OTP) que se pide junto con la contraseña
+	Challenge *StaticChallenge
+
+	// Certificate indica que el perfil trae un certificado de cliente
+	// (cert/key o pkcs12, en archivos o inline)
+	Certificate bool
+}
+
+// FileRef es un archivo externo al que apunta una directiva
+type FileRef struct {
+	Node *Node  // Directiva que lo referencia
+	Path string // Ruta como figura en el perfil
+}
+
+// fileDirectives son las directivas cuyo primer argumento es un archivo que
+// también puede ir inline
+var fileDirectives = map[string]bool{
+	"ca":                   true,
+	"cert":                 true,
+	"key":                  true,
+	"pkcs12":               true,
+	"dh":                   true,
+	"extra-certs":          true,
+	"crl-verify":           true,
+	"tls-auth":             true,
+	"tls-crypt":            true,
+	"tls-crypt-v2":         true,
+	"secret":               true,
+	"http-proxy-user-pass": true,
+	"auth-user-pass":       true,
+}
+
+// IsFileDirective indica si la directiva referencia un archivo que OpenVPN
+// también acepta como bloque inline (<ca>, <key>...)
+func IsFileDirective(name string) bool {
+	return fileDirectives[name]
+}
+
+// Directives retorna todas las directivas, incluidas las de los bloques
+// <connection>, en el orden del archivo
+func (p *Profile) Directives() []*Node {
+	var all []*Node
+	for _, n := range p.Nodes {
+		switch {
+		case n.Kind == Directive:
+			all = append(all, n)
+		case n.IsConnection():
+			for _, c := range n.Children {
+				if c.Kind == Directive {
+					all = append(all, c)
+				}
+			}
+		}
+	}
+	return all
+}
+
+// Lookup retorna la directiva global (fuera de <connection>) con ese
+// nombre. Si se repite, la última, que es la que aplica OpenVPN.
+func (p *Profile) Lookup(name string) *Node {
+	return lookup(p.Nodes, name)
+}
+
+func lookup(nodes []*Node, name string) *Node {
+	var found *Node
+	for _, n := range nodes {
+		if n.Kind == Directive && n.Name == name {
+			found = n
+		}
+	}
+	return found
+}
+
+// Has indica si el perfil declara la directiva global o su bloque inline
+func (p *Profile) Has(name string) bool {
+	return p.Lookup(name) != nil || p.Inline(name) != nil
+}
+
+// Inline retorna el bloque inline global con ese nombre, o nil
+func (p *Profile) Inline(name string) *Node {
+	var found *Node
+	for _, n := range p.Nodes {
+		if n.Kind == Block && n.Name == name && !n.IsConnection() {
+			found = n
+		}
+	}
+	return found
+}
+
+// Connections retorna los bloques <connection>
+func (p *Profile) Connections() []*Node {
+	var blocks []*Node
+	for _, n := range p.Nodes {
+		if n.IsConnection() {
+			blocks = append(blocks, n)
+		}
+	}
+	return blocks
+}
+
+// Proto retorna el protocolo global (proto), "udp" por defecto
+func (p *Profile) Proto() string {
+	if n := p.Lookup("proto"); n != nil && n.Arg(0) != "" {
+		return n.Arg(0)
+	}
+	return DefaultProto
+}
+
+// Port retorna el puerto global (port o rport), 1194 por defecto
+func (p *Profile) Port() int {
+	return portOf(p.Nodes, DefaultPort)
+}
+
+func portOf(nodes []*Node, fallback int) int {
+	port := fallback
+	for _, n := range nodes {
+		if n.Kind == Directive && (n.Name == "port" || n.Name == "rport") {
+			if value, err := strconv.Atoi(n.Arg(0)); err == nil {
+				port = value
+			}
+		}
+	}
+	return port
+}
+
+// Remotes retorna los servidores del perfil en orden, los globales y los de
+// cada <connection>. El puerto y el protocolo que no indica el remote se
+// toman del bloque o, si no, de las directivas globales.
+func (p *Profile) Remotes() []Remote {
+	port, proto := p.Port(), p.Proto()
+
+	var remotes []Remote
+	collect := func(nodes []*Node, port int, proto string) {
+		for _, n := range nodes {
+			if n.Kind != Directive || n.Name != "remote" || n.Arg(0) == "" {
+				continue
+			}
+			r := Remote{Host: n.Arg(0), Port: port, Proto: proto, Node: n}
+			if value, err := strconv.Atoi(n.Arg(1)); err == nil {
+				r.Port = value
+			}
+			if n.Arg(2) != "" {
+				r.Proto = n.Arg(2)
+			}
+			remotes = append(remotes, r)
+		}
+	}
+
+	for _, n := range p.Nodes {
+		switch {
+		case n.Kind == Directive:
+			collect([]*Node{n}, port, proto)
+		case n.IsConnection():
+			blockProto := proto
+			if d := lookup(n.Children, "proto"); d != nil && d.Arg(0) != "" {
+				blockProto = d.Arg(0)
+			}
+			collect(n.Children, portOf(n.Children, port), blockProto)
+		}
+	}
+	return remotes
+}
+
+// Dev retorna el dispositivo del túnel (dev), "" si no se declara
+func (p *Profile) Dev() string {
+	if n := p.Lookup("dev"); n != nil {
+		return n.Arg(0)
+	}
+	return ""
+}
+
+// DevType retorna "tun", "tap" o "null": el de dev-type o, si no, el que se deduce
+// del nombre del dispositivo. "" si no se puede saber.
+func (p *Profile) DevType() string {
+	if n := p.Lookup("dev-type"); n != nil && n.Arg(0) != "" {
+		return n.Arg(0)
+	}
+	dev := p.Dev()
+	for _, t := range []string{"tun", "tap", "null"} {
+		if strings.HasPrefix(dev, t) {
+			return t
+		}
+	}
+	return ""
+}
+
+// Auth retorna el modo de autenticación del perfil
+func (p *Profile) Auth() Auth {
+	var auth Auth
+	if n := p.Lookup("auth-user-pass"); n != nil {
+		auth.UserPass = true
+		auth.UserPassFile = n.Arg(0)
+	}
+	if p.Inline("auth-user-pass") != nil {
+		auth.UserPass = true
+		auth.UserPassFile = "[inline]"
+	}
+	if n := p.Lookup("static-challenge"); n != nil && len(n.Args) > 0 {
+		auth.Challenge = &StaticChallenge{Text: n.Arg(0), Echo: n.Arg(1) == "1"}
+	}
+	auth.Certificate = p.Has("cert") && p.Has("key") || p.Has("pkcs12")
+	return auth
+}
+
+// Files retorna los archivos externos que referencia el perfil, en orden.
+// Se omiten los que se indican como "[inline]".
+func (p *Profile) Files() []FileRef {
+	var refs []FileRef
+	for _, n := range p.Directives() {
+		if !IsFileDirective(n.Name) {
+			continue
+		}
+		path := n.Arg(0)
+		if path == "" || path == "[inline]" || path == "stdin" {
+			continue
+		}
+		refs = append(refs, FileRef{Node: n, Path: path})
+	}
+	return refs
+}
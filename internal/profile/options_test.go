@@ -0,0 +1,189 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRemotes(t *testing.T) {
+	p, err := ParseBytes([]byte(`client
+remote 203.0.113.1
+remote 203.0.113.2 443 tcp-client
+<ca>
+remote 198.51.100.1 1194
+</ca>
+<connection>
+remote 2001:db8::1 1195 udp6
+</connection>
+<connection>
+remote vpn.example.com
+port 8443
+proto tcp
+</connection>
+port 1200
+proto udp4
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type remote struct {
+		Host, Proto, Transport string
+		Port                   int
+	}
+	var got []remote
+	for _, r := range p.Remotes() {
+		got = append(got, remote{r.Host, r.Proto, r.Transport(), r.Port})
+	}
+	want := []remote{
+		{"203.0.113.1", "udp4", "udp", 1200},
+		{"203.0.113.2", "tcp-client", "tcp", 443},
+		{"2001:db8::1", "udp6", "udp", 1195},
+		{"vpn.example.com", "tcp", "tcp", 8443},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("remotes:\n got: %+v\nwant: %+v", got, want)
+	}
+	if p.Proto() != "udp4" || p.Port() != 1200 {
+		t.Errorf("proto/port globales = %s/%d", p.Proto(), p.Port())
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	p, err := ParseBytes([]byte("client\nremote vpn.example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Proto() != DefaultProto || p.Port() != DefaultPort {
+		t.Errorf("proto/port = %s/%d, se esperaba %s/%d", p.Proto(), p.Port(), DefaultProto, DefaultPort)
+	}
+	if p.Dev() != "" || p.DevType() != "" {
+		t.Errorf("dev = %q (%q), se esperaba vacío", p.Dev(), p.DevType())
+	}
+	if auth := p.Auth(); !reflect.DeepEqual(auth, Auth{}) {
+		t.Errorf("auth = %+v, se esperaba vacío", auth)
+	}
+}
+
+func TestDevType(t *testing.T) {
+	tests := map[string]string{
+		"dev tun\n":                "tun",
+		"dev tun0\n":               "tun",
+		"dev tap\n":                "tap",
+		"dev vpn0\ndev-type tap\n": "tap",
+		"dev vpn0\n":               "",
+	}
+	for text, want := range tests {
+		p, err := ParseBytes([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.DevType(); got != want {
+			t.Errorf("DevType(%q) = %q, se esperaba %q", text, got, want)
+		}
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Auth
+	}{
+		{
+			"usuario y OTP",
+			"auth-user-pass\nstatic-challenge \"Enter OTP\" 1\n",
+			Auth{UserPass: true, Challenge: &StaticChallenge{Text: "Enter OTP", Echo: true}},
+		},
+		{
+			"archivo de credenciales",
+			"auth-user-pass creds.txt\nstatic-challenge 'Código' 0\n",
+			Auth{UserPass: true, UserPassFile: "creds.txt", Challenge: &StaticChallenge{Text: "Código"}},
+		},
+		{
+			"credenciales inline",
+			"<auth-user-pass>\nana\nsecreto\n</auth-user-pass>\n",
+			Auth{UserPass: true, UserPassFile: "[inline]"},
+		},
+		{
+			"certificado inline y en archivo",
+			"cert client.crt\n<key>\nMIIE\n</key>\n",
+			Auth{Certificate: true},
+		},
+		{
+			"pkcs12",
+			"pkcs12 client.p12\nauth-user-pass\n",
+			Auth{UserPass: true, Certificate: true},
+		},
+		{
+			"certificado sin clave",
+			"cert client.crt\n",
+			Auth{},
+		},
+	}
+	for _, tt := range tests {
+		p, err := ParseBytes([]byte(tt.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Auth(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: auth = %+v, se esperaba %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "client.ovpn")
+	text := `client
+ca ca.crt
+cert /etc/openvpn/client.crt
+key "my key.pem"
+tls-auth ta.key 1
+tls-crypt [inline]
+auth-user-pass
+auth-user-pass stdin
+up /etc/openvpn/up.sh
+<connection>
+remote a
+http-proxy-user-pass proxy.txt
+</connection>
+`
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, ref := range p.Files() {
+		got = append(got, ref.Node.Name+"="+p.Resolve(ref.Path))
+	}
+	want := []string{
+		"ca=" + filepath.Join(dir, "ca.crt"),
+		"cert=/etc/openvpn/client.crt",
+		"key=" + filepath.Join(dir, "my key.pem"),
+		"tls-auth=" + filepath.Join(dir, "ta.key"),
+		"http-proxy-user-pass=" + filepath.Join(dir, "proxy.txt"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("archivos:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestLookupUsesLastDirective(t *testing.T) {
+	p, err := ParseBytes([]byte("proto udp\n<connection>\nproto tcp\n</connection>\nproto tcp-client\nverb 3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := p.Lookup("proto"); n == nil || n.Line != 5 {
+		t.Errorf("Lookup(proto) = %+v, se esperaba la línea 5", n)
+	}
+	if got := len(p.Directives()); got != 4 {
+		t.Errorf("Directives() = %d directivas, se esperaban 4", got)
+	}
+}
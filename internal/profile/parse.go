@@ -0,0 +1,240 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError es un error de sintaxis del perfil
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("línea %d: %s", e.Line, e.Msg)
+}
+
+// ParseBytes lee el perfil de data
+func ParseBytes(data []byte) (*Profile, error) {
+	p := &parser{lines: splitLines(string(data))}
+	nodes, _, err := p.nodes("")
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{Nodes: nodes, newline: "\n"}
+	for _, line := range p.lines {
+		if strings.HasSuffix(line, "\r\n") {
+			profile.newline = "\r\n"
+			break
+		}
+		if strings.HasSuffix(line, "\n") {
+			break
+		}
+	}
+	return profile, nil
+}
+
+// splitLines separa el texto en líneas que conservan su final de línea
+func splitLines(text string) []string {
+	var lines []string
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+type parser struct {
+	lines []string
+	pos   int
+}
+
+// nodes lee nodos hasta el final o hasta la etiqueta de cierre </until>,
+// que retorna junto con los nodos
+func (p *parser) nodes(until string) ([]*Node, string, error) {
+	var nodes []*Node
+	start := p.pos
+
+	for p.pos < len(p.lines) {
+		raw := p.lines[p.pos]
+		p.pos++
+		line := p.pos
+		text := lineText(raw)
+		trimmed := strings.TrimSpace(text)
+
+		switch {
+		case trimmed == "":
+			nodes = append(nodes, &Node{Kind: Blank, Line: line, raw: raw})
+
+		case trimmed[0] == '#' || trimmed[0] == ';':
+			nodes = append(nodes, &Node{Kind: Comment, Line: line, raw: raw})
+
+		case closeTag(trimmed) != "":
+			name := closeTag(trimmed)
+			if name != until {
+				return nil, "", &ParseError{Line: line, Msg: fmt.Sprintf("</%s> sin <%s>", name, name)}
+			}
+			return nodes, raw, nil
+
+		case openTag(trimmed) != "":
+			block := &Node{Kind: Block, Line: line, Name: openTag(trimmed), raw: raw}
+			var err error
+			if block.IsConnection() {
+				if until != "" {
+					return nil, "", &ParseError{Line: line, Msg: "<connection> dentro de otro bloque"}
+				}
+				block.Children, block.close, err = p.nodes("connection")
+			} else {
+				block.Content, block.close, err = p.content(block.Name)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, block)
+
+		default:
+			fields, err := splitLine(text)
+			if err != nil {
+				return nil, "", &ParseError{Line: line, Msg: err.Error()}
+			}
+			if len(fields) == 0 {
+				nodes = append(nodes, &Node{Kind: Comment, Line: line, raw: raw})
+				continue
+			}
+			nodes = append(nodes, &Node{
+				Kind: Directive,
+				Line: line,
+				Name: directiveName(fields[0]),
+				Args: fields[1:],
+				raw:  raw,
+			})
+		}
+	}
+
+	if until != "" {
+		return nil, "", &ParseError{Line: start, Msg: fmt.Sprintf("falta </%s>", until)}
+	}
+	return nodes, "", nil
+}
+
+// content lee el texto de un bloque inline hasta su etiqueta de cierre
+func (p *parser) content(name string) (string, string, error) {
+	start := p.pos
+	var content strings.Builder
+
+	for p.pos < len(p.lines) {
+		raw := p.lines[p.pos]
+		p.pos++
+		if closeTag(raw) == name {
+			return content.String(), raw, nil
+		}
+		content.WriteString(raw)
+	}
+	return "", "", &ParseError{Line: start, Msg: fmt.Sprintf("falta </%s>", name)}
+}
+
+// bom es la marca de orden de bytes con que algunos editores empiezan el archivo
+const bom = "\ufeff"
+
+// lineText quita el final de línea y la marca de orden de bytes
+func lineText(raw string) string {
+	return strings.TrimPrefix(strings.TrimRight(raw, "\r\n"), bom)
+}
+
+// openTag retorna el nombre de una etiqueta de apertura (<ca>), o ""
+func openTag(line string) string {
+	line = strings.TrimSpace(lineText(line))
+	if len(line) < 3 || line[0] != '<' || line[len(line)-1] != '>' || line[1] == '/' {
+		return ""
+	}
+	return tagName(line[1 : len(line)-1])
+}
+
+// closeTag retorna el nombre de una etiqueta de cierre (</ca>), o ""
+func closeTag(line string) string {
+	line = strings.TrimSpace(lineText(line))
+	if len(line) < 4 || !strings.HasPrefix(line, "</") || line[len(line)-1] != '>' {
+		return ""
+	}
+	return tagName(line[2 : len(line)-1])
+}
+
+// tagName valida el nombre de una etiqueta: letras, dígitos y guiones
+func tagName(name string) string {
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return ""
+		}
+	}
+	return name
+}
+
+// directiveName quita el "--" opcional con que OpenVPN acepta las directivas
+func directiveName(field string) string {
+	if len(field) > 2 && strings.HasPrefix(field, "--") {
+		return field[2:]
+	}
+	return field
+}
+
+// splitLine separa una línea en tokens como OpenVPN: respeta comillas dobles
+// (con escapes) y simples (literales), y corta en un comentario (# o ;) que
+// empiece un token
+func splitLine(line string) ([]string, error) {
+	var (
+		fields  []string
+		current strings.Builder
+		quote   rune
+		escaped bool
+		inToken bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case (r == '#' || r == ';') && !inToken:
+			return fields, nil
+		case r == ' ' || r == '\t':
+			if inToken {
+				fields = append(fields, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("falta cerrar las comillas (%c)", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("barra invertida al final de la línea")
+	}
+	if inToken {
+		fields = append(fields, current.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,223 @@
+package profile
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"remote vpn.example.com 1194 udp", []string{"remote", "vpn.example.com", "1194", "udp"}},
+		{"  remote\tvpn.example.com   443  ", []string{"remote", "vpn.example.com", "443"}},
+		{`static-challenge "Enter OTP code" 1`, []string{"static-challenge", "Enter OTP code", "1"}},
+		{`static-challenge 'Código: \n' 0`, []string{"static-challenge", `Código: \n`, "0"}},
+		{`auth-user-pass "C:\\Users\\ana\\pass.txt"`, []string{"auth-user-pass", `C:\Users\ana\pass.txt`}},
+		{`setenv FRIENDLY_NAME "Oficina \"central\""`, []string{"setenv", "FRIENDLY_NAME", `Oficina "central"`}},
+		{`ca my\ ca.crt`, []string{"ca", "my ca.crt"}},
+		{`push "route 10.0.0.0 255.0.0.0"`, []string{"push", "route 10.0.0.0 255.0.0.0"}},
+		{"verb 3 # nivel de log", []string{"verb", "3"}},
+		{"verb 3 ; nivel de log", []string{"verb", "3"}},
+		{"setenv PASS abc#def", []string{"setenv", "PASS", "abc#def"}},
+		{`setenv EMPTY ""`, []string{"setenv", "EMPTY", ""}},
+		{"# solo un comentario", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitLine(tt.line)
+		if err != nil {
+			t.Errorf("splitLine(%q): %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitLine(%q) = %q, se esperaba %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSplitLineErrors(t *testing.T) {
+	for _, line := range []string{`static-challenge "Enter OTP 1`, `ca 'ca.crt`, `ca ca.crt\`} {
+		if _, err := splitLine(line); err == nil {
+			t.Errorf("splitLine(%q) no retornó error", line)
+		}
+	}
+}
+
+const sample = `# Perfil de ejemplo
+client
+dev tun
+proto udp
+
+--remote vpn1.example.com 1194
+remote "vpn2.example.com" 443 tcp-client ; respaldo
+auth-user-pass
+static-challenge "Enter OTP" 1
+
+<connection>
+remote vpn3.example.com
+proto tcp
+</connection>
+
+<ca>
+-----BEGIN CERTIFICATE-----
+MIIB
+-----END CERTIFICATE-----
+</ca>
+key client.key
+`
+
+func TestParseNodes(t *testing.T) {
+	p, err := ParseBytes([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type node struct {
+		Kind Kind
+		Line int
+		Name string
+		Args []string
+	}
+	var got []node
+	for _, n := range p.Nodes {
+		got = append(got, node{n.Kind, n.Line, n.Name, n.Args})
+	}
+	want := []node{
+		{Comment, 1, "", nil},
+		{Directive, 2, "client", []string{}},
+		{Directive, 3, "dev", []string{"tun"}},
+		{Directive, 4, "proto", []string{"udp"}},
+		{Blank, 5, "", nil},
+		{Directive, 6, "remote", []string{"vpn1.example.com", "1194"}},
+		{Directive, 7, "remote", []string{"vpn2.example.com", "443", "tcp-client"}},
+		{Directive, 8, "auth-user-pass", []string{}},
+		{Directive, 9, "static-challenge", []string{"Enter OTP", "1"}},
+		{Blank, 10, "", nil},
+		{Block, 11, "connection", nil},
+		{Blank, 15, "", nil},
+		{Block, 16, "ca", nil},
+		{Directive, 21, "key", []string{"client.key"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodos:\n got: %+v\nwant: %+v", got, want)
+	}
+
+	conn := p.Nodes[10]
+	if len(conn.Children) != 2 || conn.Children[0].Name != "remote" || conn.Children[1].Line != 13 {
+		t.Errorf("hijos de <connection>: %+v", conn.Children)
+	}
+	ca := p.Inline("ca")
+	wantCA := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	if ca == nil || ca.Content != wantCA {
+		t.Errorf("contenido de <ca> = %q, se esperaba %q", ca.Content, wantCA)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{"bloque sin cerrar", "client\n<ca>\nMIIB\n", 2},
+		{"connection sin cerrar", "client\n\n<connection>\nremote a\n", 3},
+		{"cierre sin apertura", "client\n</ca>\n", 2},
+		{"cierre de otro bloque", "<connection>\nremote a\n</ca>\n", 3},
+		{"connection anidado", "<connection>\n<connection>\n</connection>\n</connection>\n", 2},
+		{"comillas sin cerrar", "client\nstatic-challenge \"OTP 1\n", 2},
+	}
+	for _, tt := range tests {
+		_, err := ParseBytes([]byte(tt.text))
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%s: error = %v, se esperaba *ParseError", tt.name, err)
+			continue
+		}
+		if perr.Line != tt.line {
+			t.Errorf("%s: línea %d, se esperaba %d (%v)", tt.name, perr.Line, tt.line, err)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	inputs := map[string]string{
+		"ejemplo":              sample,
+		"vacío":                "",
+		"sin salto final":      "client\nremote vpn.example.com",
+		"crlf":                 "client\r\nremote a 1194\r\n<ca>\r\nMIIB\r\n</ca>\r\n",
+		"bom":                  "\ufeffclient\nremote a\n",
+		"bom con bloque":       "\ufeff<ca>\nMIIB\n</ca>\n",
+		"espacios y tabs":      "  client  \n\tremote   a\t1194   # comentario\n   \n",
+		"comillas":             "static-challenge 'Enter OTP' 1\nsetenv X \"a \\\"b\\\"\"\n",
+		"etiquetas indentadas": "  <ca>  \nMIIB\n  </ca>\n",
+		"bloque vacío":         "<tls-crypt>\n</tls-crypt>\n",
+		"solo comentarios":     "# a\n; b\n",
+	}
+	for name, input := range inputs {
+		p, err := ParseBytes([]byte(input))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := string(p.Bytes()); got != input {
+			t.Errorf("%s: la serialización cambió el perfil\n got: %q\nwant: %q", name, got, input)
+		}
+	}
+}
+
+func TestEditSerialization(t *testing.T) {
+	p, err := ParseBytes([]byte("client\r\nremote a 1194 # principal\r\nca ca.crt\r\nverb 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modificar una directiva regenera solo esa línea, con el final de línea del archivo
+	remote := p.Lookup("remote")
+	remote.Args[1] = "443"
+	// Reemplazar una directiva por un bloque inline
+	if !p.Replace(p.Lookup("ca"), NewBlock("ca", "MIIB\r\n")) {
+		t.Fatal("Replace no encontró la directiva ca")
+	}
+	// Agregar al final de un archivo sin salto final
+	p.Nodes = append(p.Nodes, NewDirective("setenv", "FRIENDLY_NAME", `Oficina "central"`))
+
+	want := "client\r\nremote a 443\r\n<ca>\r\nMIIB\r\n</ca>\r\nverb 3\r\nsetenv FRIENDLY_NAME \"Oficina \\\"central\\\"\"\r\n"
+	got := string(p.Bytes())
+	if got != want {
+		t.Errorf("serialización:\n got: %q\nwant: %q", got, want)
+	}
+
+	// Lo generado se vuelve a leer igual
+	again, err := ParseBytes([]byte(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args := again.Lookup("setenv").Args; !reflect.DeepEqual(args, []string{"FRIENDLY_NAME", `Oficina "central"`}) {
+		t.Errorf("setenv releído = %q", args)
+	}
+	if block := again.Inline("ca"); block == nil || block.Content != "MIIB\r\n" {
+		t.Errorf("<ca> releído = %+v", block)
+	}
+
+	// Eliminar un nodo
+	if !again.Replace(again.Lookup("verb")) || strings.Contains(string(again.Bytes()), "verb") {
+		t.Error("Replace sin nodos no eliminó la directiva")
+	}
+}
+
+func TestEditInsideConnection(t *testing.T) {
+	p, err := ParseBytes([]byte("<connection>\nremote a\n</connection>\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := p.Connections()[0]
+	if !p.Replace(conn.Children[0], NewDirective("remote", "b", "443", "tcp")) {
+		t.Fatal("Replace no encontró el remote del bloque")
+	}
+	if got, want := string(p.Bytes()), "<connection>\nremote b 443 tcp\n</connection>\n"; got != want {
+		t.Errorf("serialización = %q, se esperaba %q", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+// Package profile lee y escribe perfiles de OpenVPN (.ovpn).
+//
+// El perfil se guarda como una lista de nodos (directivas, comentarios,
+// líneas vacías y bloques) que conservan su texto original: si no se
+// modifica, Bytes retorna exactamente lo que se leyó.
+package profile
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kind es el tipo de un nodo del perfil
+type Kind int
+
+const (
+	Blank     Kind = iota // Línea vacía
+	Comment               // Comentario (# o ;)
+	Directive             // Directiva con sus argumentos
+	Block                 // Bloque inline (<ca>...</ca>) o <connection>
+)
+
+// Node es un elemento del perfil
+type Node struct {
+	Kind Kind
+	Line int    // Línea donde empieza (desde 1); 0 si el nodo es nuevo
+	Name string // Nombre de la directiva (sin "--") o etiqueta del bloque
+
+	// Args son los argumentos de la directiva, ya sin comillas
+	Args []string
+
+	// Content es el texto de un bloque inline, con sus finales de línea
+	Content string
+
+	// Children son las directivas de un bloque <connection>
+	Children []*Node
+
+	raw   string // Texto original de la línea (o de la etiqueta de apertura)
+	close string // Etiqueta de cierre original de un bloque
+}
+
+// NewDirective crea una directiva para agregar al perfil
+func NewDirective(name string, args ...string) *Node {
+	return &Node{Kind: Directive, Name: name, Args: args}
+}
+
+// NewBlock crea un bloque inline (<name>content</name>)
+func NewBlock(name, content string) *Node {
+	return &Node{Kind: Block, Name: name, Content: content}
+}
+
+// IsConnection indica si el nodo es un bloque <connection>
+func (n *Node) IsConnection() bool {
+	return n.Kind == Block && n.Name == "connection"
+}
+
+// Arg retorna el argumento i de la directiva, o "" si no existe
+func (n *Node) Arg(i int) string {
+	if i < len(n.Args) {
+		return n.Args[i]
+	}
+	return ""
+}
+
+// Profile es un perfil de OpenVPN
+type Profile struct {
+	// Path es el archivo del que se leyó; vacío si se leyó de otra fuente
+	Path  string
+	Nodes []*Node
+
+	newline string // Final de línea de las líneas nuevas ("\n" o "\r\n")
+}
+
+// ParseFile lee el perfil de un archivo
+func ParseFile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParseBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	p.Path = path
+	return p, nil
+}
+
+// Parse lee el perfil de r
+func Parse(r io.Reader) (*Profile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBytes(data)
+}
+
+// Dir retorna el directorio del perfil, contra el que se resuelven las rutas relativas
+func (p *Profile) Dir() string {
+	if p.Path == "" {
+		return ""
+	}
+	return filepath.Dir(p.Path)
+}
+
+// Resolve retorna la ruta de un archivo referenciado por el perfil
+func (p *Profile) Resolve(path string) string {
+	if filepath.IsAbs(path) || p.Path == "" {
+		return path
+	}
+	return filepath.Join(p.Dir(), path)
+}
+
+// Bytes serializa el perfil. Los nodos sin cambios se escriben tal como se
+// leyeron; los nuevos o modificados, con el formato de OpenVPN.
+func (p *Profile) Bytes() []byte {
+	var buf bytes.Buffer
+	p.writeNodes(&buf, p.Nodes)
+	return buf.Bytes()
+}
+
+// WriteTo escribe el perfil serializado en w
+func (p *Profile) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(p.Bytes())
+	return int64(n), err
+}
+
+// Replace cambia el nodo old (en cualquier nivel) por nodes; sin nodes lo
+// elimina. Retorna false si old no está en el perfil.
+func (p *Profile) Replace(old *Node, nodes ...*Node) bool {
+	return replaceNode(&p.Nodes, old, nodes)
+}
+
+func replaceNode(list *[]*Node, old *Node, nodes []*Node) bool {
+	for i, n := range *list {
+		if n == old {
+			rest := append(append([]*Node{}, nodes...), (*list)[i+1:]...)
+			*list = append((*list)[:i], rest...)
+			return true
+		}
+		if n.IsConnection() && replaceNode(&n.Children, old, nodes) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Profile) writeNodes(buf *bytes.Buffer, nodes []*Node) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case Block:
+			p.writeLine(buf, n.raw, "<"+n.Name+">", n.raw != "" && openTag(n.raw) == n.Name)
+			if n.IsConnection() {
+				p.writeNodes(buf, n.Children)
+			} else if n.Content != "" {
+				p.ensureNewline(buf)
+				buf.WriteString(n.Content)
+			}
+			p.writeLine(buf, n.close, "</"+n.Name+">", n.close != "" && closeTag(n.close) == n.Name)
+		case Directive:
+			p.writeLine(buf, n.raw, formatDirective(n), n.raw != "" && sameDirective(n))
+		default:
+			p.writeLine(buf, n.raw, "", true)
+		}
+	}
+}
+
+// writeLine escribe el texto original si sigue valiendo o la línea generada
+func (p *Profile) writeLine(buf *bytes.Buffer, raw, generated string, useRaw bool) {
+	p.ensureNewline(buf)
+	if useRaw {
+		buf.WriteString(raw)
+		return
+	}
+	buf.WriteString(generated)
+	buf.WriteString(p.lineEnding())
+}
+
+// ensureNewline termina la última línea escrita si quedó abierta (la última
+// línea de un archivo sin salto final, seguida ahora de nodos nuevos)
+func (p *Profile) ensureNewline(buf *bytes.Buffer) {
+	if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteString(p.lineEnding())
+	}
+}
+
+func (p *Profile) lineEnding() string {
+	if p.newline == "" {
+		return "\n"
+	}
+	return p.newline
+}
+
+// sameDirective indica si el texto original todavía corresponde a la directiva
+func sameDirective(n *Node) bool {
+	fields, err := splitLine(lineText(n.raw))
+	if err != nil || len(fields) == 0 || directiveName(fields[0]) != n.Name {
+		return false
+	}
+	args := fields[1:]
+	if len(args) != len(n.Args) {
+		return false
+	}
+	for i := range args {
+		if args[i] != n.Args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatDirective arma la línea de una directiva, con comillas donde hagan falta
+func formatDirective(n *Node) string {
+	parts := []string{n.Name}
+	for _, arg := range n.Args {
+		parts = append(parts, quoteArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteArg pone entre comillas dobles un argumento con espacios, comillas,
+// barras invertidas o caracteres de comentario
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\#;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
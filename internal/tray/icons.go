@@ -17,6 +17,9 @@ var IconConnectedData []byte
 //go:embed icons/error.png
 var IconErrorData []byte
 
+//go:embed icons/degraded.png
+var IconDegradedData []byte
+
 // GetIconData devuelve los bytes del icono según el tipo
 func GetIconData(iconType IconType) []byte {
 	switch iconType {
@@ -28,6 +31,8 @@ func GetIconData(iconType IconType) []byte {
 		return IconConnectedData
 	case IconError:
 		return IconErrorData
+	case IconDegraded:
+		return IconDegradedData
 	default:
 		return IconDisconnectedData
 	}
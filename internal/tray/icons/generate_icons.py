@@ -37,4 +37,7 @@ if __name__ == '__main__':
     # Rojo para error
     create_circle_icon('error.png', (220, 20, 20, 255))
 
+    # Amarillo para conectado sin respuesta (chequeo de salud)
+    create_circle_icon('degraded.png', (255, 215, 0, 255))
+
     print("\n✅ All icons generated successfully!")
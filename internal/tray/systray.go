@@ -14,9 +14,17 @@ type Systray struct {
 	// Menu items
 	mStatus      *systray.MenuItem
 	mConnect     *systray.MenuItem
+	mProfiles    *systray.MenuItem
 	mDisconnect  *systray.MenuItem
 	mShowWindow  *systray.MenuItem
+	mCopyIP      *systray.MenuItem
 	mQuit        *systray.MenuItem
+	mQuitKeep    *systray.MenuItem
+
+	// Submenú de perfiles: un item por perfil; los que sobran se ocultan
+	profileItems []*systray.MenuItem
+	profiles     []ProfileItem
+	active       bool
 
 	currentState string
 	currentIcon  IconType
@@ -59,16 +67,22 @@ func (s *Systray) onReady() {
 	systray.AddSeparator()
 
 	s.mConnect = systray.AddMenuItem("Conectar", "Conectar a la VPN")
+	s.mProfiles = systray.AddMenuItem("Conectar perfil", "Conectar a un perfil VPN concreto")
 	s.mDisconnect = systray.AddMenuItem("Desconectar", "Desconectar de la VPN")
 	s.mDisconnect.Disable() // Inicialmente deshabilitado
+	s.updateProfileItems()
 
 	systray.AddSeparator()
 
 	s.mShowWindow = systray.AddMenuItem("Abrir ventana", "Mostrar ventana principal")
+	s.mCopyIP = systray.AddMenuItem("Copiar IP del túnel", "Copiar al portapapeles la IP asignada por la VPN")
+	s.mCopyIP.Disable() // Solo con la VPN conectada
 
 	systray.AddSeparator()
 
 	s.mQuit = systray.AddMenuItem("Salir", "Cerrar NavTunnel")
+	s.mQuitKeep = systray.AddMenuItem("Salir sin desconectar", "Cerrar NavTunnel dejando la VPN conectada")
+	s.mQuitKeep.Disable() // Solo con la VPN conectada
 
 	// Iniciar goroutines para manejar clics
 	go s.handleMenuActions()
@@ -93,13 +107,81 @@ func (s *Systray) handleMenuActions() {
 				s.callbacks.OnShowWindow()
 			}
 
+		case <-s.mCopyIP.ClickedCh:
+			if s.callbacks.OnCopyTunnelIP != nil {
+				s.callbacks.OnCopyTunnelIP()
+			}
+
 		case <-s.mQuit.ClickedCh:
 			if s.callbacks.OnQuit != nil {
 				s.callbacks.OnQuit()
 			}
 			systray.Quit()
 			return
+
+		case <-s.mQuitKeep.ClickedCh:
+			if s.callbacks.OnQuitKeepTunnel != nil {
+				s.callbacks.OnQuitKeepTunnel()
+			}
+			systray.Quit()
+			return
+		}
+	}
+}
+
+// handleProfileClicks conecta el perfil que ocupa la posición index del submenú
+func (s *Systray) handleProfileClicks(index int, item *systray.MenuItem) {
+	for range item.ClickedCh {
+		s.mu.RLock()
+		var id string
+		if index < len(s.profiles) {
+			id = s.profiles[index].ID
+		}
+		s.mu.RUnlock()
+
+		if id != "" && s.callbacks.OnConnectProfile != nil {
+			s.callbacks.OnConnectProfile(id)
+		}
+	}
+}
+
+// SetProfiles actualiza los perfiles del submenú "Conectar perfil".
+// Se puede llamar antes de que el tray esté listo.
+func (s *Systray) SetProfiles(profiles []ProfileItem) {
+	s.mu.Lock()
+	s.profiles = append([]ProfileItem(nil), profiles...)
+	s.mu.Unlock()
+
+	s.updateProfileItems()
+}
+
+// updateProfileItems refleja los perfiles en el submenú, reutilizando los
+// items ya creados (systray no permite borrarlos)
+func (s *Systray) updateProfileItems() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mProfiles == nil {
+		return // El tray aún no está listo; onReady los agrega
+	}
+	for i, p := range s.profiles {
+		if i == len(s.profileItems) {
+			item := s.mProfiles.AddSubMenuItem(p.Name, "Conectar a "+p.Name)
+			s.profileItems = append(s.profileItems, item)
+			go s.handleProfileClicks(i, item)
 		}
+		s.profileItems[i].SetTitle(p.Name)
+		s.profileItems[i].SetTooltip("Conectar a " + p.Name)
+		s.profileItems[i].Show()
+	}
+	for _, item := range s.profileItems[len(s.profiles):] {
+		item.Hide()
+	}
+
+	if len(s.profiles) == 0 || s.active {
+		s.mProfiles.Disable()
+	} else {
+		s.mProfiles.Enable()
 	}
 }
 
@@ -126,11 +208,14 @@ func (s *Systray) SetTooltip(text string) {
 	systray.SetTooltip(text)
 }
 
-// UpdateState actualiza el estado visible en el menú
-func (s *Systray) UpdateState(state string, connected bool) {
+// UpdateState actualiza el estado visible en el menú.
+// active indica que hay una sesión que se puede desconectar (o cancelar).
+func (s *Systray) UpdateState(state string, active bool) {
 	s.mu.Lock()
 	s.currentState = state
+	s.active = active
 	s.mu.Unlock()
+	s.updateProfileItems()
 
 	// Actualizar texto del menú de estado
 	if s.mStatus != nil {
@@ -143,7 +228,7 @@ func (s *Systray) UpdateState(state string, connected bool) {
 	// Habilitar/deshabilitar botones según estado
 	// Solo si los items del menú están inicializados
 	if s.mConnect != nil && s.mDisconnect != nil {
-		if connected {
+		if active {
 			s.mConnect.Disable()
 			s.mDisconnect.Enable()
 		} else {
@@ -153,6 +238,21 @@ func (s *Systray) UpdateState(state string, connected bool) {
 	}
 }
 
+// SetConnected habilita las acciones que solo tienen sentido con la VPN
+// conectada ("Copiar IP del túnel" y "Salir sin desconectar")
+func (s *Systray) SetConnected(connected bool) {
+	for _, item := range []*systray.MenuItem{s.mCopyIP, s.mQuitKeep} {
+		if item == nil {
+			continue
+		}
+		if connected {
+			item.Enable()
+		} else {
+			item.Disable()
+		}
+	}
+}
+
 // Quit cierra el tray icon
 func (s *Systray) Quit() {
 	systray.Quit()
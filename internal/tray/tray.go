@@ -28,6 +28,7 @@ const (
 	IconConnecting
 	IconConnected
 	IconError
+	IconDegraded // Conectado pero el chequeo de salud falla
 )
 
 // MenuCallbacks contiene los callbacks para las acciones del menú
@@ -36,6 +37,21 @@ type MenuCallbacks struct {
 	OnDisconnect func()
 	OnShowWindow func()
 	OnQuit       func()
+
+	// OnQuitKeepTunnel cierra la aplicación dejando OpenVPN conectado
+	OnQuitKeepTunnel func()
+
+	// OnCopyTunnelIP copia al portapapeles la IP asignada al túnel
+	OnCopyTunnelIP func()
+
+	// OnConnectProfile conecta directamente el perfil con ese ID
+	OnConnectProfile func(id string)
+}
+
+// ProfileItem es un perfil del submenú "Conectar perfil"
+type ProfileItem struct {
+	ID   string
+	Name string
 }
 
 // New crea una nueva instancia de TrayIcon
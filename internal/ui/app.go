@@ -3,13 +3,18 @@ package ui
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/lavp2393/navtunnel/internal/config"
 	"github.com/lavp2393/navtunnel/internal/core"
+	"github.com/lavp2393/navtunnel/internal/history"
 	"github.com/lavp2393/navtunnel/internal/logs"
+	"github.com/lavp2393/navtunnel/internal/profile"
 	"github.com/lavp2393/navtunnel/internal/tray"
 
 	"fyne.io/fyne/v2"
@@ -20,22 +25,15 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-// AppState representa el estado de la aplicación
-type AppState int
-
-const (
-	StateDisconnected AppState = iota
-	StateConnecting
-	StateAuthenticating
-	StateConnected
-	StateError
-)
+// quitStopTimeout es cuánto se espera a que OpenVPN se detenga al salir de la aplicación
+const quitStopTimeout = 20 * time.Second
 
 // App representa la aplicación principal
 type App struct {
 	fyneApp   fyne.App
 	window    fyne.Window
-	state     AppState
+	state     core.State // Estado de la sesión según la máquina de estados de core
+	prevState core.State
 	logBuffer *logs.Buffer
 	trayIcon  *tray.Systray
 	config    *config.Config
@@ -48,13 +46,45 @@ type App struct {
 	connectBtn    *widget.Button
 	disconnectBtn *widget.Button
 	retryBtn      *widget.Button
-	changeFileBtn *widget.Button
+	settingsBtn   *widget.Button
 	logView       *widget.Entry
 	configStatus  *widget.Label
+	profileList   *widget.List
+	webAuthDialog dialog.Dialog
+
+	// Texto de la reconexión en curso (intento y cuenta regresiva)
+	reconnectStatus  string
+	reconnectAttempt int
+
+	// Estadísticas de tráfico de la sesión conectada
+	statsLabel *widget.Label
+	statsStop  chan struct{}
+	statsMutex sync.Mutex
+	healthText string // Último resultado del chequeo de salud
+
+	// Panel de detalles de la conexión
+	detailsPanel *widget.Accordion
+	detailsLabel *widget.Label
+	routesLabel  *widget.Label
+
+	// Historial de conexiones y registro de la sesión en curso
+	history        *history.Store
+	record         *history.Record
+	recordedStats  core.Stats // Última muestra de tráfico sumada al registro
+	recordBaseline bool       // La próxima muestra solo marca desde dónde sumar
+	recordMutex    sync.Mutex
 
 	// Core components
-	manager *core.Manager
-	sendFns core.SendFns
+	session        *core.Supervisor
+	sessionProfile string // Perfil de la sesión activa
+	sendFns        core.SendFns
+
+	// Suspensión y bloqueo de la sesión del usuario (ver power.go)
+	sessionMonitor core.SessionMonitor
+	powerMutex     sync.Mutex
+	resumeProfile  string // Perfil a reconectar al volver de la suspensión
+	unlockProfile  string // Perfil a reconectar al desbloquear la pantalla
+	promptNotify   bool   // Avisar con una notificación si la reconexión pide credenciales
 
 	// Credentials cache (in-memory for current session)
 	savedUsername string
@@ -68,24 +98,27 @@ func NewApp() *App {
 	a := &App{
 		fyneApp:   app.New(),
 		logBuffer: logs.NewBuffer(30),
-		state:     StateDisconnected,
+		state:     core.StateIdle,
 	}
 
 	// Cargar o crear configuración
-	cfg, err := config.Load()
-	if err != nil {
-		if errors.Is(err, config.ErrConfigNotFound) {
-			// Primera ejecución - crear configuración por defecto
-			cfg = config.Default()
-		} else {
-			// Error cargando configuración
-			cfg = config.Default()
+	cfg, configErr := config.Load()
+	if configErr != nil {
+		// Primera ejecución o error: se usa la configuración por defecto; el
+		// error se informa cuando la ventana está lista
+		cfg = config.Default()
+		if errors.Is(configErr, config.ErrConfigNotFound) {
+			configErr = nil
 		}
 	}
 	a.config = cfg
 
+	if path, err := history.DefaultPath(); err == nil {
+		a.history = history.Open(path)
+	}
+
 	a.window = a.fyneApp.NewWindow("NavTunnel")
-	a.window.Resize(fyne.NewSize(700, 500))
+	a.window.Resize(fyne.NewSize(900, 520))
 
 	// Configurar comportamiento al cerrar: minimizar a tray en vez de salir
 	a.window.SetCloseIntercept(func() {
@@ -95,15 +128,53 @@ func NewApp() *App {
 	a.buildUI()
 	a.initializeStoredCredentials()
 	a.setupTrayIcon()
+	a.refreshProfiles()
+	a.watchSession()
+
+	if configErr != nil {
+		a.reportConfigError(configErr)
+	}
+
+	// Una sesión que quedó corriendo tiene prioridad sobre la configuración inicial
+	if a.checkOrphanSessions() {
+		return a
+	}
+	a.checkKillSwitch()
 
-	// Si no hay archivo .ovpn configurado, mostrar file picker
-	if !a.config.HasVPNConfig() || !a.config.IsVPNConfigValid() {
+	// Si no hay ningún perfil VPN, mostrar file picker
+	if configErr == nil && len(a.config.VPNProfiles) == 0 {
 		a.showWelcomeDialog()
 	}
 
 	return a
 }
 
+// reportConfigError avisa que no se pudo cargar la configuración. Un
+// config.json dañado se aparta (sin borrarlo) para empezar de cero; uno de
+// una versión más nueva se deja intacto y Save se niega a sobrescribirlo.
+func (a *App) reportConfigError(err error) {
+	a.addLog("Error al cargar la configuración: " + err.Error())
+
+	var parseErr *config.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		moved, moveErr := config.MoveAside()
+		if moveErr != nil {
+			a.addLog("No se pudo apartar la configuración dañada: " + moveErr.Error())
+			ShowError(a.window, "Configuración dañada", fmt.Sprintf("No se pudo leer la configuración:\n%s\n\nLos cambios no se guardarán hasta que se corrija o se borre el archivo.", err))
+			return
+		}
+		a.addLog("Configuración dañada guardada en " + moved)
+		ShowError(a.window, "Configuración dañada", fmt.Sprintf("No se pudo leer la configuración:\n%s\n\nSe guardó una copia en:\n%s\n\nNavTunnel empieza con la configuración por defecto.", err, moved))
+
+	case errors.Is(err, config.ErrNewerVersion):
+		ShowError(a.window, "Configuración de una versión más nueva", "La configuración la guardó una versión más nueva de NavTunnel. Se usa la configuración por defecto y los cambios no se guardarán; actualiza NavTunnel para recuperar tus perfiles.")
+
+	default:
+		ShowError(a.window, "Error", "No se pudo cargar la configuración:\n"+err.Error())
+	}
+}
+
 // setupTrayIcon configura el icono de system tray
 func (a *App) setupTrayIcon() {
 	callbacks := tray.MenuCallbacks{
@@ -119,10 +190,23 @@ func (a *App) setupTrayIcon() {
 			a.window.RequestFocus()
 		},
 		OnQuit: func() {
-			// Desconectar si está conectado
-			if a.manager != nil {
-				a.manager.Stop()
+			// Desconectar si está conectado, esperando (con límite) a que
+			// OpenVPN deshaga el túnel
+			select {
+			case <-a.endSession(history.EndUser):
+			case <-time.After(quitStopTimeout):
 			}
+			a.disableKillSwitch()
+			a.fyneApp.Quit()
+		},
+		OnCopyTunnelIP: a.copyTunnelIP,
+		OnConnectProfile: func(id string) {
+			a.window.Show()
+			a.connectProfile(id)
+		},
+		OnQuitKeepTunnel: func() {
+			// La VPN sigue conectada y se retoma en el próximo arranque
+			a.detachSession()
 			a.fyneApp.Quit()
 		},
 	}
@@ -137,6 +221,12 @@ func (a *App) buildUI() {
 	a.statusLabel = widget.NewLabel("Estado: Desconectado")
 	a.statusLabel.Wrapping = fyne.TextWrapWord
 
+	// Traffic stats (solo con la VPN conectada)
+	a.statsLabel = widget.NewLabel("")
+
+	// Configuración de red (solo con la VPN conectada)
+	details := a.buildDetailsPanel()
+
 	// Config status
 	a.configStatus = widget.NewLabel("")
 
@@ -147,19 +237,15 @@ func (a *App) buildUI() {
 
 	a.retryBtn = widget.NewButton("Reintentar", func() {
 		a.updateConfigStatus()
-		if a.config.IsVPNConfigValid() {
+		if p := a.config.Current(); p != nil && p.Exists() {
 			a.connectBtn.Enable()
 			a.retryBtn.Hide()
 		}
 	})
 	a.retryBtn.Hide()
 
-	a.changeFileBtn = widget.NewButton("Cambiar archivo VPN", func() {
-		a.showFilePicker()
-	})
-
-	// Actualizar estado del config después de crear todos los widgets
-	a.updateConfigStatus()
+	a.settingsBtn = widget.NewButton("Ajustes del perfil", a.showProfileSettings)
+	historyBtn := widget.NewButton("Historial", a.showHistoryWindow)
 
 	// Log view (read-only)
 	a.logView = widget.NewMultiLineEntry()
@@ -171,7 +257,8 @@ func (a *App) buildUI() {
 		a.connectBtn,
 		a.disconnectBtn,
 		a.retryBtn,
-		a.changeFileBtn,
+		a.settingsBtn,
+		historyBtn,
 	)
 
 	content := container.NewBorder(
@@ -180,6 +267,8 @@ func (a *App) buildUI() {
 			widget.NewSeparator(),
 			a.configStatus,
 			a.statusLabel,
+			a.statsLabel,
+			details,
 			buttonBox,
 			widget.NewSeparator(),
 			widget.NewLabel("Logs:"),
@@ -190,7 +279,11 @@ func (a *App) buildUI() {
 		container.NewScroll(a.logView),
 	)
 
-	a.window.SetContent(content)
+	// Lista de perfiles a la izquierda
+	split := container.NewHSplit(a.buildProfilesPanel(), content)
+	split.Offset = 0.25
+
+	a.window.SetContent(split)
 }
 
 // initializeStoredCredentials intenta recuperar credenciales guardadas y actualiza el estado interno
@@ -219,43 +312,53 @@ func (a *App) initializeStoredCredentials() {
 	a.addLog("Advertencia: No se pudieron cargar credenciales guardadas: " + err.Error())
 }
 
-// updateConfigStatus actualiza el estado del archivo de configuración
+// updateConfigStatus actualiza el estado del perfil seleccionado
 func (a *App) updateConfigStatus() {
-	if a.config.IsVPNConfigValid() {
-		fileName := filepath.Base(a.config.VPNConfigPath)
-		a.configStatus.SetText(fmt.Sprintf("✅ Archivo VPN: %s", fileName))
-		a.connectBtn.Enable()
-		a.retryBtn.Hide()
-		a.changeFileBtn.Show()
-	} else if a.config.HasVPNConfig() {
-		// Tiene configurado pero el archivo no existe
-		fileName := filepath.Base(a.config.VPNConfigPath)
-		a.configStatus.SetText(fmt.Sprintf("❌ Archivo no encontrado: %s", fileName))
+	p := a.config.Current()
+	switch {
+	case p == nil:
+		// No hay perfiles
+		a.configStatus.SetText("⚠️  No hay perfiles VPN: importa un archivo .ovpn")
 		a.connectBtn.Disable()
-		a.retryBtn.Hide()
-		a.changeFileBtn.Show()
-	} else {
-		// No hay archivo configurado
-		a.configStatus.SetText("⚠️  No hay archivo VPN configurado")
+	case p.Exists():
+		a.configStatus.SetText(fmt.Sprintf("✅ Perfil: %s (%s)", p.Name, filepath.Base(p.Path)))
+		if a.currentSession() == nil {
+			a.connectBtn.Enable()
+		}
+	default:
+		// El perfil existe pero su archivo no
+		a.configStatus.SetText(fmt.Sprintf("❌ Archivo no encontrado: %s", filepath.Base(p.Path)))
 		a.connectBtn.Disable()
-		a.retryBtn.Hide()
-		a.changeFileBtn.Show()
 	}
+	a.retryBtn.Hide()
 }
 
-// onConnect maneja el evento de conectar
+// onConnect maneja el evento de conectar con el perfil seleccionado
 func (a *App) onConnect() {
-	// Verificar que exista el config
-	if !a.config.IsVPNConfigValid() {
+	// Verificar que exista el perfil y su archivo
+	p := a.config.Current()
+	if p == nil || !p.Exists() {
 		ShowError(a.window, "Error", "No se encontró el archivo de configuración VPN. Por favor selecciona un archivo.")
 		a.showFilePicker()
 		return
 	}
 
-	a.addLog("Iniciando conexión VPN...")
+	configPath := p.Path
+	if !a.profileApproved(configPath) {
+		// Se vuelve a revisar: con la política de bloqueo se rechaza, si no
+		// se pide la confirmación y se conecta al aceptarla
+		a.addLog("El perfil no se aceptó en su versión actual: se revisa de nuevo")
+		a.reviewProfile(configPath, func(accepted bool) {
+			if !accepted {
+				a.addLog("Conexión cancelada")
+				return
+			}
+			a.onConnect()
+		})
+		return
+	}
 
-	// Obtener la ruta del config
-	configPath := a.config.VPNConfigPath
+	a.addLog("Iniciando conexión VPN...")
 
 	// Buscar el binario de OpenVPN
 	openvpnPath, err := core.FindOpenVPN()
@@ -267,57 +370,210 @@ func (a *App) onConnect() {
 
 	a.addLog(fmt.Sprintf("Usando OpenVPN: %s", openvpnPath))
 
-	// Iniciar el manager directamente (sin Management Interface)
-	// El manager se encarga de lanzar OpenVPN con pipes directos
-	mgr, err := core.Start(configPath, openvpnPath)
-	if err != nil {
+	// Iniciar la sesión: lanza OpenVPN, se conecta a su Management Interface
+	// y reconecta automáticamente si el túnel se cae
+	session := core.NewSupervisor(configPath, openvpnPath, a.reconnectPolicy())
+	session.SetOptions(a.sessionOptions(configPath))
+	if err := session.Start(); err != nil {
 		a.addLog("Error al iniciar OpenVPN: " + err.Error())
 		ShowError(a.window, "Error", err.Error())
 		return
 	}
 
-	a.manager = mgr
-	a.sendFns = mgr.SendFunctions()
+	a.runSession(session, configPath, time.Now(), false)
+	a.addLog("Esperando prompts de autenticación...")
+
+	a.config.UseProfile(p.ID)
+	if err := a.config.Save(); err != nil {
+		a.addLog("Error al guardar configuración: " + err.Error())
+	}
+}
+
+// runSession muestra en la UI una sesión ya iniciada y procesa sus eventos
+func (a *App) runSession(session *core.Supervisor, configPath string, start time.Time, resumed bool) {
+	a.setSession(session, configPath)
+	a.updateSleepDelay()
+	a.sendFns = session.SendFunctions()
+	a.beginRecord(configPath, start, resumed)
 
 	// Actualizar UI
-	a.setState(StateConnecting)
+	a.setState(session.State())
 	a.connectBtn.Disable()
 	a.disconnectBtn.Enable()
 
-	a.addLog("Esperando prompts de autenticación...")
-
 	// Iniciar procesamiento de eventos
-	go a.handleEvents()
+	go a.handleEvents(session)
+}
+
+// reconnectPolicy construye la política de reconexión a partir de la configuración
+func (a *App) reconnectPolicy() core.ReconnectPolicy {
+	policy := core.DefaultReconnectPolicy()
+	if a.config.Reconnect.MaxAttempts > 0 {
+		policy.MaxAttempts = a.config.Reconnect.MaxAttempts
+	}
+	if a.config.Reconnect.Disabled {
+		policy.MaxAttempts = 0
+	}
+	return policy
+}
+
+// sessionOptions traduce las preferencias del perfil a opciones de la sesión
+func (a *App) sessionOptions(profilePath string) core.SessionOptions {
+	var options core.SessionOptions
+	settings := a.config.SettingsFor(profilePath)
+	switch settings.DNS {
+	case config.DNSOff:
+		options.DNS = core.DNSOff
+	case config.DNSGlobal:
+		options.DNS = core.DNSGlobal
+	default:
+		options.DNS = core.DNSSplit
+	}
+	options.Routes = routeRules(settings.Routes)
+	options.KillSwitch = core.KillSwitchOptions{
+		Enabled:  settings.KillSwitch,
+		AllowLAN: settings.KillSwitchAllowLAN,
+	}
+	options.Health = healthCheck(settings.Health)
+	return options
 }
 
-// onDisconnect maneja el evento de desconectar
+// onDisconnect maneja el evento de desconectar. Solo una desconexión
+// pedida por el usuario quita el kill switch.
 func (a *App) onDisconnect() {
+	done := a.endSession(history.EndUser)
+	go func() {
+		<-done
+		a.disableKillSwitch()
+	}()
+}
+
+// endSession termina la sesión y la registra en el historial con el motivo dado.
+// OpenVPN se detiene en segundo plano para no bloquear la UI; el canal
+// retornado se cierra cuando terminó.
+func (a *App) endSession(reason history.EndReason) <-chan struct{} {
+	done := make(chan struct{})
+
+	session := a.takeSession()
+	if session == nil {
+		close(done)
+		return done
+	}
+	a.updateSleepDelay()
+
 	a.addLog("Desconectando...")
-	// Set state immediately to prevent race conditions in callbacks
-	a.setState(StateDisconnected)
 
-	// El manager se encarga de matar el proceso OpenVPN cuando se llama Stop()
-	if a.manager != nil {
-		a.manager.Stop()
-		a.addLog("Proceso OpenVPN detenido")
-		a.manager = nil
+	if a.statsRunning() {
+		a.recordTraffic(session.Stats())
 	}
+	a.finishRecord(reason)
 
-	a.connectBtn.Enable()
+	a.hideWebAuthDialog()
+	a.stopStatsUpdates()
+	a.hideConnectionInfo()
 	a.disconnectBtn.Disable()
+	a.setState(core.StateDisconnecting)
+
+	// La sesión se encarga de detener OpenVPN y cancelar reconexiones.
+	// Su estado final (Idle o Failed) es el que se muestra.
+	go func() {
+		defer close(done)
+
+		if err := session.Stop(); err != nil {
+			a.addLog("Advertencia: " + err.Error())
+			ShowError(a.window, "OpenVPN sigue en ejecución", err.Error())
+		} else {
+			a.addLog("Proceso OpenVPN detenido")
+		}
+		a.setState(session.State())
+		a.connectBtn.Enable()
+	}()
+
+	return done
+}
+
+// setSession registra la sesión activa y su perfil
+func (a *App) setSession(session *core.Supervisor, profile string) {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+	a.session = session
+	a.sessionProfile = profile
+}
+
+// activeProfile retorna el perfil de la sesión activa (vacío si no hay)
+func (a *App) activeProfile() string {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+	if a.session == nil {
+		return ""
+	}
+	return a.sessionProfile
+}
+
+// currentSession retorna la sesión activa (nil si no hay)
+func (a *App) currentSession() *core.Supervisor {
+	a.stateMutex.RLock()
+	defer a.stateMutex.RUnlock()
+	return a.session
 }
 
-// handleEvents procesa los eventos del manager
-func (a *App) handleEvents() {
-	for event := range a.manager.Events() {
+// takeSession retira la sesión activa: a partir de aquí sus eventos se ignoran
+func (a *App) takeSession() *core.Supervisor {
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+	session := a.session
+	a.session = nil
+	return session
+}
+
+// handleEvents procesa los eventos de la sesión
+func (a *App) handleEvents(session *core.Supervisor) {
+	for event := range session.Events() {
+		// Una sesión que se está cerrando ya no controla la UI
+		if a.currentSession() != session {
+			if event.Type == core.EventLogLine {
+				a.addLog(event.Message)
+			}
+			continue
+		}
+
 		switch event.Type {
+		case core.EventStateChanged:
+			if event.State == core.StateDegraded {
+				a.updateRecord(func(r *history.Record) { r.Degraded++ })
+			}
+			a.setState(event.State)
+
+		case core.EventHealth:
+			a.setHealth(event.Health)
+
+		case core.EventHealthRestart:
+			a.updateRecord(func(r *history.Record) { r.HealthRestarts++ })
+
 		case core.EventLogLine:
 			a.addLog(event.Message)
 
 		case core.EventAskUser:
-			a.setState(StateAuthenticating)
+			a.notifyPrompt("La VPN necesita tus credenciales para reconectar")
+			if event.Challenge != "" {
+				// static-challenge: pedir usuario, contraseña y OTP juntos
+				ShowStaticChallengePrompt(a.window, a.savedUsername, a.savedPassword, a.rememberCreds, event.Challenge, event.Echo, func(result CredentialsResult) {
+					if !a.getState().AwaitingCredentials() {
+						return // Abort if state changed
+					}
+					a.savedUsername = result.Username
+					a.savedPassword = result.Password
+					a.rememberCreds = result.Remember
+					a.persistCredentials()
+
+					if err := a.sendFns.Credentials(result.Username, result.Password, result.OTP); err != nil {
+						a.addLog("Error al enviar credenciales: " + err.Error())
+					}
+				})
+				continue
+			}
 			ShowUsernamePromptWithRemember(a.window, a.savedUsername, a.rememberCreds, func(result PromptResult) {
-				if a.getState() != StateAuthenticating {
+				if !a.getState().AwaitingCredentials() {
 					return // Abort if state changed (e.g., disconnected)
 				}
 				a.savedUsername = result.Value
@@ -330,32 +586,13 @@ func (a *App) handleEvents() {
 			})
 
 		case core.EventAskPass:
-			a.setState(StateAuthenticating)
+			a.notifyPrompt("La VPN necesita tu contraseña para reconectar")
 			ShowPasswordPromptWithDefault(a.window, a.savedPassword, func(password string) {
-				if a.getState() != StateAuthenticating {
+				if !a.getState().AwaitingCredentials() {
 					return // Abort if state changed
 				}
 				a.savedPassword = password
-
-				if a.rememberCreds {
-					method, warning, err := core.SaveCredentials(a.savedUsername, a.savedPassword)
-					if err != nil {
-						a.addLog("Advertencia: No se pudieron guardar las credenciales: " + err.Error())
-					} else {
-						a.credStore = method
-						a.logCredentialSave(method)
-						if warning != "" {
-							a.addLog("Aviso: " + warning)
-						}
-					}
-				} else {
-					if err := core.DeleteCredentials(); err != nil {
-						a.addLog("Advertencia: No se pudieron eliminar credenciales guardadas: " + err.Error())
-					}
-					a.savedUsername = ""
-					a.savedPassword = ""
-					a.credStore = core.CredentialStoreMethodNone
-				}
+				a.persistCredentials()
 
 				if err := a.sendFns.Password(password); err != nil {
 					a.addLog("Error al enviar contraseña: " + err.Error())
@@ -363,9 +600,9 @@ func (a *App) handleEvents() {
 			})
 
 		case core.EventAskOTP:
-			a.setState(StateAuthenticating)
-			ShowOTPPrompt(a.window, func(otp string) {
-				if a.getState() != StateAuthenticating {
+			a.notifyPrompt("La VPN necesita un nuevo código OTP para reconectar")
+			ShowChallengePrompt(a.window, event.Message, event.Echo, event.ResponseRequired, func(otp string) {
+				if !a.getState().AwaitingCredentials() {
 					return // Abort if state changed
 				}
 				if err := a.sendFns.OTP(otp); err != nil {
@@ -373,19 +610,47 @@ func (a *App) handleEvents() {
 				}
 			})
 
+		case core.EventWebAuth:
+			a.notifyPrompt("La VPN necesita que vuelvas a iniciar sesión en el navegador")
+			a.addLog(fmt.Sprintf("Inicio de sesión web requerido (máx. %s): %s", event.Timeout, event.URL))
+			if err := core.OpenBrowser(event.URL); err != nil {
+				a.addLog("Error al abrir el navegador: " + err.Error())
+			}
+			a.showWebAuthDialog(event.URL)
+			a.refreshStatus() // Mostrar la espera del navegador
+
+		case core.EventReconnecting:
+			a.reconnectStatus = formatReconnectStatus(event)
+			// La cuenta regresiva llega cada segundo; solo se registra el primer aviso
+			if event.Attempt == 0 || event.Attempt != a.reconnectAttempt {
+				a.addLog(event.Message + ", reconectando " + a.reconnectStatus)
+			}
+			a.reconnectAttempt = event.Attempt
+			if a.statsRunning() {
+				a.recordTraffic(session.Stats())
+			}
+			a.stopStatsUpdates()
+			a.hideConnectionInfo()
+			a.refreshStatus() // Actualizar la cuenta regresiva
+
 		case core.EventConnected:
-			a.setState(StateConnected)
+			a.setPromptNotify(false)
+			a.hideWebAuthDialog()
+			a.reconnectAttempt = 0
+			a.updateRecord(func(r *history.Record) { r.AssignedIP = event.LocalIP })
+			a.startStatsUpdates(session)
+			a.showConnectionInfo(session.ConnectionInfo())
 			a.addLog(event.Message)
 			ShowInfo(a.window, "Conectado", "Conexión VPN establecida exitosamente")
 
 		case core.EventAuthFailed:
-			a.setState(StateAuthenticating)
+			a.updateRecord(func(r *history.Record) { r.AuthRetries++ })
 			a.addLog("Error: " + event.Message)
 			ShowError(a.window, "Error de autenticación", event.Message)
 
 			if event.Stage == "password" {
 				ShowPasswordPromptWithDefault(a.window, a.savedPassword, func(password string) {
-					if a.getState() != StateAuthenticating {
+					if !a.getState().AwaitingCredentials() {
 						return // Abort if state changed
 					}
 					a.savedPassword = password
@@ -399,61 +664,120 @@ func (a *App) handleEvents() {
 						a.addLog("Error al enviar contraseña: " + err.Error())
 					}
 				})
-			} else if event.Stage == "otp" {
-				ShowOTPPrompt(a.window, func(otp string) {
-					if a.getState() != StateAuthenticating {
+			} else if event.Stage == "credentials" {
+				// static-challenge: la contraseña y el OTP se vuelven a pedir juntos,
+				// con el mismo texto y eco que el primer intento
+				ShowStaticChallengePrompt(a.window, a.savedUsername, a.savedPassword, a.rememberCreds, event.Challenge, event.Echo, func(result CredentialsResult) {
+					if !a.getState().AwaitingCredentials() {
 						return // Abort if state changed
 					}
-					if err := a.sendFns.OTP(otp); err != nil {
-						a.addLog("Error al enviar OTP: " + err.Error())
+					a.savedUsername = result.Username
+					a.savedPassword = result.Password
+					a.rememberCreds = result.Remember
+					a.persistCredentials()
+
+					if err := a.sendFns.Credentials(result.Username, result.Password, result.OTP); err != nil {
+						a.addLog("Error al enviar credenciales: " + err.Error())
 					}
 				})
 			}
 
 		case core.EventFatal:
-			a.setState(StateError)
+			reason := a.failureReason(history.EndFatal)
 			a.addLog("Error fatal: " + event.Message)
 			ShowError(a.window, "Error Fatal", event.Message)
-			a.onDisconnect()
+			a.endSession(reason)
+			a.keepKillSwitch()
 
 		case core.EventDisconnected:
 			a.addLog("Conexión cerrada")
-			a.onDisconnect()
+			a.endSession(a.failureReason(history.EndNetwork))
+			a.keepKillSwitch()
+		}
+	}
+}
+
+// persistCredentials guarda o elimina las credenciales según "Recordar credenciales"
+func (a *App) persistCredentials() {
+	if a.rememberCreds {
+		method, warning, err := core.SaveCredentials(a.savedUsername, a.savedPassword)
+		if err != nil {
+			a.addLog("Advertencia: No se pudieron guardar las credenciales: " + err.Error())
+		} else {
+			a.credStore = method
+			a.logCredentialSave(method)
+			if warning != "" {
+				a.addLog("Aviso: " + warning)
+			}
 		}
+		return
 	}
+
+	if err := core.DeleteCredentials(); err != nil {
+		a.addLog("Advertencia: No se pudieron eliminar credenciales guardadas: " + err.Error())
+	}
+	a.savedUsername = ""
+	a.savedPassword = ""
+	a.credStore = core.CredentialStoreMethodNone
 }
 
 // getState de forma segura para hilos
-func (a *App) getState() AppState {
+func (a *App) getState() core.State {
 	a.stateMutex.RLock()
 	defer a.stateMutex.RUnlock()
 	return a.state
 }
 
-// setState actualiza el estado de la aplicación de forma segura para hilos
-func (a *App) setState(state AppState) {
+// setState guarda el estado publicado por la sesión y actualiza la UI
+func (a *App) setState(state core.State) {
 	a.stateMutex.Lock()
-	a.state = state
+	if state != a.state {
+		a.prevState = a.state
+		a.state = state
+	}
 	a.stateMutex.Unlock()
 
-	switch state {
-	case StateDisconnected:
-		a.statusLabel.SetText("Estado: Desconectado")
-	case StateConnecting:
-		a.statusLabel.SetText("Estado: Conectando...")
-	case StateAuthenticating:
-		a.statusLabel.SetText("Estado: Autenticando...")
-	case StateConnected:
-		a.statusLabel.SetText("Estado: Conectado ✅")
-	case StateError:
-		a.statusLabel.SetText("Estado: Error ❌")
-	}
+	a.refreshStatus()
+}
+
+// refreshStatus actualiza la ventana y el tray a partir del estado actual
+func (a *App) refreshStatus() {
+	state := a.getState()
+	a.statusLabel.SetText("Estado: " + a.statusText(state))
 	a.statusLabel.Refresh()
 
 	// Actualizar tray icon también
 	a.updateTrayIcon()
 }
 
+// statusText describe el estado para el usuario
+func (a *App) statusText(state core.State) string {
+	switch state {
+	case core.StateIdle:
+		return "Desconectado"
+	case core.StateStarting:
+		return "Conectando..."
+	case core.StateAwaitingUser, core.StateAwaitingPassword, core.StateAwaitingOTP:
+		return "Autenticando..."
+	case core.StateConnecting:
+		if a.webAuthDialog != nil {
+			return "Esperando inicio de sesión en el navegador..."
+		}
+		return "Estableciendo el túnel..."
+	case core.StateConnected:
+		return "Conectado ✅"
+	case core.StateDegraded:
+		return "Conectado, sin respuesta ⚠️"
+	case core.StateReconnecting:
+		return "Reconectando " + a.reconnectStatus
+	case core.StateDisconnecting:
+		return "Desconectando..."
+	case core.StateFailed:
+		return "Error ❌"
+	}
+	return state.String()
+}
+
 // updateTrayIcon actualiza el icono y estado del system tray
 func (a *App) updateTrayIcon() {
 	if a.trayIcon == nil {
@@ -462,25 +786,73 @@ func (a *App) updateTrayIcon() {
 
 	state := a.getState()
 	switch state {
-	case StateDisconnected:
+	case core.StateIdle:
 		a.trayIcon.SetIcon(tray.IconDisconnected)
-		a.trayIcon.UpdateState("Desconectado", false)
-
-	case StateConnecting:
+	case core.StateConnected:
+		a.trayIcon.SetIcon(tray.IconConnected)
+	case core.StateDegraded:
+		a.trayIcon.SetIcon(tray.IconDegraded)
+	case core.StateFailed:
+		a.trayIcon.SetIcon(tray.IconError)
+	default:
 		a.trayIcon.SetIcon(tray.IconConnecting)
-		a.trayIcon.UpdateState("Conectando...", false)
+	}
 
-	case StateAuthenticating:
-		a.trayIcon.SetIcon(tray.IconConnecting)
-		a.trayIcon.UpdateState("Autenticando...", false)
+	// "Desconectar" queda habilitado mientras haya una sesión, también para
+	// cancelar la autenticación o una reconexión
+	status := strings.TrimSuffix(strings.TrimSuffix(a.statusText(state), " ✅"), " ⚠️")
+	a.trayIcon.UpdateState(status, state.Active())
+	a.trayIcon.SetConnected(state.TunnelUp())
+}
 
-	case StateConnected:
-		a.trayIcon.SetIcon(tray.IconConnected)
-		a.trayIcon.UpdateState("Conectado", true)
+// formatReconnectStatus describe una reconexión: "(intento 2/5) en 8s..."
+func formatReconnectStatus(event core.Event) string {
+	if event.Offline {
+		return fmt.Sprintf("(intento %d/%d) cuando vuelva la red...", event.Attempt, event.MaxAttempts)
+	}
+	if event.Attempt == 0 {
+		if event.Message != "" {
+			return fmt.Sprintf("(%s)...", event.Message)
+		}
+		return "..."
+	}
+	return fmt.Sprintf("(intento %d/%d) en %s...", event.Attempt, event.MaxAttempts, event.Delay)
+}
 
-	case StateError:
-		a.trayIcon.SetIcon(tray.IconError)
-		a.trayIcon.UpdateState("Error", false)
+// showWebAuthDialog muestra la espera del inicio de sesión web con opción de cancelar
+func (a *App) showWebAuthDialog(rawURL string) {
+	a.hideWebAuthDialog()
+
+	label := widget.NewLabel("Completa el inicio de sesión en la ventana del navegador.\nSi no se abrió, usa el enlace:")
+	label.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(label)
+	if u, err := url.Parse(rawURL); err == nil {
+		content.Add(widget.NewHyperlink(rawURL, u))
+	}
+
+	d := dialog.NewCustomConfirm(
+		"Inicio de sesión web",
+		"Cancelar conexión",
+		"Ocultar",
+		content,
+		func(cancel bool) {
+			if cancel && a.getState() == core.StateConnecting {
+				a.onDisconnect()
+			}
+		},
+		a.window,
+	)
+	d.Resize(fyne.NewSize(450, 180))
+	d.Show()
+	a.webAuthDialog = d
+}
+
+// hideWebAuthDialog cierra el diálogo de inicio de sesión web si está visible
+func (a *App) hideWebAuthDialog() {
+	if a.webAuthDialog != nil {
+		a.webAuthDialog.Hide()
+		a.webAuthDialog = nil
 	}
 }
 
@@ -537,7 +909,7 @@ func (a *App) showWelcomeDialog() {
 	}()
 }
 
-// showFilePicker muestra el selector de archivos para elegir un .ovpn
+// showFilePicker muestra el selector de archivos para importar un .ovpn, .conf o .zip
 func (a *App) showFilePicker() {
 	// Crear file dialog
 	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
@@ -555,29 +927,18 @@ func (a *App) showFilePicker() {
 		filePath := reader.URI().Path()
 		reader.Close()
 
-		// Verificar que sea un archivo .ovpn
-		if filepath.Ext(filePath) != ".ovpn" {
-			ShowError(a.window, "Error", "Por favor selecciona un archivo .ovpn válido")
+		// Verificar que sea un perfil que se pueda importar
+		if !profile.IsImportable(filePath) {
+			ShowError(a.window, "Error", "Por favor selecciona un archivo .ovpn, .conf o .zip válido")
 			return
 		}
 
-		// Guardar en configuración
-		a.config.VPNConfigPath = filePath
-		if err := a.config.Save(); err != nil {
-			a.addLog("Error al guardar configuración: " + err.Error())
-			ShowError(a.window, "Error", "No se pudo guardar la configuración")
-			return
-		}
-
-		// Actualizar UI
-		a.updateConfigStatus()
-		fileName := filepath.Base(filePath)
-		a.addLog(fmt.Sprintf("✓ Archivo VPN seleccionado: %s", fileName))
-		ShowInfo(a.window, "Archivo configurado", fmt.Sprintf("Se ha configurado el archivo:\n%s\n\nYa puedes conectarte.", fileName))
+		// Copiar el perfil al directorio de la aplicación, revisarlo y usarlo
+		a.importProfile(filePath)
 	}, a.window)
 
-	// Configurar filtro para solo mostrar archivos .ovpn
-	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".ovpn"}))
+	// Configurar filtro para solo mostrar perfiles y paquetes ZIP
+	fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".ovpn", ".conf", ".zip"}))
 
 	// Intentar abrir en el directorio home del usuario
 	homeDir, err := storage.ListerForURI(storage.NewFileURI(getUserHomeDir()))
@@ -588,6 +949,23 @@ func (a *App) showFilePicker() {
 	fileDialog.Show()
 }
 
+// selectProfile agrega el archivo a la lista de perfiles (si no estaba) y
+// lo selecciona
+func (a *App) selectProfile(filePath string) {
+	p := a.config.AddProfile(filePath)
+	a.config.CurrentProfile = p.ID
+	if err := a.config.Save(); err != nil {
+		a.addLog("Error al guardar configuración: " + err.Error())
+		ShowError(a.window, "Error", "No se pudo guardar la configuración")
+		return
+	}
+
+	// Actualizar UI
+	a.refreshProfiles()
+	a.addLog(fmt.Sprintf("✓ Perfil seleccionado: %s", p.Name))
+	ShowInfo(a.window, "Perfil configurado", fmt.Sprintf("Se ha agregado el perfil:\n%s\n\nYa puedes conectarte.", p.Name))
+}
+
 // getUserHomeDir retorna el directorio home del usuario
 func getUserHomeDir() string {
 	home, err := os.UserHomeDir()
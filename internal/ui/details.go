@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/core"
+)
+
+// buildDetailsPanel crea el panel plegable con la configuración de red de la sesión
+func (a *App) buildDetailsPanel() fyne.CanvasObject {
+	a.detailsLabel = widget.NewLabel("")
+	a.detailsLabel.Wrapping = fyne.TextWrapWord
+	a.detailsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+
+	a.routesLabel = widget.NewLabel("")
+	a.routesLabel.TextStyle = fyne.TextStyle{Monospace: true}
+
+	a.detailsPanel = widget.NewAccordion(
+		widget.NewAccordionItem("Detalles de la conexión", a.detailsLabel),
+		widget.NewAccordionItem("Tabla de rutas", a.routesLabel),
+	)
+	a.detailsPanel.Hide()
+	return a.detailsPanel
+}
+
+// showConnectionInfo muestra la configuración de red de la sesión conectada
+func (a *App) showConnectionInfo(info core.ConnectionInfo) {
+	a.detailsLabel.SetText(formatConnectionInfo(info))
+
+	// La tabla efectiva ya incluye las reglas del perfil y lo que empujó el servidor
+	routes, err := core.RoutingTable()
+	if err != nil {
+		a.routesLabel.SetText("No se pudo leer la tabla de rutas: " + err.Error())
+	} else {
+		a.routesLabel.SetText(formatRoutes(routes, info.Device))
+	}
+	a.detailsPanel.Show()
+}
+
+// hideConnectionInfo oculta el panel de detalles
+func (a *App) hideConnectionInfo() {
+	a.detailsPanel.Hide()
+	a.detailsLabel.SetText("")
+	a.routesLabel.SetText("")
+}
+
+// copyTunnelIP copia al portapapeles la IP asignada al túnel
+func (a *App) copyTunnelIP() {
+	session := a.currentSession()
+	if session == nil || !a.getState().TunnelUp() {
+		return
+	}
+
+	ip := session.ConnectionInfo().TunnelIP()
+	if ip == "" {
+		a.addLog("OpenVPN no informó la IP del túnel")
+		return
+	}
+	a.window.Clipboard().SetContent(ip)
+	a.addLog("IP del túnel copiada: " + ip)
+}
+
+// formatConnectionInfo arma el texto del panel de detalles; los datos que
+// OpenVPN no informó se omiten
+func formatConnectionInfo(info core.ConnectionInfo) string {
+	var b strings.Builder
+	row := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-14s %s\n", label+":", value)
+		}
+	}
+
+	row("IP del túnel", info.LocalIP)
+	row("IPv6", info.LocalIPv6)
+	row("Interfaz", info.Device)
+	if info.RemoteIP != "" {
+		server := info.RemoteIP
+		if info.RemotePort != "" {
+			server = fmt.Sprintf("%s:%s", info.RemoteIP, info.RemotePort)
+			if strings.Contains(info.RemoteIP, ":") {
+				server = fmt.Sprintf("[%s]:%s", info.RemoteIP, info.RemotePort)
+			}
+		}
+		row("Servidor", server)
+	}
+	row("Protocolo", strings.ToUpper(info.Protocol))
+	row("Rutas", strings.Join(info.Routes, ", "))
+	row("DNS", strings.Join(info.DNSServers, ", "))
+	row("Dominios", strings.Join(info.SearchDomains, ", "))
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatRoutes arma la tabla de rutas; las que van por el túnel se marcan
+func formatRoutes(routes []core.Route, tunnel string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-20s %-16s %-8s %s\n", "Destino", "Gateway", "Interfaz", "Métrica")
+	for _, r := range routes {
+		gateway := r.Gateway
+		if gateway == "" {
+			gateway = "directa"
+		}
+		fmt.Fprintf(&b, "%-20s %-16s %-8s %d", r.Destination, gateway, r.Device, r.Metric)
+		if tunnel != "" && r.Device == tunnel {
+			b.WriteString("  ← VPN")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
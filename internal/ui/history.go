@@ -0,0 +1,305 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/core"
+	"github.com/lavp2393/navtunnel/internal/history"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// allProfiles es la opción del filtro que muestra todos los perfiles
+const allProfiles = "Todos los perfiles"
+
+// endReasonLabels traduce el motivo de fin de sesión para la UI
+var endReasonLabels = map[history.EndReason]string{
+	history.EndUser:        "Usuario",
+	history.EndFatal:       "Error fatal",
+	history.EndAuthFailure: "Autenticación fallida",
+	history.EndNetwork:     "Red",
+	history.EndSleep:       "Suspensión",
+	history.EndLock:        "Pantalla bloqueada",
+	history.EndDetached:    "Segundo plano",
+}
+
+// historyColumns son los encabezados de la tabla de historial
+var historyColumns = []string{"Perfil", "Inicio", "Duración", "IP", "Recibido", "Enviado", "Motivo", "Reintentos", "Degradada"}
+
+// beginRecord comienza el registro de una sesión nueva. Con resumed el
+// tráfico anterior ya está en el historial: solo se suma el que llegue después.
+func (a *App) beginRecord(configPath string, start time.Time, resumed bool) {
+	name := a.recordProfile(configPath)
+
+	a.recordMutex.Lock()
+	defer a.recordMutex.Unlock()
+
+	a.record = &history.Record{
+		Profile: name,
+		Start:   start,
+	}
+	a.recordedStats = core.Stats{}
+	a.recordBaseline = resumed
+}
+
+// recordProfile es el nombre con que el historial muestra el perfil; el
+// archivo si no está en la lista
+func (a *App) recordProfile(configPath string) string {
+	if p := a.config.ProfileByPath(configPath); p != nil {
+		return p.Name
+	}
+	return filepath.Base(configPath)
+}
+
+// detachedUntil busca si la sesión que empezó en start ya se dejó en segundo
+// plano: retorna hasta cuándo la cubre el historial, o start si no lo está
+func (a *App) detachedUntil(configPath string, start time.Time) (time.Time, bool) {
+	if a.history == nil {
+		return start, false
+	}
+	records, err := a.history.Load(history.Filter{From: start, Profile: a.recordProfile(configPath)})
+	if err != nil {
+		return start, false
+	}
+
+	until, found := start, false
+	for _, r := range records {
+		if r.EndReason == history.EndDetached && !r.End.Before(until) {
+			until, found = r.End, true
+		}
+	}
+	return until, found
+}
+
+// updateRecord modifica el registro de la sesión en curso, si existe
+func (a *App) updateRecord(fn func(r *history.Record)) {
+	a.recordMutex.Lock()
+	defer a.recordMutex.Unlock()
+
+	if a.record != nil {
+		fn(a.record)
+	}
+}
+
+// recordTraffic suma al registro el tráfico nuevo desde la última muestra.
+// El Manager mantiene sus totales a través de los reinicios de OpenVPN
+// (ping-restart, SIGUSR1) y conserva ConnectedSince; solo cuando el
+// supervisor lanza una sesión nueva los contadores empiezan de cero.
+func (a *App) recordTraffic(stats core.Stats) {
+	if stats.ConnectedSince.IsZero() {
+		return
+	}
+	a.updateRecord(func(r *history.Record) {
+		if a.recordBaseline {
+			a.recordBaseline = false
+			a.recordedStats = stats
+			return
+		}
+		last := a.recordedStats
+		if !stats.ConnectedSince.Equal(last.ConnectedSince) {
+			last = core.Stats{}
+		}
+		if stats.BytesIn >= last.BytesIn {
+			r.BytesIn += stats.BytesIn - last.BytesIn
+		}
+		if stats.BytesOut >= last.BytesOut {
+			r.BytesOut += stats.BytesOut - last.BytesOut
+		}
+		a.recordedStats = stats
+	})
+}
+
+// finishRecord cierra el registro de la sesión y lo guarda en el historial
+func (a *App) finishRecord(reason history.EndReason) {
+	a.recordMutex.Lock()
+	record := a.record
+	a.record = nil
+	a.recordMutex.Unlock()
+
+	if record == nil || a.history == nil {
+		return
+	}
+
+	record.End = time.Now()
+	record.EndReason = reason
+	if err := a.history.Append(*record); err != nil {
+		a.addLog("Advertencia: No se pudo guardar el historial: " + err.Error())
+	}
+}
+
+// failureReason clasifica el fin de una sesión que no pidió el usuario.
+// Los eventos Fatal y Disconnected llegan después del cambio a Failed o
+// Disconnecting, así que se mira el estado anterior.
+func (a *App) failureReason(fallback history.EndReason) history.EndReason {
+	retries := 0
+	a.updateRecord(func(r *history.Record) { retries = r.AuthRetries })
+
+	a.stateMutex.RLock()
+	state := a.state
+	if state == core.StateFailed || state == core.StateDisconnecting {
+		state = a.prevState
+	}
+	a.stateMutex.RUnlock()
+
+	switch {
+	case state == core.StateReconnecting:
+		return history.EndNetwork
+	case state.AwaitingCredentials() && retries > 0:
+		return history.EndAuthFailure
+	}
+	return fallback
+}
+
+// showHistoryWindow abre la ventana de historial de conexiones
+func (a *App) showHistoryWindow() {
+	if a.history == nil {
+		ShowError(a.window, "Historial", "El historial no está disponible")
+		return
+	}
+
+	w := a.fyneApp.NewWindow("Historial de conexiones")
+	w.Resize(fyne.NewSize(900, 450))
+
+	var records []history.Record
+
+	all, err := a.history.Load(history.Filter{})
+	if err != nil {
+		a.addLog("Error al leer el historial: " + err.Error())
+	}
+	profileSelect := widget.NewSelect(append([]string{allProfiles}, history.Profiles(all)...), nil)
+	profileSelect.SetSelected(allProfiles)
+
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("Desde (AAAA-MM-DD)")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("Hasta (AAAA-MM-DD)")
+
+	summary := widget.NewLabel("")
+
+	table := widget.NewTable(
+		func() (int, int) { return len(records) + 1, len(historyColumns) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.TableCellID, obj fyne.CanvasObject) {
+			label := obj.(*widget.Label)
+			if id.Row == 0 {
+				label.TextStyle = fyne.TextStyle{Bold: true}
+				label.SetText(historyColumns[id.Col])
+				return
+			}
+			label.TextStyle = fyne.TextStyle{}
+			label.SetText(historyCell(records[id.Row-1], id.Col))
+		},
+	)
+	for col, width := range []float32{160, 150, 90, 120, 90, 90, 160, 80, 80} {
+		table.SetColumnWidth(col, width)
+	}
+
+	refresh := func() {
+		filter, err := historyFilter(profileSelect.Selected, fromEntry.Text, toEntry.Text)
+		if err != nil {
+			summary.SetText(err.Error())
+			return
+		}
+		records, err = a.history.Load(filter)
+		if err != nil {
+			summary.SetText("Error al leer el historial: " + err.Error())
+			return
+		}
+		summary.SetText(fmt.Sprintf("%d sesiones", len(records)))
+		table.Refresh()
+	}
+	profileSelect.OnChanged = func(string) { refresh() }
+
+	exportBtn := widget.NewButton("Exportar CSV", func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return // Cancelado
+			}
+			defer writer.Close()
+
+			if err := history.WriteCSV(writer, records); err != nil {
+				ShowError(w, "Error", err.Error())
+				return
+			}
+			a.addLog("Historial exportado a " + writer.URI().Path())
+		}, w)
+		save.SetFileName("navtunnel-historial.csv")
+		save.Show()
+	})
+
+	filters := container.NewHBox(
+		profileSelect,
+		container.NewGridWrap(fyne.NewSize(170, fromEntry.MinSize().Height), fromEntry),
+		container.NewGridWrap(fyne.NewSize(170, toEntry.MinSize().Height), toEntry),
+		widget.NewButton("Filtrar", refresh),
+		exportBtn,
+	)
+
+	w.SetContent(container.NewBorder(
+		container.NewVBox(filters, summary),
+		nil, nil, nil,
+		table,
+	))
+
+	refresh()
+	w.Show()
+}
+
+// historyFilter construye el filtro a partir de los campos de la ventana.
+// Las fechas son días completos en hora local; "Hasta" incluye ese día.
+func historyFilter(profile, from, to string) (history.Filter, error) {
+	var filter history.Filter
+	if profile != allProfiles {
+		filter.Profile = profile
+	}
+
+	if from = strings.TrimSpace(from); from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("fecha \"desde\" inválida: %s", from)
+		}
+		filter.From = t
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("fecha \"hasta\" inválida: %s", to)
+		}
+		filter.To = t.AddDate(0, 0, 1)
+	}
+	return filter, nil
+}
+
+// historyCell retorna el texto de una columna de la tabla de historial
+func historyCell(r history.Record, col int) string {
+	switch col {
+	case 0:
+		return r.Profile
+	case 1:
+		return r.Start.Local().Format("2006-01-02 15:04")
+	case 2:
+		return r.Duration().String()
+	case 3:
+		return r.AssignedIP
+	case 4:
+		return formatBytes(float64(r.BytesIn))
+	case 5:
+		return formatBytes(float64(r.BytesOut))
+	case 6:
+		if label, ok := endReasonLabels[r.EndReason]; ok {
+			return label
+		}
+		return string(r.EndReason)
+	case 7:
+		return fmt.Sprint(r.AuthRetries)
+	case 8:
+		return fmt.Sprint(r.Degraded)
+	}
+	return ""
+}
@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/config"
+	"github.com/lavp2393/navtunnel/internal/profile"
+)
+
+// importProfile copia el perfil elegido (o los de un ZIP) al directorio de
+// perfiles de la aplicación y ofrece usarlo
+func (a *App) importProfile(source string) {
+	dir, err := config.ProfilesDir()
+	if err != nil {
+		a.addLog("Error al importar el perfil: " + err.Error())
+		ShowError(a.window, "Error", "No se pudo preparar el directorio de perfiles")
+		return
+	}
+
+	imported, err := profile.Import(source, dir)
+	if err != nil {
+		a.addLog("Error al importar el perfil: " + err.Error())
+		ShowError(a.window, "No se pudo importar", err.Error())
+		return
+	}
+
+	for _, im := range imported {
+		switch {
+		case im.Duplicate:
+			a.addLog(fmt.Sprintf("El perfil ya estaba importado: %s", filepath.Base(im.Path)))
+		case len(im.Inlined) > 0:
+			a.addLog(fmt.Sprintf("Perfil importado: %s (se incorporaron %s)", filepath.Base(im.Path), strings.Join(im.Inlined, ", ")))
+		default:
+			a.addLog(fmt.Sprintf("Perfil importado: %s", filepath.Base(im.Path)))
+		}
+		if len(im.External) > 0 {
+			a.addLog(fmt.Sprintf("Aviso: %s sigue usando archivos externos (%s); si se mueven, el perfil deja de funcionar",
+				filepath.Base(im.Path), strings.Join(im.External, ", ")))
+		}
+	}
+
+	if len(imported) == 1 {
+		a.useImported(imported[0])
+		return
+	}
+	a.showImportChoice(imported)
+}
+
+// showImportChoice pregunta cuál de los perfiles de un ZIP se usa. Las copias
+// de los demás se borran, y todas si se cancela.
+func (a *App) showImportChoice(imported []profile.Imported) {
+	var names []string
+	for _, im := range imported {
+		names = append(names, filepath.Base(im.Path))
+	}
+	choice := widget.NewRadioGroup(names, nil)
+	choice.SetSelected(names[0])
+
+	label := widget.NewLabel("El archivo contiene varios perfiles. ¿Cuál quieres usar?")
+	label.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm(
+		"Perfiles importados",
+		"Usar",
+		"Cancelar",
+		container.NewVBox(label, choice),
+		func(ok bool) {
+			if !ok {
+				discardImported(imported, "")
+				a.addLog("Importación cancelada")
+				return
+			}
+			for i, name := range names {
+				if name == choice.Selected {
+					discardImported(imported, imported[i].Path)
+					a.useImported(imported[i])
+				}
+			}
+		},
+		a.window,
+	)
+	d.Resize(fyne.NewSize(450, 300))
+	d.Show()
+}
+
+// useImported revisa el perfil importado y lo selecciona. Si el usuario lo
+// rechaza se borra la copia, salvo que ya estuviera importado de antes.
+func (a *App) useImported(im profile.Imported) {
+	a.reviewProfile(im.Path, func(accepted bool) {
+		if accepted {
+			a.selectProfile(im.Path)
+			return
+		}
+		if !im.Duplicate {
+			os.Remove(im.Path)
+		}
+	})
+}
+
+// discardImported borra las copias importadas que no se van a usar, salvo la
+// indicada y las que ya existían antes de importar (duplicados)
+func discardImported(imported []profile.Imported, keep string) {
+	for _, im := range imported {
+		if !im.Duplicate && im.Path != keep {
+			os.Remove(im.Path)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/core"
+)
+
+// checkKillSwitch ofrece quitar un kill switch que quedó activo sin una
+// sesión que lo use (por ejemplo, si la aplicación terminó inesperadamente)
+func (a *App) checkKillSwitch() {
+	if core.KillSwitchActive() {
+		a.addLog("El kill switch de una sesión anterior sigue bloqueando el tráfico")
+		a.showKillSwitchDialog("El kill switch de una sesión anterior sigue activo: el tráfico fuera de la VPN está bloqueado.")
+	}
+}
+
+// keepKillSwitch avisa que la sesión terminó sin que el usuario desconectara:
+// el tráfico sigue bloqueado hasta que lo decida
+func (a *App) keepKillSwitch() {
+	if core.KillSwitchActive() {
+		a.addLog("El kill switch sigue activo: el tráfico fuera de la VPN está bloqueado")
+		a.showKillSwitchDialog("La VPN se desconectó y el kill switch sigue bloqueando el tráfico fuera de la VPN.")
+	}
+}
+
+// showKillSwitchDialog pregunta si se quita el bloqueo del kill switch
+func (a *App) showKillSwitchDialog(msg string) {
+	label := widget.NewLabel(msg + "\n\nPuedes volver a conectar o quitar el bloqueo.")
+	label.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm(
+		"Tráfico bloqueado",
+		"Quitar bloqueo",
+		"Mantener",
+		label,
+		func(release bool) {
+			if release && a.currentSession() == nil {
+				go a.disableKillSwitch()
+			}
+		},
+		a.window,
+	)
+	d.Resize(fyne.NewSize(450, 200))
+	d.Show()
+}
+
+// disableKillSwitch quita el kill switch si está activo
+func (a *App) disableKillSwitch() {
+	if !core.KillSwitchActive() {
+		return
+	}
+	if err := core.DisableKillSwitch(); err != nil {
+		a.addLog("Error al quitar el kill switch: " + err.Error())
+		ShowError(a.window, "Error", "No se pudo quitar el kill switch: "+err.Error())
+		return
+	}
+	a.addLog("Kill switch desactivado")
+}
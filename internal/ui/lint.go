@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/config"
+	"github.com/lavp2393/navtunnel/internal/profile"
+)
+
+// severityLabels son los títulos de cada grupo de hallazgos
+var severityLabels = map[profile.Severity]string{
+	profile.SeverityError:      "❌ Errores",
+	profile.SeverityDanger:     "⛔ Ejecuta código como administrador",
+	profile.SeveritySecurity:   "⚠️ Seguridad",
+	profile.SeverityDeprecated: "ℹ️ Obsoleto",
+}
+
+// blockedProfileMessage explica por qué no se puede usar un perfil peligroso
+const blockedProfileMessage = "Este perfil ejecuta comandos como administrador (up, down, plugin...) y la política de NavTunnel no permite usarlo."
+
+// reviewProfile revisa el perfil elegido y llama a done con true si se puede
+// usar: sin hallazgos directamente, o después de que el usuario los revise.
+// Los perfiles que ejecutan comandos como root piden una confirmación
+// explícita, o se rechazan si la política lo indica.
+func (a *App) reviewProfile(path string, done func(accepted bool)) {
+	p, err := profile.ParseFile(path)
+	if err != nil {
+		a.addLog("El perfil no es válido: " + err.Error())
+		ShowError(a.window, "Perfil no válido", fmt.Sprintf("No se pudo leer %s:\n%s", filepath.Base(path), err))
+		done(false)
+		return
+	}
+
+	findings := profile.Lint(p)
+	if len(findings) == 0 {
+		done(true)
+		return
+	}
+	for _, f := range findings {
+		a.addLog("Perfil: " + f.String())
+	}
+
+	dangerous := profile.HasSeverity(findings, profile.SeverityDanger)
+	if dangerous && a.config.DangerousProfiles == config.DangerousBlock {
+		ShowError(a.window, "Perfil bloqueado", blockedProfileMessage)
+		done(false)
+		return
+	}
+	a.showLintDialog(path, findings, dangerous, func(accepted bool) {
+		if accepted && dangerous {
+			a.approveProfile(path)
+		}
+		done(accepted)
+	})
+}
+
+// showLintDialog muestra los hallazgos del linter y deja usar el perfil de
+// todos modos. Si es peligroso, hay que marcar la confirmación.
+func (a *App) showLintDialog(path string, findings []profile.Finding, dangerous bool, done func(accepted bool)) {
+	list := container.NewVBox()
+	for i, f := range findings {
+		if i == 0 || findings[i-1].Severity != f.Severity {
+			list.Add(widget.NewLabelWithStyle(severityLabels[f.Severity], fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		}
+		label := widget.NewLabel("• " + f.String())
+		label.Wrapping = fyne.TextWrapWord
+		list.Add(label)
+	}
+
+	intro := widget.NewLabel(fmt.Sprintf("Se encontraron problemas en %s:", filepath.Base(path)))
+	intro.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(intro, nil, nil, nil, container.NewVScroll(list))
+
+	var d *dialog.CustomDialog
+	cancel := widget.NewButton("Cancelar", func() {
+		a.addLog("Revisión del perfil cancelada")
+		d.Hide()
+		done(false)
+	})
+	use := widget.NewButton("Usar de todos modos", func() {
+		d.Hide()
+		done(true)
+	})
+	use.Importance = widget.WarningImportance
+
+	if dangerous {
+		// NavTunnel lanza OpenVPN como root: el perfil correría esos comandos como administrador
+		use.Disable()
+		confirm := widget.NewCheck("Entiendo que este perfil ejecutará comandos como administrador", func(checked bool) {
+			if checked {
+				use.Enable()
+			} else {
+				use.Disable()
+			}
+		})
+		content = container.NewBorder(intro, confirm, nil, nil, container.NewVScroll(list))
+	}
+
+	d = dialog.NewCustomWithoutButtons("Revisión del perfil", content, a.window)
+	d.SetButtons([]fyne.CanvasObject{cancel, use})
+	d.Resize(fyne.NewSize(560, 420))
+	d.Show()
+}
+
+// profileApproved indica si se puede conectar con el perfil sin revisarlo de
+// nuevo: no ejecuta comandos como administrador, o el usuario aceptó este
+// mismo contenido. Un perfil que se editó después de aceptarlo, que nunca
+// pasó por la revisión (por ejemplo, de una configuración migrada) o que no
+// se puede leer, no lo está.
+func (a *App) profileApproved(path string) bool {
+	p, err := profile.ParseFile(path)
+	if err != nil {
+		return false // Sin leerlo no se sabe si es peligroso: se revisa
+	}
+	if !profile.HasSeverity(profile.Lint(p), profile.SeverityDanger) {
+		return true
+	}
+	if a.config.DangerousProfiles == config.DangerousBlock {
+		return false
+	}
+	hash, err := profileHash(path)
+	return err == nil && a.config.SettingsFor(path).ApprovedHash == hash
+}
+
+// approveProfile guarda el hash del perfil que el usuario aceptó y la
+// configuración, para que la aprobación se mantenga entre sesiones
+func (a *App) approveProfile(path string) {
+	hash, err := profileHash(path)
+	if err != nil {
+		a.addLog("No se pudo registrar la aprobación del perfil: " + err.Error())
+		return
+	}
+	a.config.SettingsFor(path).ApprovedHash = hash
+	a.saveConfig()
+}
+
+// profileHash calcula el SHA-256 del archivo del perfil
+func profileHash(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/core"
+	"github.com/lavp2393/navtunnel/internal/history"
+)
+
+// checkOrphanSessions busca sesiones de OpenVPN que quedaron corriendo de una
+// ejecución anterior. Una sesión que se dejó conectada al salir se retoma
+// directamente; por las demás se pregunta. Retorna true si encontró alguna.
+func (a *App) checkOrphanSessions() bool {
+	orphans, err := core.FindOrphanSessions()
+	if err != nil {
+		a.addLog("No se pudieron revisar sesiones anteriores: " + err.Error())
+		return false
+	}
+	if len(orphans) == 0 {
+		return false
+	}
+
+	var pending []core.OrphanSession
+	for _, orphan := range orphans {
+		if orphan.Record.Detached && a.currentSession() == nil {
+			a.adoptSession(orphan)
+			continue
+		}
+		pending = append(pending, orphan)
+	}
+	if len(pending) > 0 {
+		a.showOrphanDialog(pending)
+	}
+	return true
+}
+
+// showOrphanDialog ofrece reconectarse a la primera sesión huérfana o
+// terminarla; las siguientes se preguntan al cerrar el diálogo
+func (a *App) showOrphanDialog(orphans []core.OrphanSession) {
+	orphan, rest := orphans[0], orphans[1:]
+	record := orphan.Record
+
+	msg := fmt.Sprintf("OpenVPN sigue en ejecución desde una sesión anterior de NavTunnel.\n\nPerfil: %s\nIniciada: %s",
+		filepath.Base(record.Profile), record.StartedAt.Format("02/01/2006 15:04"))
+	if len(orphan.PIDs) > 0 {
+		msg += fmt.Sprintf("\nPID: %v", orphan.PIDs)
+	}
+	label := widget.NewLabel(msg)
+	label.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm(
+		"Sesión VPN activa",
+		"Reconectar",
+		"Terminar",
+		label,
+		func(adopt bool) {
+			switch {
+			case adopt && a.currentSession() != nil:
+				// Solo se puede controlar una sesión a la vez
+				ShowError(a.window, "Error", "Ya hay una sesión VPN activa. Desconéctala antes de recuperar otra.")
+				a.showOrphanDialog(orphans)
+				return
+			case adopt:
+				a.adoptSession(orphan)
+			default:
+				a.terminateOrphan(orphan)
+			}
+			if len(rest) > 0 {
+				a.showOrphanDialog(rest)
+			}
+		},
+		a.window,
+	)
+	d.Resize(fyne.NewSize(450, 220))
+	d.Show()
+}
+
+// adoptSession vuelve a mostrar en la UI una sesión que quedó corriendo
+func (a *App) adoptSession(orphan core.OrphanSession) {
+	a.addLog("Recuperando la sesión VPN en curso...")
+
+	// El binario solo hace falta si el túnel se cae y hay que reconectar
+	openvpnPath, err := core.FindOpenVPN()
+	if err != nil {
+		a.addLog("No se encontró OpenVPN; no habrá reconexión automática: " + err.Error())
+	}
+
+	session := core.NewSupervisor(orphan.Record.Profile, openvpnPath, a.reconnectPolicy())
+	session.SetOptions(a.sessionOptions(orphan.Record.Profile))
+	if err := session.Adopt(orphan); err != nil {
+		a.addLog("Error al recuperar la sesión: " + err.Error())
+		ShowError(a.window, "Error", err.Error())
+		return
+	}
+
+	// Si la app ya dejó esta sesión en segundo plano, el historial tiene su
+	// tráfico hasta entonces: el registro sigue desde ahí
+	start, resumed := a.detachedUntil(orphan.Record.Profile, orphan.Record.StartedAt)
+	a.runSession(session, orphan.Record.Profile, start, resumed)
+}
+
+// terminateOrphan cierra una sesión que quedó corriendo sin mostrarla en la UI
+func (a *App) terminateOrphan(orphan core.OrphanSession) {
+	a.addLog("Terminando la sesión VPN anterior...")
+
+	go func() {
+		if err := core.TerminateOrphan(orphan); err != nil {
+			a.addLog("Error al terminar la sesión anterior: " + err.Error())
+			ShowError(a.window, "Error", err.Error())
+			return
+		}
+		a.addLog("Sesión VPN anterior terminada")
+	}()
+}
+
+// detachSession deja de controlar la sesión sin desconectar la VPN.
+// Solo una sesión conectada se deja corriendo; cualquier otra se cierra. El
+// registro del historial se guarda hasta aquí y se retoma al recuperarla.
+func (a *App) detachSession() {
+	if !a.getState().TunnelUp() {
+		select {
+		case <-a.endSession(history.EndUser):
+		case <-time.After(quitStopTimeout):
+		}
+		return
+	}
+
+	session := a.takeSession()
+	if session == nil {
+		return
+	}
+	if a.statsRunning() {
+		a.recordTraffic(session.Stats())
+	}
+	a.finishRecord(history.EndDetached)
+	a.stopStatsUpdates()
+	a.hideConnectionInfo()
+	a.hideWebAuthDialog()
+
+	if err := session.Detach(); err != nil {
+		a.addLog("Advertencia: " + err.Error())
+	}
+	a.addLog("La VPN sigue conectada en segundo plano")
+}
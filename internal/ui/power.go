@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/lavp2393/navtunnel/internal/config"
+	"github.com/lavp2393/navtunnel/internal/core"
+	"github.com/lavp2393/navtunnel/internal/history"
+)
+
+// watchSession sigue la suspensión y el bloqueo de la sesión del usuario
+// para aplicar las políticas del perfil conectado
+func (a *App) watchSession() {
+	monitor, err := core.WatchSession()
+	if err != nil {
+		a.addLog("No se vigilarán la suspensión ni el bloqueo de pantalla: " + err.Error())
+		return
+	}
+	a.sessionMonitor = monitor
+
+	go func() {
+		for event := range monitor.Events() {
+			a.handleSessionEvent(event)
+		}
+	}()
+}
+
+// handleSessionEvent aplica la política del perfil a un evento de la sesión
+func (a *App) handleSessionEvent(event core.SessionEvent) {
+	profile := a.activeProfile()
+	var settings config.ProfileSettings
+	if profile != "" {
+		settings = *a.config.SettingsFor(profile)
+	}
+
+	switch event {
+	case core.SessionSleep:
+		if profile != "" && settings.OnSleep == config.SleepReconnect {
+			a.addLog("El equipo se va a suspender: desconectando la VPN")
+			a.setPendingReconnect(&a.resumeProfile, profile)
+			select {
+			case <-a.endSession(history.EndSleep):
+			case <-time.After(quitStopTimeout):
+			}
+		}
+		a.sessionMonitor.SleepReady()
+
+	case core.SessionResume:
+		if pending := a.takePendingReconnect(&a.resumeProfile); pending != "" {
+			a.reconnectProfile(pending, "al volver de la suspensión")
+			return
+		}
+		// Tras suspender, el túnel suele estar muerto aunque figure conectado
+		if session := a.currentSession(); session != nil && settings.OnSleep == config.SleepRestart {
+			a.setPromptNotify(true)
+			session.RestartTunnel("la vuelta de la suspensión")
+		}
+
+	case core.SessionLock:
+		if profile == "" || settings.OnLock == config.LockKeep {
+			return
+		}
+		a.addLog("Pantalla bloqueada: desconectando la VPN")
+		if settings.OnLock == config.LockReconnect {
+			// El kill switch sigue activo hasta reconectar
+			a.setPendingReconnect(&a.unlockProfile, profile)
+			a.endSession(history.EndLock)
+			return
+		}
+		done := a.endSession(history.EndLock)
+		go func() {
+			<-done
+			a.disableKillSwitch()
+		}()
+
+	case core.SessionUnlock:
+		if pending := a.takePendingReconnect(&a.unlockProfile); pending != "" {
+			a.reconnectProfile(pending, "al desbloquear la pantalla")
+		}
+	}
+}
+
+// reconnectProfile vuelve a conectar el perfil que se desconectó por una política
+func (a *App) reconnectProfile(profile, why string) {
+	if a.currentSession() != nil {
+		return // El usuario ya conectó otra vez
+	}
+	p := a.config.ProfileByPath(profile)
+	if p == nil {
+		a.addLog("No se reconecta " + filepath.Base(profile) + ": ya no está en la lista de perfiles")
+		return
+	}
+
+	a.addLog("Reconectando la VPN " + why)
+	a.setPromptNotify(true)
+	a.connectProfile(p.ID)
+}
+
+// updateSleepDelay pide retrasar la suspensión mientras haya una sesión cuyo
+// perfil se desconecta antes de suspender
+func (a *App) updateSleepDelay() {
+	if a.sessionMonitor == nil {
+		return
+	}
+	profile := a.activeProfile()
+	delay := profile != "" && a.config.SettingsFor(profile).OnSleep == config.SleepReconnect
+	if err := a.sessionMonitor.SetSleepDelay(delay); err != nil {
+		a.addLog("No se podrá desconectar la VPN antes de suspender: " + err.Error())
+	}
+}
+
+// setPendingReconnect recuerda el perfil a reconectar
+func (a *App) setPendingReconnect(field *string, profile string) {
+	a.powerMutex.Lock()
+	defer a.powerMutex.Unlock()
+	*field = profile
+}
+
+// takePendingReconnect retorna (y olvida) el perfil a reconectar
+func (a *App) takePendingReconnect(field *string) string {
+	a.powerMutex.Lock()
+	defer a.powerMutex.Unlock()
+	profile := *field
+	*field = ""
+	return profile
+}
+
+// setPromptNotify activa o desactiva el aviso de credenciales en la reconexión
+func (a *App) setPromptNotify(on bool) {
+	a.powerMutex.Lock()
+	defer a.powerMutex.Unlock()
+	a.promptNotify = on
+}
+
+// notifyPrompt muestra una notificación del sistema si una reconexión
+// automática (al volver de la suspensión o al desbloquear) pide datos: la
+// ventana puede estar oculta en la bandeja
+func (a *App) notifyPrompt(message string) {
+	a.powerMutex.Lock()
+	notify := a.promptNotify
+	a.promptNotify = false
+	a.powerMutex.Unlock()
+
+	if notify {
+		a.fyneApp.SendNotification(fyne.NewNotification("NavTunnel", message))
+	}
+}
@@ -0,0 +1,231 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/config"
+	"github.com/lavp2393/navtunnel/internal/profile"
+	"github.com/lavp2393/navtunnel/internal/tray"
+)
+
+// buildProfilesPanel construye la lista de perfiles con sus acciones
+func (a *App) buildProfilesPanel() fyne.CanvasObject {
+	a.profileList = widget.NewList(
+		func() int {
+			return len(a.config.VPNProfiles)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			if id >= len(a.config.VPNProfiles) {
+				return
+			}
+			p := a.config.VPNProfiles[id]
+			text := p.Name
+			if !p.Exists() {
+				text = "❌ " + text
+			}
+			item.(*widget.Label).SetText(text)
+		},
+	)
+	a.profileList.OnSelected = func(id widget.ListItemID) {
+		if id < len(a.config.VPNProfiles) {
+			a.chooseProfile(a.config.VPNProfiles[id])
+		}
+	}
+
+	importBtn := widget.NewButton("Importar", a.showFilePicker)
+	renameBtn := widget.NewButton("Renombrar", a.showRenameProfile)
+	duplicateBtn := widget.NewButton("Duplicar", a.duplicateProfile)
+	removeBtn := widget.NewButton("Eliminar", a.confirmRemoveProfile)
+
+	return container.NewBorder(
+		widget.NewLabel("Perfiles:"),
+		container.NewGridWithColumns(2, importBtn, renameBtn, duplicateBtn, removeBtn),
+		nil,
+		nil,
+		a.profileList,
+	)
+}
+
+// refreshProfiles actualiza la lista de perfiles, el perfil seleccionado y
+// el submenú del tray
+func (a *App) refreshProfiles() {
+	current := a.config.Current()
+	a.profileList.Refresh()
+	for i, p := range a.config.VPNProfiles {
+		if p == current {
+			a.profileList.Select(i)
+		}
+	}
+	if current == nil {
+		a.profileList.UnselectAll()
+	}
+	a.updateConfigStatus()
+
+	if a.trayIcon == nil {
+		return
+	}
+	items := make([]tray.ProfileItem, len(a.config.VPNProfiles))
+	for i, p := range a.config.VPNProfiles {
+		items[i] = tray.ProfileItem{ID: p.ID, Name: p.Name}
+	}
+	a.trayIcon.SetProfiles(items)
+}
+
+// chooseProfile selecciona el perfil para la próxima conexión
+func (a *App) chooseProfile(p *config.VPNProfile) {
+	if a.config.CurrentProfile == p.ID {
+		return
+	}
+	a.config.CurrentProfile = p.ID
+	a.saveConfig()
+	a.updateConfigStatus()
+	a.addLog("Perfil seleccionado: " + p.Name)
+}
+
+// connectProfile selecciona el perfil y conecta (desde el submenú del tray)
+func (a *App) connectProfile(id string) {
+	p := a.config.Profile(id)
+	if p == nil {
+		return
+	}
+	if a.currentSession() != nil {
+		ShowError(a.window, "Error", "Desconecta la VPN antes de conectar otro perfil")
+		return
+	}
+	a.config.CurrentProfile = p.ID
+	a.refreshProfiles()
+	a.onConnect()
+}
+
+// showRenameProfile pide el nuevo nombre del perfil seleccionado
+func (a *App) showRenameProfile() {
+	p := a.config.Current()
+	if p == nil {
+		return
+	}
+	entry := widget.NewEntry()
+	entry.SetText(p.Name)
+	entry.Validator = func(name string) error {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("el nombre no puede estar vacío")
+		}
+		for _, other := range a.config.VPNProfiles {
+			if other != p && other.Name == name {
+				return fmt.Errorf("ya hay un perfil con ese nombre")
+			}
+		}
+		return nil
+	}
+
+	d := dialog.NewForm("Renombrar perfil", "Guardar", "Cancelar",
+		[]*widget.FormItem{widget.NewFormItem("Nombre", entry)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			old := p.Name
+			p.Name = strings.TrimSpace(entry.Text)
+			a.saveConfig()
+			a.refreshProfiles()
+			a.addLog(fmt.Sprintf("Perfil renombrado: %s → %s", old, p.Name))
+		},
+		a.window,
+	)
+	d.Resize(fyne.NewSize(400, 160))
+	d.Show()
+}
+
+// duplicateProfile copia el perfil seleccionado (archivo y ajustes) con
+// otro nombre, para poder cambiarle los ajustes sin tocar el original
+func (a *App) duplicateProfile() {
+	p := a.config.Current()
+	if p == nil {
+		return
+	}
+	dir, err := config.ProfilesDir()
+	if err != nil {
+		a.addLog("Error al duplicar el perfil: " + err.Error())
+		ShowError(a.window, "Error", "No se pudo preparar el directorio de perfiles")
+		return
+	}
+	path, err := profile.Copy(p.Path, dir, p.Name+" (copia)")
+	if err != nil {
+		a.addLog("Error al duplicar el perfil: " + err.Error())
+		ShowError(a.window, "No se pudo duplicar", err.Error())
+		return
+	}
+	dup, err := a.config.DuplicateProfile(p.ID, path)
+	if err != nil {
+		os.Remove(path)
+		ShowError(a.window, "No se pudo duplicar", err.Error())
+		return
+	}
+
+	a.config.CurrentProfile = dup.ID
+	a.saveConfig()
+	a.refreshProfiles()
+	a.addLog("Perfil duplicado: " + dup.Name)
+}
+
+// confirmRemoveProfile quita el perfil seleccionado de la lista. Si es una
+// copia importada (dentro del directorio de perfiles) también se borra.
+func (a *App) confirmRemoveProfile() {
+	p := a.config.Current()
+	if p == nil {
+		return
+	}
+	if a.activeProfile() == p.Path {
+		ShowError(a.window, "Error", "Desconecta la VPN antes de eliminar su perfil")
+		return
+	}
+
+	message := fmt.Sprintf("¿Quitar el perfil %s de la lista?", p.Name)
+	owned := a.ownsProfileFile(p)
+	if owned {
+		message += "\n\nTambién se borrará su copia importada."
+	}
+	dialog.ShowConfirm("Eliminar perfil", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		a.config.RemoveProfile(p.ID)
+		if owned && a.config.ProfileByPath(p.Path) == nil {
+			if err := os.Remove(p.Path); err != nil && !os.IsNotExist(err) {
+				a.addLog("No se pudo borrar " + filepath.Base(p.Path) + ": " + err.Error())
+			}
+		}
+		a.saveConfig()
+		a.refreshProfiles()
+		a.addLog("Perfil eliminado: " + p.Name)
+	}, a.window)
+}
+
+// ownsProfileFile indica si el archivo del perfil es una copia que NavTunnel
+// guardó en su directorio de perfiles
+func (a *App) ownsProfileFile(p *config.VPNProfile) bool {
+	dir, err := config.ProfilesDir()
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(p.Path) == dir
+}
+
+// saveConfig guarda la configuración, avisando si falla
+func (a *App) saveConfig() {
+	if err := a.config.Save(); err != nil {
+		a.addLog("Error al guardar configuración: " + err.Error())
+		ShowError(a.window, "Error", "No se pudo guardar la configuración")
+	}
+}
@@ -21,6 +21,14 @@ type PromptResult struct {
 // PromptCallbackWithRemember es el callback para prompts con opción de recordar
 type PromptCallbackWithRemember func(PromptResult)
 
+// CredentialsResult contiene usuario, contraseña y OTP pedidos en un solo modal
+type CredentialsResult struct {
+	Username string
+	Password string
+	OTP      string
+	Remember bool
+}
+
 // ShowUsernamePrompt muestra un modal para ingresar el usuario
 func ShowUsernamePrompt(window fyne.Window, callback PromptCallback) {
 	entry := widget.NewEntry()
@@ -222,6 +230,116 @@ func ShowOTPPrompt(window fyne.Window, callback PromptCallback) {
 	window.Canvas().Focus(entry)
 }
 
+// ShowStaticChallengePrompt muestra un modal que pide usuario, contraseña y OTP
+// juntos, para perfiles con static-challenge. El campo OTP usa el texto del
+// challenge como etiqueta y se oculta salvo que el perfil permita eco.
+func ShowStaticChallengePrompt(window fyne.Window, defaultUser, defaultPassword string, rememberDefault bool, challenge string, echo bool, callback func(CredentialsResult)) {
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder("usuario corporativo")
+	if defaultUser != "" {
+		userEntry.SetText(defaultUser)
+	}
+
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetPlaceHolder("contraseña")
+	if defaultPassword != "" {
+		passEntry.SetText(defaultPassword)
+	}
+
+	var otpEntry *widget.Entry
+	if echo {
+		otpEntry = widget.NewEntry()
+	} else {
+		otpEntry = widget.NewPasswordEntry()
+	}
+
+	rememberCheck := widget.NewCheck("Recordar credenciales", nil)
+	rememberCheck.SetChecked(rememberDefault)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Usuario:", userEntry),
+		widget.NewFormItem("Contraseña:", passEntry),
+		widget.NewFormItem(challenge+":", otpEntry),
+	)
+
+	content := container.NewVBox(
+		form,
+		rememberCheck,
+	)
+
+	d := dialog.NewCustomConfirm(
+		"Autenticación",
+		"Confirmar",
+		"Cancelar",
+		content,
+		func(submit bool) {
+			if submit && userEntry.Text != "" && passEntry.Text != "" && otpEntry.Text != "" {
+				callback(CredentialsResult{
+					Username: userEntry.Text,
+					Password: passEntry.Text,
+					OTP:      otpEntry.Text,
+					Remember: rememberCheck.Checked,
+				})
+			}
+		},
+		window,
+	)
+
+	d.Resize(fyne.NewSize(420, 260))
+	d.Show()
+
+	// Focus en el primer campo vacío
+	switch {
+	case userEntry.Text == "":
+		window.Canvas().Focus(userEntry)
+	case passEntry.Text == "":
+		window.Canvas().Focus(passEntry)
+	default:
+		window.Canvas().Focus(otpEntry)
+	}
+}
+
+// ShowChallengePrompt muestra un modal para responder a un challenge del servidor
+// (static-challenge o CRV1). El texto lo define el servidor; si echo es false
+// la respuesta se oculta como una contraseña.
+func ShowChallengePrompt(window fyne.Window, message string, echo bool, required bool, callback PromptCallback) {
+	var entry *widget.Entry
+	if echo {
+		entry = widget.NewEntry()
+	} else {
+		entry = widget.NewPasswordEntry()
+	}
+
+	label := widget.NewLabel(message)
+	label.Wrapping = fyne.TextWrapWord
+
+	content := container.NewVBox(
+		label,
+		widget.NewForm(
+			widget.NewFormItem("Respuesta:", entry),
+		),
+	)
+
+	d := dialog.NewCustomConfirm(
+		"Código OTP",
+		"Confirmar",
+		"Cancelar",
+		content,
+		func(submit bool) {
+			if submit && (entry.Text != "" || !required) {
+				callback(entry.Text)
+			}
+		},
+		window,
+	)
+
+	d.Resize(fyne.NewSize(400, 180))
+	d.Show()
+
+	// Focus en el campo de entrada
+	window.Canvas().Focus(entry)
+}
+
 // ShowError muestra un diálogo de error
 func ShowError(window fyne.Window, title, message string) {
 	dialog.ShowError(
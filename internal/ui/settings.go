@@ -0,0 +1,337 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/lavp2393/navtunnel/internal/config"
+	"github.com/lavp2393/navtunnel/internal/core"
+)
+
+// Opciones de DNS del diálogo de ajustes, en el orden en que se muestran
+var dnsModeLabels = []struct {
+	mode  string
+	label string
+}{
+	{config.DNSSplit, "Solo los dominios de la VPN (split DNS)"},
+	{config.DNSGlobal, "Todas las consultas por la VPN"},
+	{config.DNSOff, "No modificar el DNS del sistema"},
+}
+
+// Políticas al suspender y al bloquear la pantalla, en el orden en que se muestran
+var (
+	sleepPolicyLabels = []policyLabel{
+		{config.SleepRestart, "Reiniciar el túnel al volver"},
+		{config.SleepReconnect, "Desconectar y reconectar al volver"},
+		{config.SleepKeep, "No hacer nada"},
+	}
+	lockPolicyLabels = []policyLabel{
+		{config.LockKeep, "No hacer nada"},
+		{config.LockDisconnect, "Desconectar"},
+		{config.LockReconnect, "Desconectar y reconectar al desbloquear"},
+	}
+)
+
+// policyLabel es una opción de un select de políticas
+type policyLabel struct {
+	policy string
+	label  string
+}
+
+// Tipos de sondeo del chequeo de salud, en el orden en que se muestran
+var healthProbeLabels = []struct {
+	probe core.HealthProbe
+	label string
+}{
+	{core.ProbeNone, "Desactivado"},
+	{core.ProbeICMP, "Ping (ICMP)"},
+	{core.ProbeTCP, "Conexión TCP"},
+	{core.ProbeHTTP, "Petición HTTP"},
+}
+
+// showProfileSettings abre el diálogo de ajustes del perfil seleccionado
+func (a *App) showProfileSettings() {
+	p := a.config.Current()
+	if p == nil {
+		ShowError(a.window, "Error", "Primero selecciona un perfil VPN")
+		return
+	}
+	a.showProfileSettingsDraft(p.Path, *a.config.SettingsFor(p.Path))
+}
+
+// showProfileSettingsDraft muestra el diálogo con los valores indicados; si
+// las reglas no son válidas se vuelve a abrir con lo que escribió el usuario
+func (a *App) showProfileSettingsDraft(profilePath string, draft config.ProfileSettings) {
+	labels := make([]string, len(dnsModeLabels))
+	for i, m := range dnsModeLabels {
+		labels[i] = m.label
+	}
+	dnsSelect := widget.NewSelect(labels, nil)
+	dnsSelect.SetSelected(dnsModeLabel(draft.DNS))
+
+	ignorePushed := widget.NewCheck("Ignorar las rutas que envía el servidor", nil)
+	ignorePushed.SetChecked(draft.Routes.IgnorePushed)
+
+	include := widget.NewMultiLineEntry()
+	include.SetPlaceHolder("10.20.0.0/16\nintranet.example.com")
+	include.SetText(strings.Join(draft.Routes.Include, "\n"))
+
+	exclude := widget.NewMultiLineEntry()
+	exclude.SetPlaceHolder("0.0.0.0/1\nvideo.example.com")
+	exclude.SetText(strings.Join(draft.Routes.Exclude, "\n"))
+
+	killSwitch := widget.NewCheck("Bloquear el tráfico fuera de la VPN (kill switch)", nil)
+	killSwitch.SetChecked(draft.KillSwitch)
+	allowLAN := widget.NewCheck("Permitir la red local", nil)
+	allowLAN.SetChecked(draft.KillSwitchAllowLAN)
+	killSwitch.OnChanged = func(on bool) {
+		if on {
+			allowLAN.Enable()
+		} else {
+			allowLAN.Disable()
+		}
+	}
+	killSwitch.OnChanged(draft.KillSwitch)
+
+	probeLabels := make([]string, len(healthProbeLabels))
+	for i, p := range healthProbeLabels {
+		probeLabels[i] = p.label
+	}
+	probeSelect := widget.NewSelect(probeLabels, nil)
+	healthTarget := widget.NewEntry()
+	healthTarget.SetText(draft.Health.Target)
+	healthInterval := widget.NewEntry()
+	healthInterval.SetPlaceHolder("30")
+	healthFailures := widget.NewEntry()
+	healthFailures.SetPlaceHolder("3")
+	if draft.Health.IntervalSeconds > 0 {
+		healthInterval.SetText(strconv.Itoa(draft.Health.IntervalSeconds))
+	}
+	if draft.Health.Failures > 0 {
+		healthFailures.SetText(strconv.Itoa(draft.Health.Failures))
+	}
+	healthRestart := widget.NewCheck("Reiniciar la conexión al degradarse", nil)
+	healthRestart.SetChecked(draft.Health.Restart)
+	probeSelect.OnChanged = func(label string) {
+		fields := []fyne.Disableable{healthTarget, healthInterval, healthFailures, healthRestart}
+		for _, f := range fields {
+			if label == healthProbeLabels[0].label {
+				f.Disable()
+			} else {
+				f.Enable()
+			}
+		}
+		switch core.HealthProbe(healthProbeFromLabel(label)) {
+		case core.ProbeTCP:
+			healthTarget.SetPlaceHolder("intranet.example.com:443")
+		case core.ProbeHTTP:
+			healthTarget.SetPlaceHolder("https://intranet.example.com/")
+		default:
+			healthTarget.SetPlaceHolder("10.10.0.1")
+		}
+	}
+	probeSelect.SetSelected(healthProbeLabel(draft.Health.Probe))
+
+	sleepSelect := policySelect(sleepPolicyLabels, draft.OnSleep)
+	lockSelect := policySelect(lockPolicyLabels, draft.OnLock)
+
+	form := widget.NewForm(
+		widget.NewFormItem("DNS:", dnsSelect),
+		widget.NewFormItem("", ignorePushed),
+		widget.NewFormItem("Por la VPN:", include),
+		widget.NewFormItem("Fuera de la VPN:", exclude),
+		widget.NewFormItem("Kill switch:", container.NewVBox(killSwitch, allowLAN)),
+		widget.NewFormItem("Chequeo de salud:", probeSelect),
+		widget.NewFormItem("Destino:", healthTarget),
+		widget.NewFormItem("Intervalo (s):", healthInterval),
+		widget.NewFormItem("Fallos seguidos:", healthFailures),
+		widget.NewFormItem("", healthRestart),
+		widget.NewFormItem("Al suspender:", sleepSelect),
+		widget.NewFormItem("Al bloquear:", lockSelect),
+	)
+	hint := widget.NewLabel("Un destino por línea: prefijo CIDR, IP o nombre de host.\nLos cambios se aplican en la próxima conexión.")
+	hint.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm(
+		"Ajustes de "+filepath.Base(profilePath),
+		"Guardar",
+		"Cancelar",
+		container.NewVBox(form, hint),
+		func(save bool) {
+			if !save {
+				return
+			}
+
+			settings := config.ProfileSettings{
+				DNS: dnsModeFromLabel(dnsSelect.Selected),
+				Routes: config.RouteRules{
+					IgnorePushed: ignorePushed.Checked,
+					Include:      splitLines(include.Text),
+					Exclude:      splitLines(exclude.Text),
+				},
+				KillSwitch:         killSwitch.Checked,
+				KillSwitchAllowLAN: allowLAN.Checked,
+				Health: config.HealthSettings{
+					Probe:   healthProbeFromLabel(probeSelect.Selected),
+					Target:  strings.TrimSpace(healthTarget.Text),
+					Restart: healthRestart.Checked,
+				},
+				OnSleep: policyFromLabel(sleepPolicyLabels, sleepSelect.Selected),
+				OnLock:  policyFromLabel(lockPolicyLabels, lockSelect.Selected),
+			}
+			interval, errInterval := parseOptionalInt(healthInterval.Text)
+			failures, errFailures := parseOptionalInt(healthFailures.Text)
+			settings.Health.IntervalSeconds, settings.Health.Failures = interval, failures
+			if settings.Health.Probe == string(core.ProbeNone) {
+				settings.Health = config.HealthSettings{}
+			}
+			if err := routeRules(settings.Routes).Validate(); err != nil {
+				a.showProfileSettingsDraft(profilePath, settings)
+				ShowError(a.window, "Reglas de rutas inválidas", err.Error())
+				return
+			}
+
+			healthErr := errInterval
+			if healthErr == nil {
+				healthErr = errFailures
+			}
+			if healthErr == nil {
+				healthErr = healthCheck(settings.Health).Validate()
+			}
+			if healthErr != nil {
+				a.showProfileSettingsDraft(profilePath, settings)
+				ShowError(a.window, "Chequeo de salud inválido", healthErr.Error())
+				return
+			}
+
+			*a.config.SettingsFor(profilePath) = settings
+			if err := a.config.Save(); err != nil {
+				a.addLog("Error al guardar configuración: " + err.Error())
+				ShowError(a.window, "Error", "No se pudo guardar la configuración")
+				return
+			}
+			a.addLog("Ajustes del perfil guardados; se aplican en la próxima conexión")
+		},
+		a.window,
+	)
+	d.Resize(fyne.NewSize(500, 720))
+	d.Show()
+}
+
+// dnsModeLabel retorna la opción del diálogo de un modo de DNS
+func dnsModeLabel(mode string) string {
+	for _, m := range dnsModeLabels {
+		if m.mode == mode {
+			return m.label
+		}
+	}
+	return dnsModeLabels[0].label
+}
+
+// dnsModeFromLabel retorna el modo de DNS de una opción del diálogo
+func dnsModeFromLabel(label string) string {
+	for _, m := range dnsModeLabels {
+		if m.label == label {
+			return m.mode
+		}
+	}
+	return config.DNSSplit
+}
+
+// policySelect crea un select con las opciones de una política
+func policySelect(options []policyLabel, selected string) *widget.Select {
+	labels := make([]string, len(options))
+	for i, o := range options {
+		labels[i] = o.label
+	}
+	s := widget.NewSelect(labels, nil)
+	s.SetSelected(labels[0])
+	for _, o := range options {
+		if o.policy == selected {
+			s.SetSelected(o.label)
+		}
+	}
+	return s
+}
+
+// policyFromLabel retorna la política de una opción del select
+func policyFromLabel(options []policyLabel, label string) string {
+	for _, o := range options {
+		if o.label == label {
+			return o.policy
+		}
+	}
+	return options[0].policy
+}
+
+// healthProbeLabel retorna la opción del diálogo de un tipo de sondeo
+func healthProbeLabel(probe string) string {
+	for _, p := range healthProbeLabels {
+		if string(p.probe) == probe {
+			return p.label
+		}
+	}
+	return healthProbeLabels[0].label
+}
+
+// healthProbeFromLabel retorna el tipo de sondeo de una opción del diálogo
+func healthProbeFromLabel(label string) string {
+	for _, p := range healthProbeLabels {
+		if p.label == label {
+			return string(p.probe)
+		}
+	}
+	return string(core.ProbeNone)
+}
+
+// healthCheck traduce el chequeo de salud guardado al de core
+func healthCheck(settings config.HealthSettings) core.HealthCheck {
+	return core.HealthCheck{
+		Probe:    core.HealthProbe(settings.Probe),
+		Target:   settings.Target,
+		Interval: time.Duration(settings.IntervalSeconds) * time.Second,
+		Failures: settings.Failures,
+		Restart:  settings.Restart,
+	}
+}
+
+// parseOptionalInt interpreta un número positivo; vacío es 0 (valor por defecto)
+func parseOptionalInt(text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("se espera un número positivo, no %q", text)
+	}
+	return n, nil
+}
+
+// routeRules traduce las reglas de rutas guardadas a las de core
+func routeRules(rules config.RouteRules) core.RouteRules {
+	return core.RouteRules{
+		IgnorePushed: rules.IgnorePushed,
+		Include:      rules.Include,
+		Exclude:      rules.Exclude,
+	}
+}
+
+// splitLines separa un texto en líneas no vacías
+func splitLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lavp2393/navtunnel/internal/core"
+)
+
+// statsRefreshInterval es cada cuánto se actualizan las estadísticas en pantalla
+const statsRefreshInterval = time.Second
+
+// startStatsUpdates comienza a mostrar las estadísticas de la sesión
+func (a *App) startStatsUpdates(session *core.Supervisor) {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+
+	if a.statsStop != nil {
+		return // Ya en curso
+	}
+	stop := make(chan struct{})
+	a.statsStop = stop
+
+	go func() {
+		ticker := time.NewTicker(statsRefreshInterval)
+		defer ticker.Stop()
+
+		a.showStats(session.Stats())
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				// El registro se actualiza en cada muestra: si el supervisor
+				// reemplaza la sesión sin aviso previo no se pierde el tráfico
+				stats := session.Stats()
+				a.recordTraffic(stats)
+				a.showStats(stats)
+			}
+		}
+	}()
+}
+
+// stopStatsUpdates detiene la actualización y limpia las estadísticas
+func (a *App) stopStatsUpdates() {
+	a.statsMutex.Lock()
+	if a.statsStop != nil {
+		close(a.statsStop)
+		a.statsStop = nil
+	}
+	a.statsMutex.Unlock()
+
+	a.statsLabel.SetText("")
+	a.setHealth(core.Health{})
+	a.updateTrayIcon()
+}
+
+// statsRunning indica si se están mostrando las estadísticas de una conexión activa
+func (a *App) statsRunning() bool {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.statsStop != nil
+}
+
+// showStats muestra las estadísticas en la ventana y en el tooltip del tray
+func (a *App) showStats(stats core.Stats) {
+	if stats.ConnectedSince.IsZero() {
+		return
+	}
+
+	duration := time.Since(stats.ConnectedSince).Truncate(time.Second)
+	text := fmt.Sprintf(
+		"Conectado desde %s (%s)\n↓ %s (%s/s)   ↑ %s (%s/s)",
+		stats.ConnectedSince.Format("15:04:05"), duration,
+		formatBytes(float64(stats.BytesIn)), formatBytes(stats.RateIn),
+		formatBytes(float64(stats.BytesOut)), formatBytes(stats.RateOut),
+	)
+	health := a.getHealth()
+	if health != "" {
+		text += "\n" + health
+	}
+	a.statsLabel.SetText(text)
+
+	if a.trayIcon != nil && a.getState().TunnelUp() {
+		tooltip := fmt.Sprintf("NavTunnel - Conectado %s\n↓ %s/s  ↑ %s/s",
+			duration, formatBytes(stats.RateIn), formatBytes(stats.RateOut))
+		if health != "" {
+			tooltip += "\n" + health
+		}
+		a.trayIcon.SetTooltip(tooltip)
+	}
+}
+
+// setHealth guarda el último resultado del chequeo de salud para mostrarlo
+// junto a las estadísticas
+func (a *App) setHealth(health core.Health) {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	a.healthText = formatHealth(health)
+}
+
+// getHealth retorna el resumen del chequeo de salud a mostrar
+func (a *App) getHealth() string {
+	a.statsMutex.Lock()
+	defer a.statsMutex.Unlock()
+	return a.healthText
+}
+
+// formatHealth describe el chequeo de salud: "Latencia 23 ms (prom. 25 ms), pérdida 5%"
+func formatHealth(h core.Health) string {
+	if h.Latency == 0 && h.Failures == 0 {
+		return ""
+	}
+	text := "Latencia "
+	if h.Failures > 0 {
+		text += "—"
+	} else {
+		text += fmt.Sprintf("%d ms", h.Latency.Milliseconds())
+	}
+	if h.AvgLatency > 0 {
+		text += fmt.Sprintf(" (prom. %d ms)", h.AvgLatency.Milliseconds())
+	}
+	return text + fmt.Sprintf(", pérdida %.0f%%", h.Loss*100)
+}
+
+// formatBytes formatea una cantidad de bytes en unidades legibles (KiB, MiB, ...)
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	units := []string{"KiB", "MiB", "GiB", "TiB"}
+	i := -1
+	for n >= unit && i < len(units)-1 {
+		n /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
@@ -42,8 +42,10 @@ fi
 echo "🔐 Configurando permisos..."
 chmod 755 "$BUILD_DIR/DEBIAN/postinst"
 chmod 755 "$BUILD_DIR/DEBIAN/prerm"
+chmod 755 "$BUILD_DIR/usr/lib/navtunnel/navtunnel-killswitch"
 chmod 644 "$BUILD_DIR/DEBIAN/control"
 chmod 644 "$BUILD_DIR/usr/share/applications/navtunnel.desktop"
+chmod 644 "$BUILD_DIR/usr/share/polkit-1/rules.d/50-navtunnel.rules"
 chmod 644 "$BUILD_DIR/usr/share/icons/hicolor/256x256/apps/navtunnel.png"
 
 # Calcular tamaño instalado (en KB)